@@ -16,12 +16,39 @@ func Router() {
 	tw := r.Group("/tweets")
 	{
 		tw.POST("/create", controllers.AuthenticateMiddleware("tweet:create"), controllers.CreateTweet)
+		tw.POST("/thread", controllers.AuthenticateMiddleware("tweet:create"), controllers.CreateThread)
+		tw.GET("/thread/:threadId", controllers.AuthenticateMiddleware("tweet:read"), controllers.GetThread)
+		tw.POST("/requeue", controllers.AuthenticateMiddleware("tweet:update"), controllers.RequeueFailedTweets)
 		tw.GET("/:id", controllers.AuthenticateMiddleware("tweet:read"), controllers.GetTweet)
 		tw.GET("/all/:userId", controllers.AuthenticateMiddleware("tweet:read"), controllers.GetTweets)
 		tw.PUT("/:id", controllers.AuthenticateMiddleware("tweet:update"), controllers.UpdateTweet)
 		tw.DELETE("/:id", controllers.AuthenticateMiddleware("tweet:delete"), controllers.DeleteTweet)
+		tw.POST("/:id/requeue", controllers.AuthenticateMiddleware("tweet:update"), controllers.RequeueTweet)
+		tw.POST("/:id/media", controllers.AuthenticateMiddleware("tweet:update"), controllers.UploadMedia)
+		tw.GET("/:id/media", controllers.AuthenticateMiddleware("tweet:read"), controllers.ListMedia)
+		tw.DELETE("/:id/media/:mediaId", controllers.AuthenticateMiddleware("tweet:update"), controllers.DeleteMedia)
+		tw.GET("/:id/destinations", controllers.AuthenticateMiddleware("tweet:read"), controllers.ListDestinations)
+		tw.PUT("/:id/destinations/:destinationId", controllers.AuthenticateMiddleware("tweet:update"), controllers.UpdateDestination)
 	}
 	r.POST("/webhook", controllers.AuthenticateMiddleware("tweet:create"), controllers.WebHook)
+	r.GET("/outbox", controllers.AuthenticateMiddleware("admin:read"), controllers.ListOutbox)
+
+	ac := r.Group("/accounts")
+	{
+		ac.POST("", controllers.AuthenticateMiddleware("account:create"), controllers.CreateAccount)
+		ac.GET("", controllers.AuthenticateMiddleware("account:read"), controllers.ListAccounts)
+		ac.POST("/connect", controllers.AuthenticateMiddleware("account:create"), controllers.ConnectAccount)
+		ac.GET("/connect/callback", controllers.ConnectCallback)
+		ac.GET("/:id", controllers.AuthenticateMiddleware("account:read"), controllers.GetAccount)
+		ac.PUT("/:id", controllers.AuthenticateMiddleware("account:update"), controllers.UpdateAccount)
+		ac.DELETE("/:id", controllers.AuthenticateMiddleware("account:delete"), controllers.DeleteAccount)
+	}
+
+	ad := r.Group("/admin")
+	{
+		ad.GET("/status", controllers.AuthenticateMiddleware("admin:read"), controllers.GetStatus)
+		ad.GET("/health", controllers.AuthenticateMiddleware("admin:read"), controllers.GetHealth)
+	}
 
 	tk := r.Group("/token")
 	{
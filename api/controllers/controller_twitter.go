@@ -18,7 +18,8 @@ import (
 // @Accept  json
 // @Produce  json
 // @Param tweet body domain.Tweet true "Create tweet"
-// @Success 200 {object} domain.Tweet
+// @Param split query bool false "Split a message that is too long into a numbered thread, the created tweets are returned as a list"
+// @Success 201 {object} domain.Tweet "The created tweet, or a list of the created tweets when split is set"
 // @Failure 403 {object} error_utils.MessageErrStruct
 // @Failure 404 {object} error_utils.MessageErrStruct
 // @Failure 422 {object} error_utils.MessageErrStruct
@@ -36,6 +37,17 @@ func CreateTweet(c *gin.Context) {
 		return
 	}
 	tweet.Status = domain.Pending
+
+	if splitRequested(c) {
+		tweets, err := services.TweetService.CreateSplit(&tweet)
+		if err != nil {
+			c.JSON(err.Status(), err)
+			return
+		}
+		c.JSON(http.StatusCreated, tweets)
+		return
+	}
+
 	msg, err := services.TweetService.Create(&tweet)
 	if err != nil {
 		c.JSON(err.Status(), err)
@@ -174,3 +186,104 @@ func DeleteTweet(c *gin.Context) {
 
 	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
 }
+
+// CreateThread godoc
+// @Summary Create a thread of tweets posted as chained replies
+// @Tags Tweets
+// @Accept  json
+// @Produce  json
+// @Param thread body domain.Thread true "Create thread"
+// @Success 201 {array} domain.Tweet
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 422 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /tweets/thread [post]
+func CreateThread(c *gin.Context) {
+	var thread domain.Thread
+
+	if err := c.ShouldBindJSON(&thread); err != nil {
+		theErr := error_utils.UnprocessableEntityError("invalid json body")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	tweets, err := services.TweetService.CreateThread(&thread)
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	c.JSON(http.StatusCreated, tweets)
+}
+
+// GetThread godoc
+// @Summary Fetch all tweets of a thread in posting order
+// @Tags Tweets
+// @Accept  json
+// @Produce  json
+// @Param threadId path string true "Thread ID"
+// @Success 200 {array} domain.Tweet
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 404 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /tweets/thread/{threadId} [get]
+func GetThread(c *gin.Context) {
+	threadId := GetParam(c, "threadId")
+
+	tweets, getErr := services.TweetService.GetThread(threadId)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+	c.JSON(http.StatusOK, tweets)
+}
+
+// RequeueTweet godoc
+// @Summary Re-queue a failed tweet for posting
+// @Tags Tweets
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Tweet ID"
+// @Success 200 {object} domain.Tweet
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 404 {object} error_utils.MessageErrStruct
+// @Failure 422 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /tweets/{id}/requeue [post]
+func RequeueTweet(c *gin.Context) {
+	twId, parseErr := strconv.ParseInt(GetParam(c, "id"), 10, 64)
+
+	if parseErr != nil {
+		theErr := error_utils.UnprocessableEntityError("unable to parse ID")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	tweet, err := services.TweetService.Requeue(twId)
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	c.JSON(http.StatusOK, tweet)
+}
+
+// RequeueFailedTweets godoc
+// @Summary Re-queue all failed tweets for posting
+// @Tags Tweets
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} object "{requeued: 2}"
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /tweets/requeue [post]
+func RequeueFailedTweets(c *gin.Context) {
+	count, err := services.TweetService.RequeueFailed()
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]int64{"requeued": count})
+}
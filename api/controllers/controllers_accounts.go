@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/services"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAccount godoc
+// @Summary Add a Twitter, Mastodon or Bluesky account to post as
+// @Description The credentials are stored for the scheduler and are never returned by the API. Mastodon accounts only need an instance URL and access token, Bluesky accounts a handle with an app password as the access token
+// @Tags Accounts
+// @Accept  json
+// @Produce  json
+// @Param account body domain.Account true "Create Account"
+// @Success 201 {object} domain.Account
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 422 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /accounts [post]
+func CreateAccount(c *gin.Context) {
+	var account domain.Account
+
+	if err := c.ShouldBindJSON(&account); err != nil {
+		theErr := error_utils.UnprocessableEntityError("invalid json body")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	result, err := services.AccountService.Create(&account)
+
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// GetAccount godoc
+// @Summary Fetches an existing account by ID
+// @Tags Accounts
+// @Produce  json
+// @Param id path int true "Account ID"
+// @Success 200 {object} domain.Account
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 404 {object} error_utils.MessageErrStruct
+// @Failure 422 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /accounts/{id} [get]
+func GetAccount(c *gin.Context) {
+	accId, err := strconv.ParseInt(GetParam(c, "id"), 10, 64)
+
+	if err != nil {
+		theErr := error_utils.UnprocessableEntityError("unable to parse ID")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	result, getErr := services.AccountService.Get(accId)
+
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ListAccounts godoc
+// @Summary Fetches a list of all accounts
+// @Tags Accounts
+// @Produce  json
+// @Success 200 {array} domain.Account
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 404 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /accounts [get]
+func ListAccounts(c *gin.Context) {
+	result, err := services.AccountService.List()
+
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// UpdateAccount godoc
+// @Summary Renames an account or replaces its credentials
+// @Description The credentials are only replaced when a full set for the account's network is provided, the network cannot be changed
+// @Tags Accounts
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Account ID"
+// @Param account body domain.Account true "Update Account"
+// @Success 200 {object} domain.Account
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 404 {object} error_utils.MessageErrStruct
+// @Failure 422 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /accounts/{id} [put]
+func UpdateAccount(c *gin.Context) {
+	accId, err := strconv.ParseInt(GetParam(c, "id"), 10, 64)
+
+	if err != nil {
+		theErr := error_utils.UnprocessableEntityError("unable to parse ID")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	var account domain.Account
+
+	if err := c.ShouldBindJSON(&account); err != nil {
+		theErr := error_utils.UnprocessableEntityError("invalid json body")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	account.Id = accId
+
+	result, updateErr := services.AccountService.Update(&account)
+
+	if updateErr != nil {
+		c.JSON(updateErr.Status(), updateErr)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// DeleteAccount godoc
+// @Summary Deletes the specified account
+// @Description Accounts that tweets or destinations still post as cannot be deleted
+// @Tags Accounts
+// @Produce  json
+// @Param id path int true "Account ID"
+// @Success 200 {object} object "{status: "deleted"}"
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 404 {object} error_utils.MessageErrStruct
+// @Failure 422 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /accounts/{id} [delete]
+func DeleteAccount(c *gin.Context) {
+	accId, parseErr := strconv.ParseInt(GetParam(c, "id"), 10, 64)
+
+	if parseErr != nil {
+		theErr := error_utils.UnprocessableEntityError("unable to parse ID")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	if err := services.AccountService.Delete(accId); err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/RemeJuan/lattr/utils/scheduler"
+	"github.com/gin-gonic/gin"
+)
+
+// schedulerStatus is swapped out in tests
+var schedulerStatus = scheduler.GetStatus
+
+// GetStatus godoc
+// @Summary Show the state of the posting queue
+// @Description Reports the current rate limit window and the state of each account's credentials
+// @Tags Admin
+// @Produce  json
+// @Success 200 {object} scheduler.Status
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /admin/status [get]
+func GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, schedulerStatus())
+}
+
+// schedulerHealth is swapped out in tests
+var schedulerHealth = scheduler.GetHealth
+
+// GetHealth godoc
+// @Summary Show the health of the posting credentials
+// @Description Reports the last check of each account's credentials, responds with 503 while any of them are rejected. Tweets for an account with rejected credentials are held until the credentials are accepted again
+// @Tags Admin
+// @Produce  json
+// @Success 200 {object} scheduler.Health
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 503 {object} scheduler.Health
+// @Security ApiKeyAuth
+// @Router /admin/health [get]
+func GetHealth(c *gin.Context) {
+	health := schedulerHealth()
+
+	if !health.Healthy {
+		c.JSON(http.StatusServiceUnavailable, health)
+		return
+	}
+	c.JSON(http.StatusOK, health)
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/services"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ConnectAccount godoc
+// @Summary Start connecting a Twitter account
+// @Description Returns the Twitter URL the account owner has to visit to authorize lattr,
+// @Description once authorized Twitter redirects back to the callback which stores the account
+// @Tags Accounts
+// @Accept  json
+// @Produce  json
+// @Param connection body domain.Connection true "Name of the account to connect"
+// @Success 201 {object} domain.Connection
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 422 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /accounts/connect [post]
+func ConnectAccount(c *gin.Context) {
+	var connection domain.Connection
+
+	if err := c.ShouldBindJSON(&connection); err != nil {
+		theErr := error_utils.UnprocessableEntityError("invalid json body")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	result, err := services.ConnectService.Start(&connection)
+
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// ConnectCallback godoc
+// @Summary OAuth callback Twitter redirects to after the account owner responds
+// @Description Stores the authorized account, the request token identifies the pending connection
+// @Tags Accounts
+// @Produce  json
+// @Param oauth_token query string true "Request token"
+// @Param oauth_verifier query string false "Verifier, present when the owner authorized the app"
+// @Param denied query string false "Request token, present when the owner declined"
+// @Success 201 {object} domain.Account
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 404 {object} error_utils.MessageErrStruct
+// @Failure 422 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Router /accounts/connect/callback [get]
+func ConnectCallback(c *gin.Context) {
+	if denied := c.Query("denied"); denied != "" {
+		if err := services.ConnectService.Cancel(denied); err != nil {
+			c.JSON(err.Status(), err)
+			return
+		}
+
+		theErr := error_utils.ForbiddenError("Authorization was declined")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	token := c.Query("oauth_token")
+	verifier := c.Query("oauth_verifier")
+
+	if token == "" || verifier == "" {
+		theErr := error_utils.UnprocessableEntityError("oauth_token and oauth_verifier are required")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	result, err := services.ConnectService.Complete(token, verifier)
+
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/services"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ListDestinations godoc
+// @Summary List the destinations a tweet is cross-posted to
+// @Tags Tweets
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Tweet ID"
+// @Success 200 {array} domain.Destination
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 404 {object} error_utils.MessageErrStruct
+// @Failure 422 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /tweets/{id}/destinations [get]
+func ListDestinations(c *gin.Context) {
+	twId, parseErr := strconv.ParseInt(GetParam(c, "id"), 10, 64)
+
+	if parseErr != nil {
+		theErr := error_utils.UnprocessableEntityError("unable to parse ID")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	destinations, getErr := services.DestinationService.List(twId)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+	c.JSON(http.StatusOK, destinations)
+}
+
+// UpdateDestination godoc
+// @Summary Skips a destination or re-queues it for another attempt
+// @Description Only the status can be changed, to Skipped or Pending, posted destinations cannot be changed
+// @Tags Tweets
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Tweet ID"
+// @Param destinationId path int true "Destination ID"
+// @Param destination body domain.Destination true "Update Destination"
+// @Success 200 {object} domain.Destination
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 404 {object} error_utils.MessageErrStruct
+// @Failure 422 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /tweets/{id}/destinations/{destinationId} [put]
+func UpdateDestination(c *gin.Context) {
+	twId, parseErr := strconv.ParseInt(GetParam(c, "id"), 10, 64)
+	destinationId, destinationParseErr := strconv.ParseInt(GetParam(c, "destinationId"), 10, 64)
+
+	if parseErr != nil || destinationParseErr != nil {
+		theErr := error_utils.UnprocessableEntityError("unable to parse ID")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	var destination domain.Destination
+
+	if err := c.ShouldBindJSON(&destination); err != nil {
+		theErr := error_utils.UnprocessableEntityError("invalid json body")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	destination.Id = destinationId
+
+	result, updateErr := services.DestinationService.Update(twId, &destination)
+
+	if updateErr != nil {
+		c.JSON(updateErr.Status(), updateErr)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/RemeJuan/lattr/domain"
@@ -14,6 +15,12 @@ func GetParam(c *gin.Context, paramName string) string {
 	return c.Params.ByName(paramName)
 }
 
+// splitRequested reports whether the split query parameter asks for long messages to be split into a thread
+func splitRequested(c *gin.Context) bool {
+	split, _ := strconv.ParseBool(c.Query("split"))
+	return split
+}
+
 func TokenCreateMiddleWare(requiredScope string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenCreate := os.Getenv("ENABLE_CREATE")
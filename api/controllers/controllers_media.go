@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/services"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/gin-gonic/gin"
+)
+
+// UploadMedia godoc
+// @Summary Attach an image to a tweet
+// @Tags Media
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param id path int true "Tweet ID"
+// @Param media formData file true "Image to attach"
+// @Param altText formData string false "Alt text describing the image"
+// @Success 201 {object} domain.Media
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 404 {object} error_utils.MessageErrStruct
+// @Failure 422 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /tweets/{id}/media [post]
+func UploadMedia(c *gin.Context) {
+	twId, parseErr := strconv.ParseInt(GetParam(c, "id"), 10, 64)
+
+	if parseErr != nil {
+		theErr := error_utils.UnprocessableEntityError("unable to parse ID")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	file, err := c.FormFile("media")
+	if err != nil {
+		theErr := error_utils.UnprocessableEntityError("media file is required")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	if file.Size > domain.MaxMediaSize {
+		theErr := error_utils.UnprocessableEntityError("media file is too large")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		theErr := error_utils.UnprocessableEntityError("unable to read media file")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		theErr := error_utils.UnprocessableEntityError("unable to read media file")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	media := &domain.Media{
+		TweetId:  twId,
+		MimeType: http.DetectContentType(data),
+		AltText:  c.PostForm("altText"),
+		Data:     data,
+	}
+
+	md, createErr := services.MediaService.Create(media)
+	if createErr != nil {
+		c.JSON(createErr.Status(), createErr)
+		return
+	}
+	c.JSON(http.StatusCreated, md)
+}
+
+// ListMedia godoc
+// @Summary List the images attached to a tweet
+// @Tags Media
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Tweet ID"
+// @Success 200 {array} domain.Media
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 404 {object} error_utils.MessageErrStruct
+// @Failure 422 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /tweets/{id}/media [get]
+func ListMedia(c *gin.Context) {
+	twId, parseErr := strconv.ParseInt(GetParam(c, "id"), 10, 64)
+
+	if parseErr != nil {
+		theErr := error_utils.UnprocessableEntityError("unable to parse ID")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	media, getErr := services.MediaService.List(twId)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+	c.JSON(http.StatusOK, media)
+}
+
+// DeleteMedia godoc
+// @Summary Remove an image from a tweet
+// @Tags Media
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Tweet ID"
+// @Param mediaId path int true "Media ID"
+// @Success 200 {object} object "{status: "deleted"}"
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 404 {object} error_utils.MessageErrStruct
+// @Failure 422 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /tweets/{id}/media/{mediaId} [delete]
+func DeleteMedia(c *gin.Context) {
+	twId, parseErr := strconv.ParseInt(GetParam(c, "id"), 10, 64)
+	mediaId, mediaParseErr := strconv.ParseInt(GetParam(c, "mediaId"), 10, 64)
+
+	if parseErr != nil || mediaParseErr != nil {
+		theErr := error_utils.UnprocessableEntityError("unable to parse ID")
+		c.JSON(theErr.Status(), theErr)
+		return
+	}
+
+	if err := services.MediaService.Delete(twId, mediaId); err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
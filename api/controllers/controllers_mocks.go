@@ -6,19 +6,39 @@ import (
 )
 
 var (
-	createTweetService     func(message *domain.Tweet) (*domain.Tweet, error_utils.MessageErr)
-	getTweetService        func(msgId int64) (*domain.Tweet, error_utils.MessageErr)
-	updateTweetService     func(tweet *domain.Tweet) (*domain.Tweet, error_utils.MessageErr)
-	deleteTweetService     func(msgId int64) error_utils.MessageErr
-	getAllTweetService     func(userId string) ([]domain.Tweet, error_utils.MessageErr)
-	getPendingTweetService func() ([]domain.Tweet, error_utils.MessageErr)
-	getLastTweet           func() (*domain.Tweet, error_utils.MessageErr)
-	createTokenService     func(token *domain.Token) (*domain.Token, error_utils.MessageErr)
-	getTokenService        func(id int64) (*domain.Token, error_utils.MessageErr)
-	listTokensService      func() ([]domain.Token, error_utils.MessageErr)
-	resetTokensService     func(token *domain.Token) (*domain.Token, error_utils.MessageErr)
-	deleteTokensService    func(id int64) error_utils.MessageErr
-	validateTokenService   func(token *domain.Token, requiredScope string) bool
+	createTweetService       func(message *domain.Tweet) (*domain.Tweet, error_utils.MessageErr)
+	getTweetService          func(msgId int64) (*domain.Tweet, error_utils.MessageErr)
+	updateTweetService       func(tweet *domain.Tweet) (*domain.Tweet, error_utils.MessageErr)
+	deleteTweetService       func(msgId int64) error_utils.MessageErr
+	getAllTweetService       func(userId string) ([]domain.Tweet, error_utils.MessageErr)
+	getPendingTweetService   func(limit int) ([]domain.Tweet, error_utils.MessageErr)
+	getLastTweet             func() (*domain.Tweet, error_utils.MessageErr)
+	createThreadService      func(thread *domain.Thread) ([]domain.Tweet, error_utils.MessageErr)
+	createSplitService       func(tweet *domain.Tweet) ([]domain.Tweet, error_utils.MessageErr)
+	getThreadService         func(threadId string) ([]domain.Tweet, error_utils.MessageErr)
+	requeueTweetService      func(id int64) (*domain.Tweet, error_utils.MessageErr)
+	requeueFailedService     func() (int64, error_utils.MessageErr)
+	createTokenService       func(token *domain.Token) (*domain.Token, error_utils.MessageErr)
+	getTokenService          func(id int64) (*domain.Token, error_utils.MessageErr)
+	listTokensService        func() ([]domain.Token, error_utils.MessageErr)
+	resetTokensService       func(token *domain.Token) (*domain.Token, error_utils.MessageErr)
+	deleteTokensService      func(id int64) error_utils.MessageErr
+	validateTokenService     func(token *domain.Token, requiredScope string) bool
+	createMediaService       func(media *domain.Media) (*domain.Media, error_utils.MessageErr)
+	listMediaService         func(tweetId int64) ([]domain.Media, error_utils.MessageErr)
+	deleteMediaService       func(tweetId int64, id int64) error_utils.MessageErr
+	listDestinationService   func(tweetId int64) ([]domain.Destination, error_utils.MessageErr)
+	updateDestinationService func(tweetId int64, destination *domain.Destination) (*domain.Destination, error_utils.MessageErr)
+	listOutboxService        func() ([]domain.OutboxEntry, error_utils.MessageErr)
+	createAccountService     func(account *domain.Account) (*domain.Account, error_utils.MessageErr)
+	getAccountService        func(id int64) (*domain.Account, error_utils.MessageErr)
+	listAccountsService      func() ([]domain.Account, error_utils.MessageErr)
+	updateAccountService     func(account *domain.Account) (*domain.Account, error_utils.MessageErr)
+	deleteAccountService     func(id int64) error_utils.MessageErr
+	reencryptService         func() (int, error_utils.MessageErr)
+	startConnectService      func(connection *domain.Connection) (*domain.Connection, error_utils.MessageErr)
+	completeConnectService   func(requestToken string, verifier string) (*domain.Account, error_utils.MessageErr)
+	cancelConnectService     func(requestToken string) error_utils.MessageErr
 )
 
 type tweetServiceMock struct {
@@ -44,14 +64,34 @@ func (sm *tweetServiceMock) Delete(id int64) error_utils.MessageErr {
 	return deleteTweetService(id)
 }
 
-func (sm *tweetServiceMock) GetPending() ([]domain.Tweet, error_utils.MessageErr) {
-	return getPendingTweetService()
+func (sm *tweetServiceMock) GetPending(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+	return getPendingTweetService(limit)
 }
 
 func (sm *tweetServiceMock) GetLast() (*domain.Tweet, error_utils.MessageErr) {
 	return getLastTweet()
 }
 
+func (sm *tweetServiceMock) CreateThread(thread *domain.Thread) ([]domain.Tweet, error_utils.MessageErr) {
+	return createThreadService(thread)
+}
+
+func (sm *tweetServiceMock) CreateSplit(tweet *domain.Tweet) ([]domain.Tweet, error_utils.MessageErr) {
+	return createSplitService(tweet)
+}
+
+func (sm *tweetServiceMock) GetThread(threadId string) ([]domain.Tweet, error_utils.MessageErr) {
+	return getThreadService(threadId)
+}
+
+func (sm *tweetServiceMock) Requeue(id int64) (*domain.Tweet, error_utils.MessageErr) {
+	return requeueTweetService(id)
+}
+
+func (sm *tweetServiceMock) RequeueFailed() (int64, error_utils.MessageErr) {
+	return requeueFailedService()
+}
+
 type authServiceMock struct{}
 
 func (asm *authServiceMock) Create(token *domain.Token) (*domain.Token, error_utils.MessageErr) {
@@ -77,3 +117,73 @@ func (asm *authServiceMock) Delete(id int64) error_utils.MessageErr {
 func (asm *authServiceMock) ValidateToken(token *domain.Token, requiredScope string) bool {
 	return validateTokenService(token, requiredScope)
 }
+
+type mediaServiceMock struct{}
+
+func (msm *mediaServiceMock) Create(media *domain.Media) (*domain.Media, error_utils.MessageErr) {
+	return createMediaService(media)
+}
+
+func (msm *mediaServiceMock) List(tweetId int64) ([]domain.Media, error_utils.MessageErr) {
+	return listMediaService(tweetId)
+}
+
+func (msm *mediaServiceMock) Delete(tweetId int64, id int64) error_utils.MessageErr {
+	return deleteMediaService(tweetId, id)
+}
+
+type destinationServiceMock struct{}
+
+func (dsm *destinationServiceMock) List(tweetId int64) ([]domain.Destination, error_utils.MessageErr) {
+	return listDestinationService(tweetId)
+}
+
+func (dsm *destinationServiceMock) Update(tweetId int64, destination *domain.Destination) (*domain.Destination, error_utils.MessageErr) {
+	return updateDestinationService(tweetId, destination)
+}
+
+type outboxServiceMock struct{}
+
+func (osm *outboxServiceMock) List() ([]domain.OutboxEntry, error_utils.MessageErr) {
+	return listOutboxService()
+}
+
+type accountServiceMock struct{}
+
+func (acm *accountServiceMock) Create(account *domain.Account) (*domain.Account, error_utils.MessageErr) {
+	return createAccountService(account)
+}
+
+func (acm *accountServiceMock) Get(id int64) (*domain.Account, error_utils.MessageErr) {
+	return getAccountService(id)
+}
+
+func (acm *accountServiceMock) List() ([]domain.Account, error_utils.MessageErr) {
+	return listAccountsService()
+}
+
+func (acm *accountServiceMock) Update(account *domain.Account) (*domain.Account, error_utils.MessageErr) {
+	return updateAccountService(account)
+}
+
+func (acm *accountServiceMock) Delete(id int64) error_utils.MessageErr {
+	return deleteAccountService(id)
+}
+
+func (acm *accountServiceMock) Reencrypt() (int, error_utils.MessageErr) {
+	return reencryptService()
+}
+
+type connectServiceMock struct{}
+
+func (csm *connectServiceMock) Start(connection *domain.Connection) (*domain.Connection, error_utils.MessageErr) {
+	return startConnectService(connection)
+}
+
+func (csm *connectServiceMock) Complete(requestToken string, verifier string) (*domain.Account, error_utils.MessageErr) {
+	return completeConnectService(requestToken, verifier)
+}
+
+func (csm *connectServiceMock) Cancel(requestToken string) error_utils.MessageErr {
+	return cancelConnectService(requestToken)
+}
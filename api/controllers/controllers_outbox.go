@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/RemeJuan/lattr/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ListOutbox godoc
+// @Summary List the posts recorded in dry-run mode
+// @Description With DRY_RUN enabled the scheduler records every post it would have published in the outbox instead of posting it, newest first
+// @Tags Admin
+// @Produce  json
+// @Success 200 {array} domain.OutboxEntry
+// @Failure 403 {object} error_utils.MessageErrStruct
+// @Failure 404 {object} error_utils.MessageErrStruct
+// @Failure 500 {object} error_utils.MessageErrStruct
+// @Security ApiKeyAuth
+// @Router /outbox [get]
+func ListOutbox(c *gin.Context) {
+	entries, err := services.OutboxService.List()
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
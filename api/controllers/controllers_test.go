@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -14,6 +15,8 @@ import (
 	"github.com/RemeJuan/lattr/domain"
 	"github.com/RemeJuan/lattr/services"
 	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/RemeJuan/lattr/utils/scheduler"
 	"github.com/RemeJuan/lattr/utils/webhook"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -115,6 +118,64 @@ func TestTweets(t *testing.T) {
 			assert.EqualValues(t, "Body cannot be empty", msgErr.Message())
 			assert.EqualValues(t, "invalid_request", msgErr.Error())
 		})
+
+		t.Run("Split", func(t *testing.T) {
+			services.TweetService = &tweetServiceMock{}
+
+			var submitted domain.Tweet
+			createSplitService = func(tweet *domain.Tweet) ([]domain.Tweet, error_utils.MessageErr) {
+				submitted = *tweet
+				return []domain.Tweet{
+					{Id: 1, Message: "first 1/2", ThreadId: "thread", ThreadPosition: 0},
+					{Id: 2, Message: "second 2/2", ThreadId: "thread", ThreadPosition: 1},
+				}, nil
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+			jsonBody := `{"message": "first second"}`
+			r := gin.Default()
+			req, err := http.NewRequest(http.MethodPost, tweetPath+"?split=true", bytes.NewBufferString(jsonBody))
+			if err != nil {
+				t.Errorf("this is the error: %v\n", err)
+			}
+			rr := httptest.NewRecorder()
+			r.POST(tweetPath, middleware, CreateTweet)
+			r.ServeHTTP(rr, req)
+
+			var tweets []domain.Tweet
+			err = json.Unmarshal(rr.Body.Bytes(), &tweets)
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusCreated, rr.Code)
+			assert.Len(t, tweets, 2)
+			assert.EqualValues(t, "second 2/2", tweets[1].Message)
+			assert.EqualValues(t, domain.Pending, submitted.Status)
+		})
+
+		t.Run("Split Error", func(t *testing.T) {
+			services.TweetService = &tweetServiceMock{}
+
+			createSplitService = func(tweet *domain.Tweet) ([]domain.Tweet, error_utils.MessageErr) {
+				return nil, error_utils.UnprocessableEntityError("A thread cannot have more than 25 messages")
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+			jsonBody := `{"message": "the message"}`
+			r := gin.Default()
+			req, err := http.NewRequest(http.MethodPost, tweetPath+"?split=true", bytes.NewBufferString(jsonBody))
+			if err != nil {
+				t.Errorf("this is the error: %v\n", err)
+			}
+			rr := httptest.NewRecorder()
+			r.POST(tweetPath, middleware, CreateTweet)
+			r.ServeHTTP(rr, req)
+
+			msgErr, err := error_utils.ApiErrFromBytes(rr.Body.Bytes())
+
+			assert.EqualValues(t, http.StatusUnprocessableEntity, msgErr.Status())
+			assert.EqualValues(t, "A thread cannot have more than 25 messages", msgErr.Message())
+		})
 	})
 
 	t.Run("GetTweet", func(t *testing.T) {
@@ -128,10 +189,13 @@ func TestTweets(t *testing.T) {
 
 			getTweetService = func(msgId int64) (*domain.Tweet, error_utils.MessageErr) {
 				return &domain.Tweet{
-					Id:       recordId,
-					Message:  message,
-					PostTime: postTime,
-					Status:   domain.Pending,
+					Id:        recordId,
+					Message:   message,
+					PostTime:  postTime,
+					Status:    domain.Posted,
+					RemoteId:  "1436255364069150720",
+					RemoteUrl: "https://twitter.com/lattr/status/1436255364069150720",
+					PostedAt:  &postTime,
 				}, nil
 			}
 			validateTokenService = func(token *domain.Token, requiredScope string) bool {
@@ -154,7 +218,10 @@ func TestTweets(t *testing.T) {
 			assert.EqualValues(t, recordId, tweet.Id)
 			assert.EqualValues(t, message, tweet.Message)
 			assert.EqualValues(t, postTime, tweet.PostTime)
-			assert.EqualValues(t, domain.Pending, tweet.Status)
+			assert.EqualValues(t, domain.Posted, tweet.Status)
+			assert.EqualValues(t, "1436255364069150720", tweet.RemoteId)
+			assert.EqualValues(t, "https://twitter.com/lattr/status/1436255364069150720", tweet.RemoteUrl)
+			assert.EqualValues(t, postTime, *tweet.PostedAt)
 		})
 
 		t.Run("Cannot parse ID", func(t *testing.T) {
@@ -479,6 +546,169 @@ func TestTweets(t *testing.T) {
 			assert.Equal(t, "server_error", apiErr.Error())
 		})
 	})
+
+	t.Run("CreateThread", func(t *testing.T) {
+		middleware := AuthenticateMiddleware("tweet:create")
+
+		t.Run("Success", func(t *testing.T) {
+			services.TweetService = &tweetServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			createThreadService = func(thread *domain.Thread) ([]domain.Tweet, error_utils.MessageErr) {
+				return thread.Tweets("thread", domain.Pending), nil
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			jsonBody := `{"userId": "001", "messages": ["first", "second"]}`
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodPost, tweetPath+"/thread", bytes.NewBufferString(jsonBody))
+			rr := httptest.NewRecorder()
+			r.POST("/tweets/thread", middleware, CreateThread)
+			r.ServeHTTP(rr, req)
+
+			var tweets []domain.Tweet
+			err := json.Unmarshal(rr.Body.Bytes(), &tweets)
+
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusCreated, rr.Code)
+			assert.Len(t, tweets, 2)
+			assert.EqualValues(t, "second", tweets[1].Message)
+			assert.EqualValues(t, 1, tweets[1].ThreadPosition)
+		})
+
+		t.Run("Invalid JSON", func(t *testing.T) {
+			services.AuthService = &authServiceMock{}
+
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodPost, tweetPath+"/thread", bytes.NewBufferString(""))
+			rr := httptest.NewRecorder()
+			r.POST("/tweets/thread", middleware, CreateThread)
+			r.ServeHTTP(rr, req)
+
+			apiErr, _ := error_utils.ApiErrFromBytes(rr.Body.Bytes())
+
+			assert.EqualValues(t, http.StatusUnprocessableEntity, apiErr.Status())
+			assert.EqualValues(t, "invalid json body", apiErr.Message())
+		})
+	})
+
+	t.Run("GetThread", func(t *testing.T) {
+		middleware := AuthenticateMiddleware("tweet:read")
+
+		t.Run("Success", func(t *testing.T) {
+			services.TweetService = &tweetServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			getThreadService = func(threadId string) ([]domain.Tweet, error_utils.MessageErr) {
+				return []domain.Tweet{{Id: 1, ThreadId: threadId}, {Id: 2, ThreadId: threadId, ThreadPosition: 1}}, nil
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodGet, tweetPath+"/thread/abc", nil)
+			rr := httptest.NewRecorder()
+			r.GET("/tweets/thread/:threadId", middleware, GetThread)
+			r.ServeHTTP(rr, req)
+
+			var tweets []domain.Tweet
+			err := json.Unmarshal(rr.Body.Bytes(), &tweets)
+
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusOK, rr.Code)
+			assert.Len(t, tweets, 2)
+			assert.EqualValues(t, "abc", tweets[0].ThreadId)
+		})
+	})
+
+	t.Run("RequeueTweet", func(t *testing.T) {
+		middleware := AuthenticateMiddleware("tweet:update")
+
+		t.Run("Success", func(t *testing.T) {
+			services.TweetService = &tweetServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			requeueTweetService = func(id int64) (*domain.Tweet, error_utils.MessageErr) {
+				return &domain.Tweet{Id: id, Status: domain.Pending}, nil
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/%v/requeue", tweetPath, recordId), nil)
+			rr := httptest.NewRecorder()
+			r.POST("/tweets/:id/requeue", middleware, RequeueTweet)
+			r.ServeHTTP(rr, req)
+
+			var tweet domain.Tweet
+			err := json.Unmarshal(rr.Body.Bytes(), &tweet)
+
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusOK, rr.Code)
+			assert.EqualValues(t, recordId, tweet.Id)
+			assert.EqualValues(t, domain.Pending, tweet.Status)
+		})
+
+		t.Run("Not failed", func(t *testing.T) {
+			services.TweetService = &tweetServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			requeueTweetService = func(id int64) (*domain.Tweet, error_utils.MessageErr) {
+				return nil, error_utils.UnprocessableEntityError("Only failed tweets can be re-queued")
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/%v/requeue", tweetPath, recordId), nil)
+			rr := httptest.NewRecorder()
+			r.POST("/tweets/:id/requeue", middleware, RequeueTweet)
+			r.ServeHTTP(rr, req)
+
+			apiErr, _ := error_utils.ApiErrFromBytes(rr.Body.Bytes())
+
+			assert.EqualValues(t, http.StatusUnprocessableEntity, apiErr.Status())
+			assert.EqualValues(t, "Only failed tweets can be re-queued", apiErr.Message())
+		})
+	})
+
+	t.Run("RequeueFailedTweets", func(t *testing.T) {
+		middleware := AuthenticateMiddleware("tweet:update")
+
+		t.Run("Success", func(t *testing.T) {
+			services.TweetService = &tweetServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			requeueFailedService = func() (int64, error_utils.MessageErr) {
+				return 3, nil
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodPost, tweetPath+"/requeue", nil)
+			rr := httptest.NewRecorder()
+			r.POST("/tweets/requeue", middleware, RequeueFailedTweets)
+			r.ServeHTTP(rr, req)
+
+			var result map[string]int64
+			err := json.Unmarshal(rr.Body.Bytes(), &result)
+
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusOK, rr.Code)
+			assert.EqualValues(t, 3, result["requeued"])
+		})
+	})
 }
 
 func TestWebHook(t *testing.T) {
@@ -623,6 +853,41 @@ func TestWebHook(t *testing.T) {
 		assert.EqualValues(t, "Body cannot be empty", msgErr.Message())
 		assert.EqualValues(t, "invalid_request", msgErr.Error())
 	})
+
+	t.Run("Split", func(t *testing.T) {
+		services.TweetService = &tweetServiceMock{}
+		services.AuthService = &authServiceMock{}
+
+		getLastTweet = func() (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, PostTime: postTime}, nil
+		}
+		var submitted domain.Tweet
+		createSplitService = func(tweet *domain.Tweet) ([]domain.Tweet, error_utils.MessageErr) {
+			submitted = *tweet
+			return []domain.Tweet{{Id: 1, Message: "first 1/2"}, {Id: 2, Message: "second 2/2"}}, nil
+		}
+		validateTokenService = func(token *domain.Token, requiredScope string) bool {
+			return true
+		}
+
+		jsonBody := `{"message": "first second"}`
+		r := gin.Default()
+		req, err := http.NewRequest(http.MethodPost, tweetPath+"?split=true", bytes.NewBufferString(jsonBody))
+		if err != nil {
+			t.Errorf("this is the error: %v\n", err)
+		}
+		rr := httptest.NewRecorder()
+		r.POST(tweetPath, middleware, WebHook)
+		r.ServeHTTP(rr, req)
+
+		var tweets []domain.Tweet
+		err = json.Unmarshal(rr.Body.Bytes(), &tweets)
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusCreated, rr.Code)
+		assert.Len(t, tweets, 2)
+		assert.EqualValues(t, domain.Scheduled, submitted.Status)
+		assert.False(t, submitted.PostTime.IsZero())
+	})
 }
 
 func TestAuthControllers(t *testing.T) {
@@ -1154,3 +1419,743 @@ func TestAuthControllers(t *testing.T) {
 		})
 	})
 }
+
+func TestMedia(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const tweetId int64 = 1
+	const mediaId int64 = 2
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	t.Run("UploadMedia", func(t *testing.T) {
+		middleware := AuthenticateMiddleware("tweet:update")
+
+		upload := func(fieldName string, data []byte) *http.Request {
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			part, _ := writer.CreateFormFile(fieldName, "image.png")
+			_, _ = part.Write(data)
+			_ = writer.WriteField("altText", "the alt text")
+			_ = writer.Close()
+
+			req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/%v/media", tweetPath, tweetId), body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			return req
+		}
+
+		t.Run("Success", func(t *testing.T) {
+			services.MediaService = &mediaServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			var received *domain.Media
+			createMediaService = func(media *domain.Media) (*domain.Media, error_utils.MessageErr) {
+				received = media
+				media.Id = mediaId
+				return media, nil
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			rr := httptest.NewRecorder()
+			r.POST("/tweets/:id/media", middleware, UploadMedia)
+			r.ServeHTTP(rr, upload("media", png))
+
+			var media domain.Media
+			err := json.Unmarshal(rr.Body.Bytes(), &media)
+
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusCreated, rr.Code)
+			assert.EqualValues(t, mediaId, media.Id)
+			assert.EqualValues(t, tweetId, received.TweetId)
+			assert.EqualValues(t, "image/png", received.MimeType)
+			assert.EqualValues(t, "the alt text", received.AltText)
+			assert.EqualValues(t, png, received.Data)
+		})
+
+		t.Run("Missing file", func(t *testing.T) {
+			services.MediaService = &mediaServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			rr := httptest.NewRecorder()
+			r.POST("/tweets/:id/media", middleware, UploadMedia)
+			r.ServeHTTP(rr, upload("other", png))
+
+			apiErr, _ := error_utils.ApiErrFromBytes(rr.Body.Bytes())
+
+			assert.EqualValues(t, http.StatusUnprocessableEntity, apiErr.Status())
+			assert.EqualValues(t, "media file is required", apiErr.Message())
+		})
+
+		t.Run("Create Error", func(t *testing.T) {
+			services.MediaService = &mediaServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			createMediaService = func(media *domain.Media) (*domain.Media, error_utils.MessageErr) {
+				return nil, error_utils.UnprocessableEntityError("A tweet can have at most 4 media attachments")
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			rr := httptest.NewRecorder()
+			r.POST("/tweets/:id/media", middleware, UploadMedia)
+			r.ServeHTTP(rr, upload("media", png))
+
+			apiErr, _ := error_utils.ApiErrFromBytes(rr.Body.Bytes())
+
+			assert.EqualValues(t, http.StatusUnprocessableEntity, apiErr.Status())
+			assert.EqualValues(t, "A tweet can have at most 4 media attachments", apiErr.Message())
+		})
+	})
+
+	t.Run("ListMedia", func(t *testing.T) {
+		middleware := AuthenticateMiddleware("tweet:read")
+
+		t.Run("Success", func(t *testing.T) {
+			services.MediaService = &mediaServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			listMediaService = func(id int64) ([]domain.Media, error_utils.MessageErr) {
+				return []domain.Media{{Id: mediaId, TweetId: id, MimeType: "image/png", Data: png}}, nil
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%v/media", tweetPath, tweetId), nil)
+			rr := httptest.NewRecorder()
+			r.GET("/tweets/:id/media", middleware, ListMedia)
+			r.ServeHTTP(rr, req)
+
+			var media []domain.Media
+			err := json.Unmarshal(rr.Body.Bytes(), &media)
+
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusOK, rr.Code)
+			assert.Len(t, media, 1)
+			assert.EqualValues(t, tweetId, media[0].TweetId)
+			assert.Nil(t, media[0].Data)
+		})
+	})
+
+	t.Run("DeleteMedia", func(t *testing.T) {
+		middleware := AuthenticateMiddleware("tweet:update")
+
+		t.Run("Success", func(t *testing.T) {
+			services.MediaService = &mediaServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			deleteMediaService = func(twId int64, id int64) error_utils.MessageErr {
+				assert.EqualValues(t, tweetId, twId)
+				assert.EqualValues(t, mediaId, id)
+				return nil
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/%v/media/%v", tweetPath, tweetId, mediaId), nil)
+			rr := httptest.NewRecorder()
+			r.DELETE("/tweets/:id/media/:mediaId", middleware, DeleteMedia)
+			r.ServeHTTP(rr, req)
+
+			assert.EqualValues(t, http.StatusOK, rr.Code)
+		})
+
+		t.Run("Cannot parse ID", func(t *testing.T) {
+			services.AuthService = &authServiceMock{}
+
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/%v/media/red", tweetPath, tweetId), nil)
+			rr := httptest.NewRecorder()
+			r.DELETE("/tweets/:id/media/:mediaId", middleware, DeleteMedia)
+			r.ServeHTTP(rr, req)
+
+			apiErr, _ := error_utils.ApiErrFromBytes(rr.Body.Bytes())
+
+			assert.EqualValues(t, http.StatusUnprocessableEntity, apiErr.Status())
+			assert.EqualValues(t, "unable to parse ID", apiErr.Message())
+		})
+	})
+}
+
+func TestDestinations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const tweetId int64 = 1
+	const destinationId int64 = 2
+
+	t.Run("ListDestinations", func(t *testing.T) {
+		middleware := AuthenticateMiddleware("tweet:read")
+
+		t.Run("Success", func(t *testing.T) {
+			services.DestinationService = &destinationServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			listDestinationService = func(id int64) ([]domain.Destination, error_utils.MessageErr) {
+				return []domain.Destination{{Id: destinationId, TweetId: id, AccountId: 3, Status: domain.Failed, LastError: "mastodon: 503 Service Unavailable"}}, nil
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%v/destinations", tweetPath, tweetId), nil)
+			rr := httptest.NewRecorder()
+			r.GET("/tweets/:id/destinations", middleware, ListDestinations)
+			r.ServeHTTP(rr, req)
+
+			var destinations []domain.Destination
+			err := json.Unmarshal(rr.Body.Bytes(), &destinations)
+
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusOK, rr.Code)
+			assert.Len(t, destinations, 1)
+			assert.EqualValues(t, domain.Failed, destinations[0].Status)
+			assert.EqualValues(t, "mastodon: 503 Service Unavailable", destinations[0].LastError)
+		})
+
+		t.Run("Not cross-posted", func(t *testing.T) {
+			services.DestinationService = &destinationServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			listDestinationService = func(id int64) ([]domain.Destination, error_utils.MessageErr) {
+				return nil, error_utils.NotFoundError("no records found")
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%v/destinations", tweetPath, tweetId), nil)
+			rr := httptest.NewRecorder()
+			r.GET("/tweets/:id/destinations", middleware, ListDestinations)
+			r.ServeHTTP(rr, req)
+
+			apiErr, _ := error_utils.ApiErrFromBytes(rr.Body.Bytes())
+
+			assert.EqualValues(t, http.StatusNotFound, apiErr.Status())
+		})
+	})
+
+	t.Run("UpdateDestination", func(t *testing.T) {
+		middleware := AuthenticateMiddleware("tweet:update")
+
+		t.Run("Success", func(t *testing.T) {
+			services.DestinationService = &destinationServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			updateDestinationService = func(twId int64, destination *domain.Destination) (*domain.Destination, error_utils.MessageErr) {
+				assert.EqualValues(t, tweetId, twId)
+				assert.EqualValues(t, destinationId, destination.Id)
+				return destination, nil
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/%v/destinations/%v", tweetPath, tweetId, destinationId), bytes.NewBufferString(`{"status":"Skipped"}`))
+			rr := httptest.NewRecorder()
+			r.PUT("/tweets/:id/destinations/:destinationId", middleware, UpdateDestination)
+			r.ServeHTTP(rr, req)
+
+			var destination domain.Destination
+			err := json.Unmarshal(rr.Body.Bytes(), &destination)
+
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusOK, rr.Code)
+			assert.EqualValues(t, domain.Skipped, destination.Status)
+		})
+
+		t.Run("Invalid body", func(t *testing.T) {
+			services.AuthService = &authServiceMock{}
+
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/%v/destinations/%v", tweetPath, tweetId, destinationId), bytes.NewBufferString(`{"status":`))
+			rr := httptest.NewRecorder()
+			r.PUT("/tweets/:id/destinations/:destinationId", middleware, UpdateDestination)
+			r.ServeHTTP(rr, req)
+
+			apiErr, _ := error_utils.ApiErrFromBytes(rr.Body.Bytes())
+
+			assert.EqualValues(t, http.StatusUnprocessableEntity, apiErr.Status())
+			assert.EqualValues(t, "invalid json body", apiErr.Message())
+		})
+
+		t.Run("Cannot parse ID", func(t *testing.T) {
+			services.AuthService = &authServiceMock{}
+
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/%v/destinations/red", tweetPath, tweetId), bytes.NewBufferString(`{"status":"Skipped"}`))
+			rr := httptest.NewRecorder()
+			r.PUT("/tweets/:id/destinations/:destinationId", middleware, UpdateDestination)
+			r.ServeHTTP(rr, req)
+
+			apiErr, _ := error_utils.ApiErrFromBytes(rr.Body.Bytes())
+
+			assert.EqualValues(t, http.StatusUnprocessableEntity, apiErr.Status())
+			assert.EqualValues(t, "unable to parse ID", apiErr.Message())
+		})
+	})
+}
+
+func TestAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("GetStatus", func(t *testing.T) {
+		middleware := AuthenticateMiddleware("admin:read")
+		resetAt := time.Date(2021, 7, 18, 12, 55, 50, 0, time.UTC)
+
+		t.Run("Success", func(t *testing.T) {
+			services.AuthService = &authServiceMock{}
+
+			schedulerStatus = func() scheduler.Status {
+				return scheduler.Status{
+					RateLimited: true,
+					RateLimits:  []scheduler.AccountRateLimit{{AccountId: 2, Limited: true, Window: publisher.RateLimit{Limit: 300, Remaining: 0, ResetAt: resetAt}}},
+				}
+			}
+			defer func() { schedulerStatus = scheduler.GetStatus }()
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return requiredScope == "admin:read"
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodGet, "/admin/status", nil)
+			rr := httptest.NewRecorder()
+			r.GET("/admin/status", middleware, GetStatus)
+			r.ServeHTTP(rr, req)
+
+			var status scheduler.Status
+			err := json.Unmarshal(rr.Body.Bytes(), &status)
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusOK, rr.Code)
+			assert.Empty(t, status.Credentials)
+			assert.True(t, status.RateLimited)
+			assert.EqualValues(t, 2, status.RateLimits[0].AccountId)
+			assert.EqualValues(t, 300, status.RateLimits[0].Window.Limit)
+			assert.True(t, resetAt.Equal(status.RateLimits[0].Window.ResetAt))
+		})
+
+		t.Run("Missing scope", func(t *testing.T) {
+			services.AuthService = &authServiceMock{}
+
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return false
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodGet, "/admin/status", nil)
+			rr := httptest.NewRecorder()
+			r.GET("/admin/status", middleware, GetStatus)
+			r.ServeHTTP(rr, req)
+
+			assert.EqualValues(t, http.StatusForbidden, rr.Code)
+		})
+	})
+
+	t.Run("GetHealth", func(t *testing.T) {
+		middleware := AuthenticateMiddleware("admin:read")
+
+		serve := func(health scheduler.Health) (*httptest.ResponseRecorder, scheduler.Health) {
+			services.AuthService = &authServiceMock{}
+
+			schedulerHealth = func() scheduler.Health {
+				return health
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return requiredScope == "admin:read"
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodGet, "/admin/health", nil)
+			rr := httptest.NewRecorder()
+			r.GET("/admin/health", middleware, GetHealth)
+			r.ServeHTTP(rr, req)
+
+			var body scheduler.Health
+			_ = json.Unmarshal(rr.Body.Bytes(), &body)
+			return rr, body
+		}
+		defer func() { schedulerHealth = scheduler.GetHealth }()
+
+		t.Run("Healthy", func(t *testing.T) {
+			rr, health := serve(scheduler.Health{Healthy: true, Credentials: []scheduler.Credentials{{AccountId: 0, Valid: true}}})
+
+			assert.EqualValues(t, http.StatusOK, rr.Code)
+			assert.True(t, health.Healthy)
+			assert.Len(t, health.Credentials, 1)
+		})
+
+		t.Run("Rejected credentials", func(t *testing.T) {
+			rr, health := serve(scheduler.Health{Credentials: []scheduler.Credentials{{AccountId: 2, Error: "twitter: 89 Invalid or expired token."}}})
+
+			assert.EqualValues(t, http.StatusServiceUnavailable, rr.Code)
+			assert.False(t, health.Healthy)
+			assert.Equal(t, "twitter: 89 Invalid or expired token.", health.Credentials[0].Error)
+		})
+	})
+}
+
+func TestAccounts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const accountId int64 = 1
+	const accountPath = "/accounts"
+
+	allowAll := func(token *domain.Token, requiredScope string) bool {
+		return true
+	}
+
+	t.Run("CreateAccount", func(t *testing.T) {
+		middleware := AuthenticateMiddleware("account:create")
+
+		t.Run("Success", func(t *testing.T) {
+			services.AccountService = &accountServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			var received *domain.Account
+			createAccountService = func(account *domain.Account) (*domain.Account, error_utils.MessageErr) {
+				received = account
+				return &domain.Account{Id: accountId, Name: account.Name}, nil
+			}
+			validateTokenService = allowAll
+
+			jsonBody := `{"name": "lattr", "consumerKey": "ck", "consumerSecret": "cs", "accessToken": "at", "accessTokenSecret": "ats"}`
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodPost, accountPath, bytes.NewBufferString(jsonBody))
+			rr := httptest.NewRecorder()
+			r.POST(accountPath, middleware, CreateAccount)
+			r.ServeHTTP(rr, req)
+
+			var account domain.Account
+			err := json.Unmarshal(rr.Body.Bytes(), &account)
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusCreated, rr.Code)
+			assert.EqualValues(t, accountId, account.Id)
+			assert.Equal(t, "ats", received.AccessTokenSecret)
+			assert.NotContains(t, rr.Body.String(), "accessTokenSecret")
+		})
+
+		t.Run("Invalid JSON", func(t *testing.T) {
+			services.AuthService = &authServiceMock{}
+			validateTokenService = allowAll
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodPost, accountPath, bytes.NewBufferString(""))
+			rr := httptest.NewRecorder()
+			r.POST(accountPath, middleware, CreateAccount)
+			r.ServeHTTP(rr, req)
+
+			apiErr, err := error_utils.ApiErrFromBytes(rr.Body.Bytes())
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusUnprocessableEntity, apiErr.Status())
+			assert.EqualValues(t, "invalid json body", apiErr.Message())
+		})
+	})
+
+	t.Run("GetAccount", func(t *testing.T) {
+		middleware := AuthenticateMiddleware("account:read")
+
+		t.Run("Success", func(t *testing.T) {
+			services.AccountService = &accountServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			getAccountService = func(id int64) (*domain.Account, error_utils.MessageErr) {
+				return &domain.Account{Id: id, Name: "lattr"}, nil
+			}
+			validateTokenService = allowAll
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%v", accountPath, accountId), nil)
+			rr := httptest.NewRecorder()
+			r.GET(accountPath+"/:id", middleware, GetAccount)
+			r.ServeHTTP(rr, req)
+
+			var account domain.Account
+			err := json.Unmarshal(rr.Body.Bytes(), &account)
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusOK, rr.Code)
+			assert.EqualValues(t, "lattr", account.Name)
+		})
+
+		t.Run("Invalid ID", func(t *testing.T) {
+			services.AuthService = &authServiceMock{}
+			validateTokenService = allowAll
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodGet, accountPath+"/abc", nil)
+			rr := httptest.NewRecorder()
+			r.GET(accountPath+"/:id", middleware, GetAccount)
+			r.ServeHTTP(rr, req)
+
+			apiErr, err := error_utils.ApiErrFromBytes(rr.Body.Bytes())
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusUnprocessableEntity, apiErr.Status())
+			assert.EqualValues(t, "unable to parse ID", apiErr.Message())
+		})
+	})
+
+	t.Run("ListAccounts", func(t *testing.T) {
+		services.AccountService = &accountServiceMock{}
+		services.AuthService = &authServiceMock{}
+
+		listAccountsService = func() ([]domain.Account, error_utils.MessageErr) {
+			return []domain.Account{{Id: 1, Name: "lattr"}, {Id: 2, Name: "brand"}}, nil
+		}
+		validateTokenService = allowAll
+
+		r := gin.Default()
+		req, _ := http.NewRequest(http.MethodGet, accountPath, nil)
+		rr := httptest.NewRecorder()
+		r.GET(accountPath, AuthenticateMiddleware("account:read"), ListAccounts)
+		r.ServeHTTP(rr, req)
+
+		var accounts []domain.Account
+		err := json.Unmarshal(rr.Body.Bytes(), &accounts)
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusOK, rr.Code)
+		assert.Len(t, accounts, 2)
+	})
+
+	t.Run("UpdateAccount", func(t *testing.T) {
+		services.AccountService = &accountServiceMock{}
+		services.AuthService = &authServiceMock{}
+
+		var received *domain.Account
+		updateAccountService = func(account *domain.Account) (*domain.Account, error_utils.MessageErr) {
+			received = account
+			return account, nil
+		}
+		validateTokenService = allowAll
+
+		r := gin.Default()
+		req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/%v", accountPath, accountId), bytes.NewBufferString(`{"name": "renamed"}`))
+		rr := httptest.NewRecorder()
+		r.PUT(accountPath+"/:id", AuthenticateMiddleware("account:update"), UpdateAccount)
+		r.ServeHTTP(rr, req)
+
+		assert.EqualValues(t, http.StatusOK, rr.Code)
+		assert.EqualValues(t, accountId, received.Id)
+		assert.EqualValues(t, "renamed", received.Name)
+	})
+
+	t.Run("DeleteAccount", func(t *testing.T) {
+		middleware := AuthenticateMiddleware("account:delete")
+
+		t.Run("Success", func(t *testing.T) {
+			services.AccountService = &accountServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			deleteAccountService = func(id int64) error_utils.MessageErr {
+				return nil
+			}
+			validateTokenService = allowAll
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/%v", accountPath, accountId), nil)
+			rr := httptest.NewRecorder()
+			r.DELETE(accountPath+"/:id", middleware, DeleteAccount)
+			r.ServeHTTP(rr, req)
+
+			assert.EqualValues(t, http.StatusOK, rr.Code)
+		})
+
+		t.Run("Still has tweets", func(t *testing.T) {
+			services.AccountService = &accountServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			deleteAccountService = func(id int64) error_utils.MessageErr {
+				return error_utils.UnprocessableEntityError("Account still has tweets and cannot be deleted")
+			}
+			validateTokenService = allowAll
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/%v", accountPath, accountId), nil)
+			rr := httptest.NewRecorder()
+			r.DELETE(accountPath+"/:id", middleware, DeleteAccount)
+			r.ServeHTTP(rr, req)
+
+			apiErr, err := error_utils.ApiErrFromBytes(rr.Body.Bytes())
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusUnprocessableEntity, apiErr.Status())
+		})
+	})
+}
+
+func TestConnect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const connectPath = "/accounts/connect"
+	const callbackPath = "/accounts/connect/callback"
+
+	t.Run("ConnectAccount", func(t *testing.T) {
+		middleware := AuthenticateMiddleware("account:create")
+
+		t.Run("Success", func(t *testing.T) {
+			services.ConnectService = &connectServiceMock{}
+			services.AuthService = &authServiceMock{}
+
+			startConnectService = func(connection *domain.Connection) (*domain.Connection, error_utils.MessageErr) {
+				connection.RequestToken = "request-token"
+				connection.RequestSecret = "request-secret"
+				connection.AuthorizationUrl = "https://api.twitter.com/oauth/authorize?oauth_token=request-token"
+				return connection, nil
+			}
+			validateTokenService = func(token *domain.Token, requiredScope string) bool {
+				return true
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodPost, connectPath, bytes.NewBufferString(`{"name": "lattr"}`))
+			rr := httptest.NewRecorder()
+			r.POST(connectPath, middleware, ConnectAccount)
+			r.ServeHTTP(rr, req)
+
+			var connection domain.Connection
+			err := json.Unmarshal(rr.Body.Bytes(), &connection)
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusCreated, rr.Code)
+			assert.Equal(t, "lattr", connection.Name)
+			assert.Equal(t, "https://api.twitter.com/oauth/authorize?oauth_token=request-token", connection.AuthorizationUrl)
+			assert.NotContains(t, rr.Body.String(), "request-secret")
+		})
+	})
+
+	t.Run("ConnectCallback", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			services.ConnectService = &connectServiceMock{}
+
+			var gotToken, gotVerifier string
+			completeConnectService = func(requestToken string, verifier string) (*domain.Account, error_utils.MessageErr) {
+				gotToken, gotVerifier = requestToken, verifier
+				return &domain.Account{Id: 3, Name: "lattr"}, nil
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodGet, callbackPath+"?oauth_token=request-token&oauth_verifier=the-verifier", nil)
+			rr := httptest.NewRecorder()
+			r.GET(callbackPath, ConnectCallback)
+			r.ServeHTTP(rr, req)
+
+			var account domain.Account
+			err := json.Unmarshal(rr.Body.Bytes(), &account)
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusCreated, rr.Code)
+			assert.EqualValues(t, 3, account.Id)
+			assert.Equal(t, "request-token", gotToken)
+			assert.Equal(t, "the-verifier", gotVerifier)
+		})
+
+		t.Run("Declined", func(t *testing.T) {
+			services.ConnectService = &connectServiceMock{}
+
+			var cancelled string
+			cancelConnectService = func(requestToken string) error_utils.MessageErr {
+				cancelled = requestToken
+				return nil
+			}
+
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodGet, callbackPath+"?denied=request-token", nil)
+			rr := httptest.NewRecorder()
+			r.GET(callbackPath, ConnectCallback)
+			r.ServeHTTP(rr, req)
+
+			assert.EqualValues(t, http.StatusForbidden, rr.Code)
+			assert.Equal(t, "request-token", cancelled)
+		})
+
+		t.Run("Missing verifier", func(t *testing.T) {
+			r := gin.Default()
+			req, _ := http.NewRequest(http.MethodGet, callbackPath+"?oauth_token=request-token", nil)
+			rr := httptest.NewRecorder()
+			r.GET(callbackPath, ConnectCallback)
+			r.ServeHTTP(rr, req)
+
+			apiErr, err := error_utils.ApiErrFromBytes(rr.Body.Bytes())
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusUnprocessableEntity, apiErr.Status())
+			assert.Equal(t, "oauth_token and oauth_verifier are required", apiErr.Message())
+		})
+	})
+}
+
+func TestListOutbox(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	middleware := AuthenticateMiddleware("admin:read")
+
+	t.Run("Success", func(t *testing.T) {
+		services.OutboxService = &outboxServiceMock{}
+		services.AuthService = &authServiceMock{}
+
+		listOutboxService = func() ([]domain.OutboxEntry, error_utils.MessageErr) {
+			return []domain.OutboxEntry{{Id: 2, AccountId: 3, Network: "mastodon", Message: "second", ReplyTo: "1"}, {Id: 1, AccountId: 3, Network: "mastodon", Message: "first"}}, nil
+		}
+		validateTokenService = func(token *domain.Token, requiredScope string) bool {
+			return requiredScope == "admin:read"
+		}
+
+		r := gin.Default()
+		req, _ := http.NewRequest(http.MethodGet, "/outbox", nil)
+		rr := httptest.NewRecorder()
+		r.GET("/outbox", middleware, ListOutbox)
+		r.ServeHTTP(rr, req)
+
+		var entries []domain.OutboxEntry
+		err := json.Unmarshal(rr.Body.Bytes(), &entries)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusOK, rr.Code)
+		assert.Len(t, entries, 2)
+		assert.EqualValues(t, "1", entries[0].ReplyTo)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		services.OutboxService = &outboxServiceMock{}
+		services.AuthService = &authServiceMock{}
+
+		listOutboxService = func() ([]domain.OutboxEntry, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no records found")
+		}
+		validateTokenService = func(token *domain.Token, requiredScope string) bool {
+			return true
+		}
+
+		r := gin.Default()
+		req, _ := http.NewRequest(http.MethodGet, "/outbox", nil)
+		rr := httptest.NewRecorder()
+		r.GET("/outbox", middleware, ListOutbox)
+		r.ServeHTTP(rr, req)
+
+		apiErr, err := error_utils.ApiErrFromBytes(rr.Body.Bytes())
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusNotFound, apiErr.Status())
+		assert.EqualValues(t, "no records found", apiErr.Message())
+	})
+}
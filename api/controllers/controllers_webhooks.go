@@ -17,7 +17,8 @@ import (
 // @Accept  json
 // @Produce  json
 // @Param tweet body domain.Tweet true "Create Tweet"
-// @Success 200 {object} domain.Tweet
+// @Param split query bool false "Split a message that is too long into a numbered thread, the created tweets are returned as a list"
+// @Success 201 {object} domain.Tweet "The created tweet, or a list of the created tweets when split is set"
 // @Failure 403 {object} error_utils.MessageErrStruct
 // @Failure 404 {object} error_utils.MessageErrStruct
 // @Failure 422 {object} error_utils.MessageErrStruct
@@ -50,6 +51,17 @@ func WebHook(c *gin.Context) {
 
 	tweet.PostTime = tweetTime
 	tweet.Status = domain.Scheduled
+
+	if splitRequested(c) {
+		tweets, err := services.TweetService.CreateSplit(&tweet)
+		if err != nil {
+			c.JSON(err.Status(), err)
+			return
+		}
+		c.JSON(http.StatusCreated, tweets)
+		return
+	}
+
 	msg, err := services.TweetService.Create(&tweet)
 	if err != nil {
 		c.JSON(err.Status(), err)
@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/RemeJuan/lattr/utils/error_formats"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/lib/pq"
+)
+
+var (
+	AccountRepo AccountRepoInterface = &accountRepo{}
+)
+
+// foreignKeyViolation is the postgres error code raised when a referenced row is deleted
+const foreignKeyViolation = "23503"
+
+const accountColumns = "Id, Name, Network, InstanceUrl, Handle, ApiVersion, ConsumerKey, ConsumerSecret, AccessToken, AccessTokenSecret, CreatedAt, Modified"
+
+var (
+	queryInsertAccount = "INSERT INTO accounts(Name, Network, InstanceUrl, Handle, ApiVersion, ConsumerKey, ConsumerSecret, AccessToken, AccessTokenSecret, CreatedAt, Modified) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING Id;"
+	queryGetAccount    = "SELECT " + accountColumns + " FROM accounts WHERE Id=$1;"
+	queryListAccounts  = "SELECT " + accountColumns + " FROM accounts ORDER BY Id asc;"
+	queryUpdateAccount = "UPDATE accounts SET Name=$1, InstanceUrl=$2, Handle=$3, ApiVersion=$4, ConsumerKey=$5, ConsumerSecret=$6, AccessToken=$7, AccessTokenSecret=$8, Modified=$9 WHERE Id=$10;"
+	queryDeleteAccount = "DELETE FROM accounts WHERE Id=$1;"
+	// queryCountAccountReferences counts the tweets and destinations that post as the account
+	queryCountAccountReferences = "SELECT (SELECT count(*) FROM tweets WHERE AccountId=$1) + (SELECT count(*) FROM destinations WHERE AccountId=$1);"
+)
+
+type AccountRepoInterface interface {
+	Initialize() *sql.DB
+	Create(*Account) (*Account, error_utils.MessageErr)
+	Get(int64) (*Account, error_utils.MessageErr)
+	List() ([]Account, error_utils.MessageErr)
+	Update(*Account) (*Account, error_utils.MessageErr)
+	Delete(int64) error_utils.MessageErr
+	CountReferences(int64) (int64, error_utils.MessageErr)
+}
+
+type accountRepo struct {
+	db *sql.DB
+}
+
+func InitAccountRepository(db *sql.DB) AccountRepoInterface {
+	return &accountRepo{
+		db: db,
+	}
+}
+
+func (ar *accountRepo) Initialize() *sql.DB {
+	var err error
+	ar.db, err = openPool()
+
+	checkError(err)
+
+	fmt.Println("Connected!")
+
+	return ar.db
+}
+
+func (ar *accountRepo) Create(account *Account) (*Account, error_utils.MessageErr) {
+	var id int64
+	stmt, err := ar.db.Prepare(queryInsertAccount)
+
+	if err != nil {
+		message := fmt.Sprintf("Error when trying to prepare all entries: %s", err.Error())
+		return nil, error_utils.InternalServerError(message)
+	}
+	defer stmt.Close()
+
+	insertResult, createErr := stmt.Query(account.Name, account.Network, account.InstanceUrl, account.Handle, account.ApiVersion, account.ConsumerKey, account.ConsumerSecret, account.AccessToken, account.AccessTokenSecret, account.CreatedAt, account.Modified)
+	if createErr != nil {
+		return nil, error_formats.ParseError(createErr)
+	}
+
+	insertResult.Next()
+	if inErr := insertResult.Scan(&id); inErr != nil {
+		message := fmt.Sprintf("error when trying to save data: %s", inErr.Error())
+		return nil, error_utils.InternalServerError(message)
+	}
+
+	account.Id = id
+	return account, nil
+}
+
+func (ar *accountRepo) Get(id int64) (*Account, error_utils.MessageErr) {
+	stmt, err := ar.db.Prepare(queryGetAccount)
+
+	if err != nil {
+		message := fmt.Sprintf("Error retrieving record: %s", err)
+		return nil, error_utils.InternalServerError(message)
+	}
+
+	defer stmt.Close()
+
+	var account Account
+	result := stmt.QueryRow(id)
+
+	if getError := scanAccount(result, &account); getError != nil {
+		return nil, error_formats.ParseError(getError)
+	}
+
+	return &account, nil
+}
+
+func (ar *accountRepo) List() ([]Account, error_utils.MessageErr) {
+	stmt, err := ar.db.Prepare(queryListAccounts)
+
+	if err != nil {
+		return nil, error_utils.InternalServerError(fmt.Sprintf("Error when trying to prepare all entries: %s", err.Error()))
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, error_formats.ParseError(err)
+	}
+	defer rows.Close()
+
+	results := make([]Account, 0)
+
+	for rows.Next() {
+		var account Account
+		if getError := scanAccount(rows, &account); getError != nil {
+			message := fmt.Sprintf("Error when trying to get account: %s", getError.Error())
+			return nil, error_utils.InternalServerError(message)
+		}
+		results = append(results, account)
+	}
+	if len(results) == 0 {
+		return nil, error_utils.NotFoundError("no records found")
+	}
+	return results, nil
+}
+
+func (ar *accountRepo) Update(account *Account) (*Account, error_utils.MessageErr) {
+	stmt, err := ar.db.Prepare(queryUpdateAccount)
+
+	if err != nil {
+		message := fmt.Sprintf("error when trying to prepare update: %s", err.Error())
+		return nil, error_utils.InternalServerError(message)
+	}
+	defer stmt.Close()
+
+	_, updateErr := stmt.Exec(account.Name, account.InstanceUrl, account.Handle, account.ApiVersion, account.ConsumerKey, account.ConsumerSecret, account.AccessToken, account.AccessTokenSecret, account.Modified, account.Id)
+	if updateErr != nil {
+		return nil, error_formats.ParseError(updateErr)
+	}
+	return account, nil
+}
+
+// CountReferences returns how many tweets and destinations post as the account
+func (ar *accountRepo) CountReferences(id int64) (int64, error_utils.MessageErr) {
+	stmt, err := ar.db.Prepare(queryCountAccountReferences)
+
+	if err != nil {
+		message := fmt.Sprintf("Error retrieving record: %s", err)
+		return 0, error_utils.InternalServerError(message)
+	}
+
+	defer stmt.Close()
+
+	var count int64
+	if getError := stmt.QueryRow(id).Scan(&count); getError != nil {
+		return 0, error_formats.ParseError(getError)
+	}
+
+	return count, nil
+}
+
+// Delete removes the account, accounts that are still referenced by tweets or destinations cannot
+// be deleted. The service checks for references first, the foreign key covers a tweet added in between
+func (ar *accountRepo) Delete(id int64) error_utils.MessageErr {
+	stmt, err := ar.db.Prepare(queryDeleteAccount)
+	if err != nil {
+		return error_utils.InternalServerError(fmt.Sprintf("error when trying to delete record: %s", err.Error()))
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(id); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return error_utils.UnprocessableEntityError("Account is still used by tweets and cannot be deleted")
+		}
+		return error_utils.InternalServerError(fmt.Sprintf("error when trying to delete record %s", err.Error()))
+	}
+	return nil
+}
+
+// scanAccount reads a row selected with accountColumns into the account
+func scanAccount(row scanner, account *Account) error {
+	return row.Scan(&account.Id, &account.Name, &account.Network, &account.InstanceUrl, &account.Handle, &account.ApiVersion, &account.ConsumerKey, &account.ConsumerSecret, &account.AccessToken, &account.AccessTokenSecret, &account.CreatedAt, &account.Modified)
+}
@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/error_utils"
+)
+
+type network string
+
+const (
+	TwitterNetwork  = network("twitter")
+	MastodonNetwork = network("mastodon")
+	BlueskyNetwork  = network("bluesky")
+)
+
+// DefaultNetwork is the network tweets without an account are posted to, selected with PUBLISHER
+func DefaultNetwork() network {
+	if publisher := os.Getenv("PUBLISHER"); publisher != "" {
+		return network(publisher)
+	}
+
+	return TwitterNetwork
+}
+
+// PostsRetweets reports whether the network can post retweets and quote tweets, Mastodon and Bluesky cannot
+func (n network) PostsRetweets() bool {
+	return n != MastodonNetwork && n != BlueskyNetwork
+}
+
+// Twitter API versions an account can post through, 1.1 is the default and 2 posts with POST /2/tweets
+const (
+	TwitterApiV1 = "1.1"
+	TwitterApiV2 = "2"
+)
+
+// Account is a Twitter, Mastodon or Bluesky account tweets can be posted to, with the credentials used to post as it.
+// Mastodon accounts only use the instance URL and access token, Bluesky accounts use their handle with an
+// app password as the access token and an optional instance URL for self-hosted PDSs. Twitter accounts post
+// through the API version set in ApiVersion
+type Account struct {
+	Id                int64     `json:"id" example:"1"`
+	Name              string    `json:"name" example:"lattr"`
+	Network           network   `json:"network" example:"twitter"`
+	InstanceUrl       string    `json:"instanceUrl,omitempty" example:"https://mastodon.social"`
+	Handle            string    `json:"handle,omitempty" example:"lattr.bsky.social"`
+	ApiVersion        string    `json:"apiVersion,omitempty" example:"2"`
+	ConsumerKey       string    `json:"consumerKey,omitempty" example:"xvz1evFS4wEEPTGEFPHBog"`
+	ConsumerSecret    string    `json:"consumerSecret,omitempty" example:"L8qq9PZyRg6ieKGEKhZolGC0vJWLw8iEJ88DRdyOg"`
+	AccessToken       string    `json:"accessToken,omitempty" example:"370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb"`
+	AccessTokenSecret string    `json:"accessTokenSecret,omitempty" example:"LswwdoUaIvS8ltyTt5jkRh4J50vUPVVHtR2YPi5kE"`
+	CreatedAt         time.Time `json:"createdAt" example:"2022-09-09T10:29:07.559636Z"`
+	Modified          time.Time `json:"modified" example:"2022-09-09T10:29:07.559636Z"`
+}
+
+func (a *Account) Validate() error_utils.MessageErr {
+	a.Name = strings.TrimSpace(a.Name)
+	a.ConsumerKey = strings.TrimSpace(a.ConsumerKey)
+	a.ConsumerSecret = strings.TrimSpace(a.ConsumerSecret)
+	a.AccessToken = strings.TrimSpace(a.AccessToken)
+	a.AccessTokenSecret = strings.TrimSpace(a.AccessTokenSecret)
+	a.InstanceUrl = strings.TrimRight(strings.TrimSpace(a.InstanceUrl), "/")
+	a.Handle = strings.TrimPrefix(strings.TrimSpace(a.Handle), "@")
+
+	if a.Name == "" {
+		return error_utils.UnprocessableEntityError("Name cannot be empty")
+	}
+
+	switch a.Network {
+	case "", TwitterNetwork:
+		a.Network = TwitterNetwork
+		a.InstanceUrl = ""
+		a.Handle = ""
+
+		switch a.ApiVersion {
+		case "":
+			a.ApiVersion = TwitterApiV1
+		case TwitterApiV1, TwitterApiV2:
+		default:
+			return error_utils.UnprocessableEntityError("API version must be one of 1.1 or 2")
+		}
+
+		if a.ConsumerKey == "" || a.ConsumerSecret == "" || a.AccessToken == "" || a.AccessTokenSecret == "" {
+			return error_utils.UnprocessableEntityError("Consumer key, consumer secret, access token and access token secret are required")
+		}
+	case MastodonNetwork:
+		a.clearTwitterCredentials()
+		a.Handle = ""
+
+		if !validInstanceUrl(a.InstanceUrl) {
+			return error_utils.UnprocessableEntityError("Instance URL must be an http or https URL")
+		}
+
+		if a.AccessToken == "" {
+			return error_utils.UnprocessableEntityError("Access token is required")
+		}
+	case BlueskyNetwork:
+		a.clearTwitterCredentials()
+
+		if a.InstanceUrl != "" && !validInstanceUrl(a.InstanceUrl) {
+			return error_utils.UnprocessableEntityError("Instance URL must be an http or https URL")
+		}
+
+		if a.Handle == "" || a.AccessToken == "" {
+			return error_utils.UnprocessableEntityError("Handle and app password are required")
+		}
+	default:
+		return error_utils.UnprocessableEntityError("Network must be one of twitter, mastodon or bluesky")
+	}
+
+	return nil
+}
+
+// clearTwitterCredentials drops the fields only Twitter accounts use so they are never stored
+func (a *Account) clearTwitterCredentials() {
+	a.ApiVersion = ""
+	a.ConsumerKey = ""
+	a.ConsumerSecret = ""
+	a.AccessTokenSecret = ""
+}
+
+func validInstanceUrl(instanceUrl string) bool {
+	instance, err := url.Parse(instanceUrl)
+	return err == nil && (instance.Scheme == "https" || instance.Scheme == "http") && instance.Host != ""
+}
+
+// Credentials lists the credential fields the account's network uses, which are stored sealed and only opened when posting
+func (a *Account) Credentials() []*string {
+	if a.Network == MastodonNetwork || a.Network == BlueskyNetwork {
+		return []*string{&a.AccessToken}
+	}
+	return []*string{&a.ConsumerKey, &a.ConsumerSecret, &a.AccessToken, &a.AccessTokenSecret}
+}
+
+// Redact clears the credentials so the account can be returned by the API
+func (a *Account) Redact() *Account {
+	a.ConsumerKey = ""
+	a.ConsumerSecret = ""
+	a.AccessToken = ""
+	a.AccessTokenSecret = ""
+
+	return a
+}
@@ -0,0 +1,349 @@
+package domain
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var accountColumnNames = []string{"Id", "Name", "Network", "InstanceUrl", "Handle", "ApiVersion", "ConsumerKey", "ConsumerSecret", "AccessToken", "AccessTokenSecret", "CreatedAt", "Modified"}
+
+func TestAccount_Validate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		account := &Account{Name: " lattr ", ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: "at", AccessTokenSecret: "ats"}
+
+		assert.Nil(t, account.Validate())
+		assert.Equal(t, "lattr", account.Name)
+	})
+
+	t.Run("Empty name", func(t *testing.T) {
+		account := &Account{ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: "at", AccessTokenSecret: "ats"}
+
+		err := account.Validate()
+
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.Equal(t, "Name cannot be empty", err.Message())
+	})
+
+	t.Run("Missing credentials", func(t *testing.T) {
+		account := &Account{Name: "lattr", ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: " "}
+
+		err := account.Validate()
+
+		assert.Equal(t, "Consumer key, consumer secret, access token and access token secret are required", err.Message())
+	})
+
+	t.Run("Defaults to twitter", func(t *testing.T) {
+		account := &Account{Name: "lattr", InstanceUrl: "https://mastodon.social", ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: "at", AccessTokenSecret: "ats"}
+
+		assert.Nil(t, account.Validate())
+		assert.Equal(t, TwitterNetwork, account.Network)
+		assert.Equal(t, "", account.InstanceUrl)
+		assert.Equal(t, TwitterApiV1, account.ApiVersion)
+	})
+
+	t.Run("Twitter API v2", func(t *testing.T) {
+		account := &Account{Name: "lattr", ApiVersion: TwitterApiV2, ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: "at", AccessTokenSecret: "ats"}
+
+		assert.Nil(t, account.Validate())
+		assert.Equal(t, TwitterApiV2, account.ApiVersion)
+	})
+
+	t.Run("Unknown API version", func(t *testing.T) {
+		account := &Account{Name: "lattr", ApiVersion: "3", ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: "at", AccessTokenSecret: "ats"}
+
+		assert.Equal(t, "API version must be one of 1.1 or 2", account.Validate().Message())
+	})
+
+	t.Run("Mastodon", func(t *testing.T) {
+		account := &Account{Name: "lattr", Network: MastodonNetwork, InstanceUrl: " https://mastodon.social/ ", ConsumerKey: "ck", AccessToken: "at"}
+
+		assert.Nil(t, account.Validate())
+		assert.Equal(t, "https://mastodon.social", account.InstanceUrl)
+		assert.Equal(t, "", account.ConsumerKey)
+		assert.Equal(t, []*string{&account.AccessToken}, account.Credentials())
+	})
+
+	t.Run("Mastodon without instance", func(t *testing.T) {
+		account := &Account{Name: "lattr", Network: MastodonNetwork, InstanceUrl: "mastodon.social", AccessToken: "at"}
+
+		assert.Equal(t, "Instance URL must be an http or https URL", account.Validate().Message())
+	})
+
+	t.Run("Mastodon without token", func(t *testing.T) {
+		account := &Account{Name: "lattr", Network: MastodonNetwork, InstanceUrl: "https://mastodon.social"}
+
+		assert.Equal(t, "Access token is required", account.Validate().Message())
+	})
+
+	t.Run("Bluesky", func(t *testing.T) {
+		account := &Account{Name: "lattr", Network: BlueskyNetwork, Handle: " @lattr.bsky.social ", AccessToken: "app-password"}
+
+		assert.Nil(t, account.Validate())
+		assert.Equal(t, "lattr.bsky.social", account.Handle)
+		assert.Equal(t, "", account.InstanceUrl)
+		assert.Equal(t, []*string{&account.AccessToken}, account.Credentials())
+	})
+
+	t.Run("Bluesky without handle", func(t *testing.T) {
+		account := &Account{Name: "lattr", Network: BlueskyNetwork, AccessToken: "app-password"}
+
+		assert.Equal(t, "Handle and app password are required", account.Validate().Message())
+	})
+
+	t.Run("Unknown network", func(t *testing.T) {
+		account := &Account{Name: "lattr", Network: "myspace"}
+
+		assert.Equal(t, "Network must be one of twitter, mastodon or bluesky", account.Validate().Message())
+	})
+}
+
+func TestNetwork_PostsRetweets(t *testing.T) {
+	assert.True(t, TwitterNetwork.PostsRetweets())
+	assert.False(t, MastodonNetwork.PostsRetweets())
+	assert.False(t, BlueskyNetwork.PostsRetweets())
+}
+
+func TestDefaultNetwork(t *testing.T) {
+	assert.Equal(t, TwitterNetwork, DefaultNetwork())
+
+	_ = os.Setenv("PUBLISHER", "mastodon")
+	defer os.Unsetenv("PUBLISHER")
+
+	assert.Equal(t, MastodonNetwork, DefaultNetwork())
+}
+
+func TestAccount_Redact(t *testing.T) {
+	account := &Account{Id: 1, Name: "lattr", ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: "at", AccessTokenSecret: "ats"}
+
+	assert.Equal(t, &Account{Id: 1, Name: "lattr"}, account.Redact())
+}
+
+func TestAccountRepo_Create(t *testing.T) {
+	var createdAt = time.Now().Local()
+	const recordId int64 = 1
+
+	request := &Account{Name: "lattr", Network: TwitterNetwork, ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: "at", AccessTokenSecret: "ats", CreatedAt: createdAt, Modified: createdAt}
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitAccountRepository(db)
+
+		mock.ExpectPrepare("INSERT INTO accounts").ExpectQuery().
+			WithArgs("lattr", TwitterNetwork, "", "", "", "ck", "cs", "at", "ats", createdAt, createdAt).
+			WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(recordId))
+
+		account, createErr := s.Create(request)
+
+		assert.Nil(t, createErr)
+		assert.EqualValues(t, recordId, account.Id)
+	})
+
+	t.Run("Insert error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitAccountRepository(db)
+
+		mock.ExpectPrepare("INSERT INTO accounts").ExpectQuery().WillReturnError(errors.New("invalid name"))
+
+		account, createErr := s.Create(request)
+
+		assert.Nil(t, account)
+		assert.Equal(t, "error when trying to save data: invalid name", createErr.Message())
+	})
+}
+
+func TestAccountRepo_Get(t *testing.T) {
+	var createdAt = time.Now().Local()
+	const recordId int64 = 1
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitAccountRepository(db)
+
+		rows := sqlmock.NewRows(accountColumnNames).AddRow(recordId, "lattr", "twitter", "", "", "2", "ck", "cs", "at", "ats", createdAt, createdAt)
+		mock.ExpectPrepare("SELECT (.+) FROM accounts").ExpectQuery().WithArgs(recordId).WillReturnRows(rows)
+
+		account, getErr := s.Get(recordId)
+
+		assert.Nil(t, getErr)
+		assert.Equal(t, &Account{Id: recordId, Name: "lattr", Network: TwitterNetwork, ApiVersion: TwitterApiV2, ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: "at", AccessTokenSecret: "ats", CreatedAt: createdAt, Modified: createdAt}, account)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitAccountRepository(db)
+
+		mock.ExpectPrepare("SELECT (.+) FROM accounts").ExpectQuery().WithArgs(recordId).WillReturnRows(sqlmock.NewRows(accountColumnNames))
+
+		account, getErr := s.Get(recordId)
+
+		assert.Nil(t, account)
+		assert.EqualValues(t, http.StatusNotFound, getErr.Status())
+	})
+}
+
+func TestAccountRepo_List(t *testing.T) {
+	var createdAt = time.Now().Local()
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitAccountRepository(db)
+
+		rows := sqlmock.NewRows(accountColumnNames).
+			AddRow(1, "lattr", "twitter", "", "", "2", "ck", "cs", "at", "ats", createdAt, createdAt).
+			AddRow(2, "brand", "mastodon", "https://mastodon.social", "", "", "ck2", "cs2", "at2", "ats2", createdAt, createdAt)
+		mock.ExpectPrepare("SELECT (.+) FROM accounts").ExpectQuery().WillReturnRows(rows)
+
+		accounts, listErr := s.List()
+
+		assert.Nil(t, listErr)
+		assert.Len(t, accounts, 2)
+		assert.Equal(t, "brand", accounts[1].Name)
+		assert.Equal(t, MastodonNetwork, accounts[1].Network)
+	})
+
+	t.Run("No records", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitAccountRepository(db)
+
+		mock.ExpectPrepare("SELECT (.+) FROM accounts").ExpectQuery().WillReturnRows(sqlmock.NewRows(accountColumnNames))
+
+		accounts, listErr := s.List()
+
+		assert.Nil(t, accounts)
+		assert.Equal(t, "no records found", listErr.Message())
+	})
+}
+
+func TestAccountRepo_Update(t *testing.T) {
+	var modified = time.Now().Local()
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitAccountRepository(db)
+
+		account := &Account{Id: 1, Name: "renamed", ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: "at", AccessTokenSecret: "ats", Modified: modified}
+		mock.ExpectPrepare("UPDATE accounts").ExpectExec().
+			WithArgs("renamed", "", "", "", "ck", "cs", "at", "ats", modified, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		updated, updateErr := s.Update(account)
+
+		assert.Nil(t, updateErr)
+		assert.Equal(t, account, updated)
+	})
+}
+
+func TestAccountRepo_CountReferences(t *testing.T) {
+	const recordId int64 = 1
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitAccountRepository(db)
+
+		mock.ExpectPrepare("SELECT \\(SELECT count\\(\\*\\) FROM tweets WHERE AccountId=\\$1\\) \\+ \\(SELECT count\\(\\*\\) FROM destinations").ExpectQuery().WithArgs(recordId).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+		count, countErr := s.CountReferences(recordId)
+
+		assert.Nil(t, countErr)
+		assert.EqualValues(t, 3, count)
+	})
+
+	t.Run("Query failed", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitAccountRepository(db)
+
+		mock.ExpectPrepare("SELECT").ExpectQuery().WithArgs(recordId).WillReturnError(errors.New("connection refused"))
+
+		count, countErr := s.CountReferences(recordId)
+
+		assert.EqualValues(t, 0, count)
+		assert.EqualValues(t, http.StatusInternalServerError, countErr.Status())
+	})
+}
+
+func TestAccountRepo_Delete(t *testing.T) {
+	const recordId int64 = 1
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitAccountRepository(db)
+
+		mock.ExpectPrepare("DELETE FROM accounts").ExpectExec().WithArgs(recordId).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.Nil(t, s.Delete(recordId))
+	})
+
+	t.Run("Still referenced by tweets", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitAccountRepository(db)
+
+		mock.ExpectPrepare("DELETE FROM accounts").ExpectExec().WithArgs(recordId).WillReturnError(&pq.Error{Code: foreignKeyViolation})
+
+		deleteErr := s.Delete(recordId)
+
+		assert.EqualValues(t, http.StatusUnprocessableEntity, deleteErr.Status())
+		assert.Equal(t, "Account is still used by tweets and cannot be deleted", deleteErr.Message())
+	})
+}
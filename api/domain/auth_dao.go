@@ -3,7 +3,6 @@ package domain
 import (
 	"database/sql"
 	"fmt"
-	"os"
 
 	"github.com/RemeJuan/lattr/utils/error_formats"
 	"github.com/RemeJuan/lattr/utils/error_utils"
@@ -43,7 +42,7 @@ func InitTokenRepository(db *sql.DB) TokenRepoInterface {
 
 func (tr *tokenRepo) Initialize() *sql.DB {
 	var err error
-	tr.db, err = sql.Open("postgres", os.Getenv("DATABASE_URL"))
+	tr.db, err = openPool()
 
 	checkError(err)
 
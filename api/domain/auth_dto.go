@@ -7,7 +7,7 @@ import (
 	"github.com/RemeJuan/lattr/utils/error_utils"
 )
 
-var scopes = []string{"token:create", "token:update", "token:read", "token:delete", "tweet:create", "tweet:update", "tweet:read", "tweet:delete"}
+var scopes = []string{"token:create", "token:update", "token:read", "token:delete", "tweet:create", "tweet:update", "tweet:read", "tweet:delete", "account:create", "account:update", "account:read", "account:delete", "admin:read"}
 
 type Token struct {
 	Id        int64     `json:"id" example:"1"`
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/error_formats"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+)
+
+var (
+	ConnectionRepo ConnectionRepoInterface = &connectionRepo{}
+)
+
+var (
+	queryInsertConnection         = "INSERT INTO connections(RequestToken, RequestSecret, Name, CreatedAt) VALUES($1, $2, $3, $4);"
+	queryTakeConnection           = "DELETE FROM connections WHERE RequestToken=$1 RETURNING RequestToken, RequestSecret, Name, CreatedAt;"
+	queryDeleteExpiredConnections = "DELETE FROM connections WHERE CreatedAt < $1;"
+)
+
+type ConnectionRepoInterface interface {
+	Initialize() *sql.DB
+	Create(*Connection) (*Connection, error_utils.MessageErr)
+	Take(string) (*Connection, error_utils.MessageErr)
+	DeleteExpired(time.Time) error_utils.MessageErr
+}
+
+type connectionRepo struct {
+	db *sql.DB
+}
+
+func InitConnectionRepository(db *sql.DB) ConnectionRepoInterface {
+	return &connectionRepo{
+		db: db,
+	}
+}
+
+func (cr *connectionRepo) Initialize() *sql.DB {
+	var err error
+	cr.db, err = openPool()
+
+	checkError(err)
+
+	fmt.Println("Connected!")
+
+	return cr.db
+}
+
+func (cr *connectionRepo) Create(connection *Connection) (*Connection, error_utils.MessageErr) {
+	stmt, err := cr.db.Prepare(queryInsertConnection)
+
+	if err != nil {
+		message := fmt.Sprintf("Error when trying to prepare all entries: %s", err.Error())
+		return nil, error_utils.InternalServerError(message)
+	}
+	defer stmt.Close()
+
+	if _, createErr := stmt.Exec(connection.RequestToken, connection.RequestSecret, connection.Name, connection.CreatedAt); createErr != nil {
+		return nil, error_formats.ParseError(createErr)
+	}
+
+	return connection, nil
+}
+
+// Take removes the pending request and returns it, a request token can therefore only be used once
+// even when the callback is hit concurrently
+func (cr *connectionRepo) Take(requestToken string) (*Connection, error_utils.MessageErr) {
+	stmt, err := cr.db.Prepare(queryTakeConnection)
+
+	if err != nil {
+		message := fmt.Sprintf("Error retrieving record: %s", err)
+		return nil, error_utils.InternalServerError(message)
+	}
+
+	defer stmt.Close()
+
+	var connection Connection
+	result := stmt.QueryRow(requestToken)
+
+	if getError := result.Scan(&connection.RequestToken, &connection.RequestSecret, &connection.Name, &connection.CreatedAt); getError != nil {
+		return nil, error_formats.ParseError(getError)
+	}
+
+	return &connection, nil
+}
+
+// DeleteExpired removes the requests created before the given time that were never completed
+func (cr *connectionRepo) DeleteExpired(before time.Time) error_utils.MessageErr {
+	stmt, err := cr.db.Prepare(queryDeleteExpiredConnections)
+	if err != nil {
+		return error_utils.InternalServerError(fmt.Sprintf("error when trying to delete record: %s", err.Error()))
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(before); err != nil {
+		return error_utils.InternalServerError(fmt.Sprintf("error when trying to delete record %s", err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/error_utils"
+)
+
+// ConnectionTTL is how long a user has to authorize the app before a connection request expires
+const ConnectionTTL = 15 * time.Minute
+
+// Connection is a pending OAuth request that becomes an Account once the user authorizes the app
+type Connection struct {
+	RequestToken     string    `json:"requestToken" example:"NPcudxy0yU5T3tBzho7iCotZ3cnetKwcTIRlX0iwRl0"`
+	RequestSecret    string    `json:"-"`
+	Name             string    `json:"name" example:"lattr"`
+	AuthorizationUrl string    `json:"authorizationUrl" example:"https://api.twitter.com/oauth/authorize?oauth_token=NPcudxy0yU5T3tBzho7iCotZ3cnetKwcTIRlX0iwRl0"`
+	CreatedAt        time.Time `json:"createdAt" example:"2022-09-09T10:29:07.559636Z"`
+}
+
+func (c *Connection) Validate() error_utils.MessageErr {
+	c.Name = strings.TrimSpace(c.Name)
+
+	if c.Name == "" {
+		return error_utils.UnprocessableEntityError("Name cannot be empty")
+	}
+
+	return nil
+}
+
+// Expired reports whether the request is too old to be completed
+func (c *Connection) Expired(now time.Time) bool {
+	return now.Sub(c.CreatedAt) > ConnectionTTL
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnection_Validate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		connection := &Connection{Name: " lattr "}
+
+		assert.Nil(t, connection.Validate())
+		assert.Equal(t, "lattr", connection.Name)
+	})
+
+	t.Run("Empty name", func(t *testing.T) {
+		err := (&Connection{}).Validate()
+
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.Equal(t, "Name cannot be empty", err.Message())
+	})
+}
+
+func TestConnection_Expired(t *testing.T) {
+	now := time.Now()
+
+	assert.False(t, (&Connection{CreatedAt: now.Add(-time.Minute)}).Expired(now))
+	assert.True(t, (&Connection{CreatedAt: now.Add(-ConnectionTTL - time.Second)}).Expired(now))
+}
+
+func TestConnectionRepo_Create(t *testing.T) {
+	var createdAt = time.Now().Local()
+	request := &Connection{RequestToken: "token", RequestSecret: "secret", Name: "lattr", CreatedAt: createdAt}
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitConnectionRepository(db)
+
+		mock.ExpectPrepare("INSERT INTO connections").ExpectExec().
+			WithArgs("token", "secret", "lattr", createdAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		connection, createErr := s.Create(request)
+
+		assert.Nil(t, createErr)
+		assert.Equal(t, request, connection)
+	})
+
+	t.Run("Insert error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitConnectionRepository(db)
+
+		mock.ExpectPrepare("INSERT INTO connections").ExpectExec().WillReturnError(errors.New("duplicate key"))
+
+		connection, createErr := s.Create(request)
+
+		assert.Nil(t, connection)
+		assert.Equal(t, "error when trying to save data: duplicate key", createErr.Message())
+	})
+}
+
+func TestConnectionRepo_Take(t *testing.T) {
+	var createdAt = time.Now().Local()
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitConnectionRepository(db)
+
+		rows := sqlmock.NewRows([]string{"RequestToken", "RequestSecret", "Name", "CreatedAt"}).AddRow("token", "secret", "lattr", createdAt)
+		mock.ExpectPrepare("DELETE FROM connections WHERE RequestToken=\\$1 RETURNING").ExpectQuery().WithArgs("token").WillReturnRows(rows)
+
+		connection, getErr := s.Take("token")
+
+		assert.Nil(t, getErr)
+		assert.Equal(t, &Connection{RequestToken: "token", RequestSecret: "secret", Name: "lattr", CreatedAt: createdAt}, connection)
+	})
+
+	t.Run("Already taken", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitConnectionRepository(db)
+
+		mock.ExpectPrepare("DELETE FROM connections").ExpectQuery().WithArgs("token").
+			WillReturnRows(sqlmock.NewRows([]string{"RequestToken", "RequestSecret", "Name", "CreatedAt"}))
+
+		connection, getErr := s.Take("token")
+
+		assert.Nil(t, connection)
+		assert.EqualValues(t, http.StatusNotFound, getErr.Status())
+	})
+}
+
+func TestConnectionRepo_DeleteExpired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := InitConnectionRepository(db)
+	before := time.Now().Local()
+
+	mock.ExpectPrepare("DELETE FROM connections WHERE CreatedAt < \\$1").ExpectExec().WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	assert.Nil(t, s.DeleteExpired(before))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/error_formats"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+)
+
+var (
+	DestinationRepo DestinationRepoInterface = &destinationRepo{}
+)
+
+const destinationColumns = "Id, TweetId, AccountId, Status, RemoteId, RemoteUrl, PostedAt, Attempts, LastError, NextAttemptAt, CreatedAt, Modified"
+
+var (
+	queryInsertDestination         = "INSERT INTO destinations(TweetId, AccountId, Status, CreatedAt, Modified) VALUES($1, $2, $3, $4, $5) RETURNING Id;"
+	queryGetDestination            = "SELECT " + destinationColumns + " FROM destinations WHERE Id=$1;"
+	queryListDestinations          = "SELECT " + destinationColumns + " FROM destinations WHERE TweetId=$1 ORDER BY Id asc;"
+	queryUpdateDestination         = "UPDATE destinations SET Status=$1, RemoteId=$2, RemoteUrl=$3, PostedAt=$4, Attempts=$5, LastError=$6, NextAttemptAt=$7, Modified=$8 WHERE Id=$9;"
+	queryRequeueFailedDestinations = "UPDATE destinations SET Status='Pending', Attempts=0, LastError='', NextAttemptAt=NULL, Modified=$1 WHERE Status='Failed';"
+)
+
+type DestinationRepoInterface interface {
+	Initialize() *sql.DB
+	Create(*Destination) (*Destination, error_utils.MessageErr)
+	Get(int64) (*Destination, error_utils.MessageErr)
+	List(int64) ([]Destination, error_utils.MessageErr)
+	Update(*Destination) (*Destination, error_utils.MessageErr)
+	RequeueFailed(time.Time) (int64, error_utils.MessageErr)
+}
+
+type destinationRepo struct {
+	db *sql.DB
+}
+
+func InitDestinationRepository(db *sql.DB) DestinationRepoInterface {
+	return &destinationRepo{
+		db: db,
+	}
+}
+
+func (dr *destinationRepo) Initialize() *sql.DB {
+	var err error
+	dr.db, err = openPool()
+
+	checkError(err)
+
+	fmt.Println("Connected!")
+
+	return dr.db
+}
+
+func (dr *destinationRepo) Create(destination *Destination) (*Destination, error_utils.MessageErr) {
+	var id int64
+	stmt, err := dr.db.Prepare(queryInsertDestination)
+
+	if err != nil {
+		message := fmt.Sprintf("Error when trying to prepare all entries: %s", err.Error())
+		return nil, error_utils.InternalServerError(message)
+	}
+	defer stmt.Close()
+
+	insertResult, createErr := stmt.Query(destination.TweetId, nullableId(destination.AccountId), destination.Status, destination.CreatedAt, destination.Modified)
+	if createErr != nil {
+		return nil, error_formats.ParseError(createErr)
+	}
+
+	insertResult.Next()
+	if inErr := insertResult.Scan(&id); inErr != nil {
+		message := fmt.Sprintf("error when trying to save data: %s", inErr.Error())
+		return nil, error_utils.InternalServerError(message)
+	}
+
+	destination.Id = id
+	return destination, nil
+}
+
+func (dr *destinationRepo) Get(id int64) (*Destination, error_utils.MessageErr) {
+	stmt, err := dr.db.Prepare(queryGetDestination)
+
+	if err != nil {
+		message := fmt.Sprintf("Error retrieving record: %s", err)
+		return nil, error_utils.InternalServerError(message)
+	}
+
+	defer stmt.Close()
+
+	var destination Destination
+	result := stmt.QueryRow(id)
+
+	if getError := scanDestination(result, &destination); getError != nil {
+		return nil, error_formats.ParseError(getError)
+	}
+
+	return &destination, nil
+}
+
+// List returns the destinations of a tweet, tweets that are not cross-posted have none
+func (dr *destinationRepo) List(tweetId int64) ([]Destination, error_utils.MessageErr) {
+	stmt, err := dr.db.Prepare(queryListDestinations)
+
+	if err != nil {
+		return nil, error_utils.InternalServerError(fmt.Sprintf("Error when trying to prepare all entries: %s", err.Error()))
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(tweetId)
+	if err != nil {
+		return nil, error_formats.ParseError(err)
+	}
+	defer rows.Close()
+
+	results := make([]Destination, 0)
+
+	for rows.Next() {
+		var destination Destination
+		if getError := scanDestination(rows, &destination); getError != nil {
+			message := fmt.Sprintf("Error when trying to get destination: %s", getError.Error())
+			return nil, error_utils.InternalServerError(message)
+		}
+		results = append(results, destination)
+	}
+	if len(results) == 0 {
+		return nil, error_utils.NotFoundError("no records found")
+	}
+	return results, nil
+}
+
+func (dr *destinationRepo) Update(destination *Destination) (*Destination, error_utils.MessageErr) {
+	stmt, err := dr.db.Prepare(queryUpdateDestination)
+
+	if err != nil {
+		message := fmt.Sprintf("error when trying to prepare update: %s", err.Error())
+		return nil, error_utils.InternalServerError(message)
+	}
+	defer stmt.Close()
+
+	_, updateErr := stmt.Exec(destination.Status, destination.RemoteId, destination.RemoteUrl, destination.PostedAt, destination.Attempts, destination.LastError, destination.NextAttemptAt, destination.Modified, destination.Id)
+	if updateErr != nil {
+		return nil, error_formats.ParseError(updateErr)
+	}
+	return destination, nil
+}
+
+// RequeueFailed moves every failed destination back to pending with a clean retry state
+func (dr *destinationRepo) RequeueFailed(modified time.Time) (int64, error_utils.MessageErr) {
+	stmt, err := dr.db.Prepare(queryRequeueFailedDestinations)
+	if err != nil {
+		return 0, error_utils.InternalServerError(fmt.Sprintf("error when trying to prepare update: %s", err.Error()))
+	}
+	defer stmt.Close()
+
+	result, err := stmt.Exec(modified)
+	if err != nil {
+		return 0, error_formats.ParseError(err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, error_formats.ParseError(err)
+	}
+
+	return count, nil
+}
+
+// scanDestination reads a row selected with destinationColumns into the destination
+func scanDestination(row scanner, destination *Destination) error {
+	var accountId sql.NullInt64
+
+	if err := row.Scan(&destination.Id, &destination.TweetId, &accountId, &destination.Status, &destination.RemoteId, &destination.RemoteUrl, &destination.PostedAt, &destination.Attempts, &destination.LastError, &destination.NextAttemptAt, &destination.CreatedAt, &destination.Modified); err != nil {
+		return err
+	}
+
+	destination.AccountId = accountId.Int64
+	return nil
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/error_utils"
+)
+
+// MaxDestinations is the most accounts a single tweet can be cross-posted to
+const MaxDestinations = 10
+
+// Destination is one account a cross-posted tweet is published to. Every destination is posted and
+// retried on its own, so a failure on one network does not fail the others
+type Destination struct {
+	Id      int64 `json:"id" example:"1"`
+	TweetId int64 `json:"tweetId" example:"1"`
+	// AccountId is the account to post as, destinations without one use the default credentials
+	AccountId     int64       `json:"accountId,omitempty" example:"1"`
+	Status        tweetStatus `json:"status" example:"Pending"`
+	RemoteId      string      `json:"remoteId,omitempty" example:"1436255364069150720"`
+	RemoteUrl     string      `json:"remoteUrl,omitempty" example:"https://twitter.com/lattr/status/1436255364069150720"`
+	PostedAt      *time.Time  `json:"postedAt,omitempty" example:"2022-09-09T10:30:01.559636Z"`
+	Attempts      int         `json:"attempts" example:"0"`
+	LastError     string      `json:"lastError,omitempty" example:"mastodon: 503 Service Unavailable"`
+	NextAttemptAt *time.Time  `json:"nextAttemptAt,omitempty" example:"2022-09-09T10:35:01.559636Z"`
+	CreatedAt     time.Time   `json:"createdAt" example:"2022-09-09T10:29:07.559636Z"`
+	Modified      time.Time   `json:"modified" example:"2022-09-09T10:29:07.559636Z"`
+}
+
+// Done reports whether nothing is left to do for the destination
+func (d *Destination) Done() bool {
+	return d.Status == Posted || d.Status == Skipped || d.Status == Failed
+}
+
+// validateDestinations makes sure a cross-posted tweet lists each account once
+func validateDestinations(accountId int64, destinations []Destination) error_utils.MessageErr {
+	if len(destinations) == 0 {
+		return nil
+	}
+
+	if accountId != 0 {
+		return error_utils.UnprocessableEntityError("A tweet with destinations cannot also set an accountId")
+	}
+
+	if len(destinations) > MaxDestinations {
+		return error_utils.UnprocessableEntityError(fmt.Sprintf("A tweet cannot have more than %d destinations", MaxDestinations))
+	}
+
+	seen := make(map[int64]bool, len(destinations))
+
+	for _, d := range destinations {
+		if seen[d.AccountId] {
+			if d.AccountId == 0 {
+				return error_utils.UnprocessableEntityError("The default account is listed more than once")
+			}
+			return error_utils.UnprocessableEntityError(fmt.Sprintf("Account %d is listed more than once", d.AccountId))
+		}
+		seen[d.AccountId] = true
+	}
+
+	return nil
+}
+
+// Settle derives the status of a cross-posted tweet from its destinations. The tweet is Posted once every
+// destination was posted or skipped and Failed once none are left to retry and at least one failed,
+// otherwise it stays queued until the earliest destination retry
+func (t *Tweet) Settle(destinations []Destination) {
+	var failed []string
+	var open, due bool
+	var next *time.Time
+
+	t.Destinations = destinations
+
+	for _, d := range destinations {
+		switch d.Status {
+		case Posted:
+			if t.RemoteId == "" {
+				t.RemoteId = d.RemoteId
+				t.RemoteUrl = d.RemoteUrl
+				t.PostedAt = d.PostedAt
+			}
+		case Skipped:
+		case Failed:
+			failed = append(failed, fmt.Sprintf("destination %d: %s", d.Id, d.LastError))
+		default:
+			open = true
+			if d.NextAttemptAt == nil {
+				due = true
+			} else if next == nil || d.NextAttemptAt.Before(*next) {
+				next = d.NextAttemptAt
+			}
+		}
+	}
+
+	t.LastError = strings.Join(failed, "; ")
+
+	switch {
+	case open:
+		if t.Status == Posted || t.Status == Failed || t.Status == Skipped {
+			t.Status = Pending
+		}
+		t.NextAttemptAt = next
+		if due {
+			t.NextAttemptAt = nil
+		}
+	case len(failed) > 0:
+		t.Status = Failed
+		t.NextAttemptAt = nil
+	default:
+		t.Status = Posted
+		t.NextAttemptAt = nil
+	}
+
+	if !open {
+		t.ScheduleDeletion()
+	}
+}
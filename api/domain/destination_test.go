@@ -0,0 +1,298 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var destinationColumnNames = []string{"Id", "TweetId", "AccountId", "Status", "RemoteId", "RemoteUrl", "PostedAt", "Attempts", "LastError", "NextAttemptAt", "CreatedAt", "Modified"}
+
+func destinationRow(d Destination) []driver.Value {
+	return []driver.Value{d.Id, d.TweetId, accountIdValue(d.AccountId), d.Status, d.RemoteId, d.RemoteUrl, d.PostedAt, d.Attempts, d.LastError, d.NextAttemptAt, d.CreatedAt, d.Modified}
+}
+
+func TestTweet_ValidateDestinations(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		tweet := &Tweet{Message: "the message", Destinations: []Destination{{}, {AccountId: 1}, {AccountId: 2}}}
+
+		assert.Nil(t, tweet.Validate())
+	})
+
+	t.Run("Account and destinations", func(t *testing.T) {
+		tweet := &Tweet{Message: "the message", AccountId: 1, Destinations: []Destination{{AccountId: 2}}}
+
+		assert.Equal(t, "A tweet with destinations cannot also set an accountId", tweet.Validate().Message())
+	})
+
+	t.Run("Duplicate account", func(t *testing.T) {
+		tweet := &Tweet{Message: "the message", Destinations: []Destination{{AccountId: 2}, {AccountId: 2}}}
+
+		err := tweet.Validate()
+
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.Equal(t, "Account 2 is listed more than once", err.Message())
+	})
+
+	t.Run("Duplicate default account", func(t *testing.T) {
+		thread := &Thread{Messages: []string{"first", "second"}, Destinations: []Destination{{}, {}}}
+
+		assert.Equal(t, "The default account is listed more than once", thread.Validate().Message())
+	})
+
+	t.Run("Too many", func(t *testing.T) {
+		tweet := &Tweet{Message: "the message", Destinations: make([]Destination, MaxDestinations+1)}
+
+		assert.Equal(t, "A tweet cannot have more than 10 destinations", tweet.Validate().Message())
+	})
+}
+
+func TestTweet_Settle(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	soon := now.Add(time.Minute)
+
+	t.Run("Posted once every destination is posted or skipped", func(t *testing.T) {
+		tweet := &Tweet{Status: Pending}
+
+		tweet.Settle([]Destination{
+			{Id: 1, Status: Skipped},
+			{Id: 2, Status: Posted, RemoteId: "2", RemoteUrl: "https://mastodon.social/@lattr/2", PostedAt: &now},
+			{Id: 3, Status: Posted, RemoteId: "3"},
+		})
+
+		assert.EqualValues(t, Posted, tweet.Status)
+		assert.Equal(t, "2", tweet.RemoteId)
+		assert.Equal(t, "https://mastodon.social/@lattr/2", tweet.RemoteUrl)
+		assert.Equal(t, &now, tweet.PostedAt)
+		assert.Len(t, tweet.Destinations, 3)
+	})
+
+	t.Run("A failed destination does not fail the others", func(t *testing.T) {
+		tweet := &Tweet{Status: Pending}
+
+		tweet.Settle([]Destination{
+			{Id: 1, Status: Failed, LastError: "mastodon: 422 Validation failed"},
+			{Id: 2, Status: Pending, NextAttemptAt: &later},
+			{Id: 3, Status: Pending, NextAttemptAt: &soon},
+		})
+
+		assert.EqualValues(t, Pending, tweet.Status)
+		assert.Equal(t, &soon, tweet.NextAttemptAt)
+		assert.Equal(t, "destination 1: mastodon: 422 Validation failed", tweet.LastError)
+	})
+
+	t.Run("Due destinations clear the retry time", func(t *testing.T) {
+		tweet := &Tweet{Status: Pending, NextAttemptAt: &later}
+
+		tweet.Settle([]Destination{{Id: 1, Status: Pending}, {Id: 2, Status: Pending, NextAttemptAt: &later}})
+
+		assert.Nil(t, tweet.NextAttemptAt)
+	})
+
+	t.Run("Failed once nothing is left to retry", func(t *testing.T) {
+		tweet := &Tweet{Status: Pending}
+
+		tweet.Settle([]Destination{{Id: 1, Status: Posted, RemoteId: "1"}, {Id: 2, Status: Failed, LastError: "auth"}, {Id: 3, Status: Failed, LastError: "gone"}})
+
+		assert.EqualValues(t, Failed, tweet.Status)
+		assert.Equal(t, "destination 2: auth; destination 3: gone", tweet.LastError)
+	})
+
+	t.Run("Retrying a destination re-opens the tweet", func(t *testing.T) {
+		tweet := &Tweet{Status: Failed, LastError: "destination 2: auth"}
+
+		tweet.Settle([]Destination{{Id: 1, Status: Posted}, {Id: 2, Status: Pending}})
+
+		assert.EqualValues(t, Pending, tweet.Status)
+		assert.Equal(t, "", tweet.LastError)
+	})
+}
+
+func TestDestinationRepo_Create(t *testing.T) {
+	var createdAt = time.Now().Local()
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitDestinationRepository(db)
+
+		mock.ExpectPrepare("INSERT INTO destinations").ExpectQuery().
+			WithArgs(int64(1), nil, Pending, createdAt, createdAt).
+			WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(4))
+
+		destination, createErr := s.Create(&Destination{TweetId: 1, Status: Pending, CreatedAt: createdAt, Modified: createdAt})
+
+		assert.Nil(t, createErr)
+		assert.EqualValues(t, 4, destination.Id)
+	})
+
+	t.Run("Unknown account", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitDestinationRepository(db)
+
+		mock.ExpectPrepare("INSERT INTO destinations").ExpectQuery().WillReturnError(errors.New("violates foreign key constraint"))
+
+		destination, createErr := s.Create(&Destination{TweetId: 1, AccountId: 9, Status: Pending})
+
+		assert.Nil(t, destination)
+		assert.Equal(t, "error when trying to save data: violates foreign key constraint", createErr.Message())
+	})
+}
+
+func TestDestinationRepo_Get(t *testing.T) {
+	var createdAt = time.Now().Local()
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitDestinationRepository(db)
+
+		expected := Destination{Id: 4, TweetId: 1, AccountId: 2, Status: Posted, RemoteId: "7", CreatedAt: createdAt, Modified: createdAt}
+		mock.ExpectPrepare("SELECT (.+) FROM destinations").ExpectQuery().WithArgs(int64(4)).
+			WillReturnRows(sqlmock.NewRows(destinationColumnNames).AddRow(destinationRow(expected)...))
+
+		destination, getErr := s.Get(4)
+
+		assert.Nil(t, getErr)
+		assert.Equal(t, &expected, destination)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitDestinationRepository(db)
+
+		mock.ExpectPrepare("SELECT (.+) FROM destinations").ExpectQuery().WithArgs(int64(4)).WillReturnRows(sqlmock.NewRows(destinationColumnNames))
+
+		destination, getErr := s.Get(4)
+
+		assert.Nil(t, destination)
+		assert.EqualValues(t, http.StatusNotFound, getErr.Status())
+	})
+}
+
+func TestDestinationRepo_List(t *testing.T) {
+	var createdAt = time.Now().Local()
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitDestinationRepository(db)
+
+		rows := sqlmock.NewRows(destinationColumnNames).
+			AddRow(destinationRow(Destination{Id: 1, TweetId: 1, Status: Posted, CreatedAt: createdAt, Modified: createdAt})...).
+			AddRow(destinationRow(Destination{Id: 2, TweetId: 1, AccountId: 3, Status: Pending, CreatedAt: createdAt, Modified: createdAt})...)
+		mock.ExpectPrepare("SELECT (.+) FROM destinations WHERE TweetId").ExpectQuery().WithArgs(int64(1)).WillReturnRows(rows)
+
+		destinations, listErr := s.List(1)
+
+		assert.Nil(t, listErr)
+		assert.Len(t, destinations, 2)
+		assert.EqualValues(t, 0, destinations[0].AccountId)
+		assert.EqualValues(t, 3, destinations[1].AccountId)
+	})
+
+	t.Run("Not cross-posted", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitDestinationRepository(db)
+
+		mock.ExpectPrepare("SELECT (.+) FROM destinations WHERE TweetId").ExpectQuery().WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows(destinationColumnNames))
+
+		destinations, listErr := s.List(1)
+
+		assert.Nil(t, destinations)
+		assert.EqualValues(t, http.StatusNotFound, listErr.Status())
+	})
+}
+
+func TestDestinationRepo_Update(t *testing.T) {
+	modified := time.Now().Local()
+	destination := &Destination{Id: 4, Status: Failed, Attempts: 5, LastError: "gone", Modified: modified}
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitDestinationRepository(db)
+
+		mock.ExpectPrepare("UPDATE destinations").ExpectExec().
+			WithArgs(Failed, "", "", nil, 5, "gone", nil, modified, int64(4)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		updated, updateErr := s.Update(destination)
+
+		assert.Nil(t, updateErr)
+		assert.Equal(t, destination, updated)
+	})
+
+	t.Run("Invalid SQL Query", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitDestinationRepository(db)
+
+		mock.ExpectPrepare("UPDATE destinations").WillReturnError(errors.New("invalid sql query"))
+
+		updated, updateErr := s.Update(destination)
+
+		assert.Nil(t, updated)
+		assert.Equal(t, "error when trying to prepare update: invalid sql query", updateErr.Message())
+	})
+}
+
+func TestDestinationRepo_RequeueFailed(t *testing.T) {
+	modified := time.Now().Local()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := InitDestinationRepository(db)
+
+	mock.ExpectPrepare("UPDATE destinations SET Status='Pending'").ExpectExec().WithArgs(modified).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	count, rqErr := s.RequeueFailed(modified)
+
+	assert.Nil(t, rqErr)
+	assert.EqualValues(t, 2, count)
+}
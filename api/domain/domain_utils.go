@@ -1,5 +1,69 @@
 package domain
 
+import (
+	"database/sql"
+	"os"
+	"sync"
+
+	"github.com/RemeJuan/lattr/utils/env"
+)
+
+const (
+	defaultMaxOpenConns = 10
+	defaultMaxIdleConns = 2
+)
+
+var (
+	pool     *sql.DB
+	poolErr  error
+	poolOnce sync.Once
+)
+
+// openPool opens the connection pool every repository shares, so each replica holds at most
+// DB_MAX_OPEN_CONNS connections however many repositories it has. The leader lock keeps one of
+// them for as long as the replica leads
+func openPool() (*sql.DB, error) {
+	poolOnce.Do(func() {
+		pool, poolErr = sql.Open("postgres", os.Getenv("DATABASE_URL"))
+		if poolErr != nil {
+			return
+		}
+
+		loadPoolConfig().apply(pool)
+	})
+
+	return pool, poolErr
+}
+
+// poolConfig limits the connections of the shared pool
+type poolConfig struct {
+	maxOpenConns int
+	maxIdleConns int
+}
+
+// loadPoolConfig reads the limits from DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS
+func loadPoolConfig() poolConfig {
+	return poolConfig{
+		maxOpenConns: env.Int("DB_MAX_OPEN_CONNS", defaultMaxOpenConns),
+		maxIdleConns: env.Int("DB_MAX_IDLE_CONNS", defaultMaxIdleConns),
+	}
+}
+
+func (c poolConfig) apply(db *sql.DB) {
+	db.SetMaxOpenConns(c.maxOpenConns)
+	db.SetMaxIdleConns(c.maxIdleConns)
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// nullableId stores an unset reference as NULL so it does not violate its foreign key
+func nullableId(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
 func checkError(err error) {
 	if err != nil {
 		panic(err)
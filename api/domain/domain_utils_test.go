@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolConfig(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		assert.Equal(t, poolConfig{maxOpenConns: 10, maxIdleConns: 2}, loadPoolConfig())
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		_ = os.Setenv("DB_MAX_OPEN_CONNS", "4")
+		_ = os.Setenv("DB_MAX_IDLE_CONNS", "1")
+		defer os.Unsetenv("DB_MAX_OPEN_CONNS")
+		defer os.Unsetenv("DB_MAX_IDLE_CONNS")
+
+		assert.Equal(t, poolConfig{maxOpenConns: 4, maxIdleConns: 1}, loadPoolConfig())
+	})
+
+	t.Run("Applied to the pool", func(t *testing.T) {
+		db, _, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		poolConfig{maxOpenConns: 4, maxIdleConns: 1}.apply(db)
+
+		assert.Equal(t, 4, db.Stats().MaxOpenConnections)
+	})
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/RemeJuan/lattr/utils/error_formats"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+)
+
+var (
+	LeaderRepo LeaderRepoInterface = &leaderRepo{}
+)
+
+var (
+	queryTryAdvisoryLock = "SELECT pg_try_advisory_lock($1);"
+	// queryHoldsAdvisoryLock checks the session still holds the lock, a bigint key is split over classid and objid
+	queryHoldsAdvisoryLock = "SELECT EXISTS (SELECT 1 FROM pg_locks WHERE locktype = 'advisory' AND granted AND pid = pg_backend_pid() AND ((classid::bigint << 32) | objid::bigint) = $1);"
+	queryAdvisoryUnlockAll = "SELECT pg_advisory_unlock_all();"
+)
+
+type LeaderRepoInterface interface {
+	Initialize() *sql.DB
+	TryLock(int64) (bool, error_utils.MessageErr)
+}
+
+// leaderRepo holds a session level advisory lock on a connection of its own. Postgres releases the
+// lock when that session ends, so a replica that stops or loses its connection hands the lock over
+type leaderRepo struct {
+	db   *sql.DB
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+func InitLeaderRepository(db *sql.DB) LeaderRepoInterface {
+	return &leaderRepo{
+		db: db,
+	}
+}
+
+func (lr *leaderRepo) Initialize() *sql.DB {
+	var err error
+	lr.db, err = openPool()
+
+	checkError(err)
+
+	fmt.Println("Connected!")
+
+	return lr.db
+}
+
+// TryLock reports whether this process holds the advisory lock for key, taking it when it is free.
+// Calls while the lock is held only check that the session holding it is still alive
+func (lr *leaderRepo) TryLock(key int64) (bool, error_utils.MessageErr) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	ctx := context.Background()
+
+	if lr.conn != nil {
+		var held bool
+		if err := lr.conn.QueryRowContext(ctx, queryHoldsAdvisoryLock, key).Scan(&held); err == nil && held {
+			return true, nil
+		}
+
+		// the session that held the lock is gone, so is the lock. Should the check have failed on a live
+		// session, the lock is dropped before the connection goes back to the pool
+		_, _ = lr.conn.ExecContext(ctx, queryAdvisoryUnlockAll)
+		_ = lr.conn.Close()
+		lr.conn = nil
+	}
+
+	conn, err := lr.db.Conn(ctx)
+	if err != nil {
+		return false, error_utils.InternalServerError(fmt.Sprintf("Error when trying to open a connection: %s", err.Error()))
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, queryTryAdvisoryLock, key).Scan(&locked); err != nil {
+		_ = conn.Close()
+		return false, error_formats.ParseError(err)
+	}
+
+	if !locked {
+		_ = conn.Close()
+		return false, nil
+	}
+
+	lr.conn = conn
+	return true, nil
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const lockKey int64 = 42
+
+func TestLeaderRepo_TryLock(t *testing.T) {
+	t.Run("Takes the free lock", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitLeaderRepository(db)
+
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(lockKey).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+
+		held, lockErr := s.TryLock(lockKey)
+
+		assert.Nil(t, lockErr)
+		assert.True(t, held)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Keeps the lock it holds", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitLeaderRepository(db)
+
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(lockKey).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+		mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM pg_locks").WithArgs(lockKey).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		_, _ = s.TryLock(lockKey)
+		held, lockErr := s.TryLock(lockKey)
+
+		assert.Nil(t, lockErr)
+		assert.True(t, held)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Held by another replica", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitLeaderRepository(db)
+
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(lockKey).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+
+		held, lockErr := s.TryLock(lockKey)
+
+		assert.Nil(t, lockErr)
+		assert.False(t, held)
+	})
+
+	t.Run("Takes the lock again after losing the session", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitLeaderRepository(db)
+
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(lockKey).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+		mock.ExpectQuery("SELECT EXISTS").WithArgs(lockKey).WillReturnError(errors.New("connection reset by peer"))
+		mock.ExpectExec("SELECT pg_advisory_unlock_all").WillReturnError(errors.New("connection reset by peer"))
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(lockKey).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+
+		_, _ = s.TryLock(lockKey)
+		held, lockErr := s.TryLock(lockKey)
+
+		assert.Nil(t, lockErr)
+		assert.False(t, held)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Lock query failed", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitLeaderRepository(db)
+
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(lockKey).WillReturnError(errors.New("connection refused"))
+
+		held, lockErr := s.TryLock(lockKey)
+
+		assert.False(t, held)
+		assert.EqualValues(t, http.StatusInternalServerError, lockErr.Status())
+		assert.Equal(t, "error when trying to save data: connection refused", lockErr.Message())
+	})
+}
@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/RemeJuan/lattr/utils/error_formats"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+)
+
+var (
+	MediaRepo MediaRepoInterface = &mediaRepo{}
+)
+
+var (
+	queryLockMediaTweet = "SELECT Id FROM tweets WHERE Id=$1 FOR UPDATE;"
+	queryInsertMedia    = "INSERT INTO media(TweetId, MimeType, AltText, Data, CreatedAt, Modified) SELECT $1, $2, $3, $4, $5, $6 WHERE (SELECT count(*) FROM media WHERE TweetId=$1) < $7 RETURNING Id;"
+	queryGetMedia       = "SELECT Id, TweetId, MimeType, AltText, Data, CreatedAt, Modified FROM media WHERE Id=$1;"
+	queryListMedia      = "SELECT Id, TweetId, MimeType, AltText, Data, CreatedAt, Modified FROM media WHERE TweetId=$1 ORDER BY Id asc;"
+	queryDeleteMedia    = "DELETE FROM media WHERE Id=$1;"
+)
+
+type MediaRepoInterface interface {
+	Initialize() *sql.DB
+	Create(*Media) (*Media, error_utils.MessageErr)
+	Get(int64) (*Media, error_utils.MessageErr)
+	List(int64) ([]Media, error_utils.MessageErr)
+	Delete(int64) error_utils.MessageErr
+}
+
+type mediaRepo struct {
+	db *sql.DB
+}
+
+func InitMediaRepository(db *sql.DB) MediaRepoInterface {
+	return &mediaRepo{
+		db: db,
+	}
+}
+
+func (mr *mediaRepo) Initialize() *sql.DB {
+	var err error
+	mr.db, err = openPool()
+
+	checkError(err)
+
+	fmt.Println("Connected!")
+
+	return mr.db
+}
+
+// Create adds the media while holding a lock on its tweet, so concurrent uploads cannot take a
+// tweet past MaxTweetMedia attachments
+func (mr *mediaRepo) Create(media *Media) (*Media, error_utils.MessageErr) {
+	var id int64
+	tx, err := mr.db.Begin()
+	if err != nil {
+		return nil, error_utils.InternalServerError(fmt.Sprintf("error when trying to save data: %s", err.Error()))
+	}
+	defer tx.Rollback()
+
+	if lockErr := tx.QueryRow(queryLockMediaTweet, media.TweetId).Scan(&id); lockErr != nil {
+		return nil, error_formats.ParseError(lockErr)
+	}
+
+	stmt, err := tx.Prepare(queryInsertMedia)
+
+	if err != nil {
+		message := fmt.Sprintf("Error when trying to prepare all entries: %s", err.Error())
+		return nil, error_utils.InternalServerError(message)
+	}
+	defer stmt.Close()
+
+	insertResult, createErr := stmt.Query(media.TweetId, media.MimeType, media.AltText, media.Data, media.CreatedAt, media.Modified, MaxTweetMedia)
+	if createErr != nil {
+		return nil, error_formats.ParseError(createErr)
+	}
+
+	if !insertResult.Next() {
+		insertResult.Close()
+		return nil, error_utils.UnprocessableEntityError(fmt.Sprintf("A tweet can have at most %d media attachments", MaxTweetMedia))
+	}
+	inErr := insertResult.Scan(&id)
+	insertResult.Close()
+	if inErr != nil {
+		message := fmt.Sprintf("error when trying to save data: %s", inErr.Error())
+		return nil, error_utils.InternalServerError(message)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, error_utils.InternalServerError(fmt.Sprintf("error when trying to save data: %s", err.Error()))
+	}
+
+	media.Id = id
+	return media, nil
+}
+
+func (mr *mediaRepo) Get(id int64) (*Media, error_utils.MessageErr) {
+	stmt, err := mr.db.Prepare(queryGetMedia)
+
+	if err != nil {
+		message := fmt.Sprintf("Error retrieving record: %s", err)
+		return nil, error_utils.InternalServerError(message)
+	}
+
+	defer stmt.Close()
+
+	var media Media
+	result := stmt.QueryRow(id)
+
+	if getError := result.Scan(&media.Id, &media.TweetId, &media.MimeType, &media.AltText, &media.Data, &media.CreatedAt, &media.Modified); getError != nil {
+		return nil, error_formats.ParseError(getError)
+	}
+
+	return &media, nil
+}
+
+func (mr *mediaRepo) List(tweetId int64) ([]Media, error_utils.MessageErr) {
+	stmt, err := mr.db.Prepare(queryListMedia)
+
+	if err != nil {
+		return nil, error_utils.InternalServerError(fmt.Sprintf("Error when trying to prepare all entries: %s", err.Error()))
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(tweetId)
+	if err != nil {
+		return nil, error_formats.ParseError(err)
+	}
+	defer rows.Close()
+
+	results := make([]Media, 0)
+
+	for rows.Next() {
+		var media Media
+		if getError := rows.Scan(&media.Id, &media.TweetId, &media.MimeType, &media.AltText, &media.Data, &media.CreatedAt, &media.Modified); getError != nil {
+			message := fmt.Sprintf("Error when trying to get media: %s", getError.Error())
+			return nil, error_utils.InternalServerError(message)
+		}
+		results = append(results, media)
+	}
+	if len(results) == 0 {
+		return nil, error_utils.NotFoundError("no records found")
+	}
+	return results, nil
+}
+
+func (mr *mediaRepo) Delete(id int64) error_utils.MessageErr {
+	stmt, err := mr.db.Prepare(queryDeleteMedia)
+	if err != nil {
+		return error_utils.InternalServerError(fmt.Sprintf("error when trying to delete record: %s", err.Error()))
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(id); err != nil {
+		return error_utils.InternalServerError(fmt.Sprintf("error when trying to delete record %s", err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/error_utils"
+)
+
+const (
+	MaxTweetMedia = 4
+	MaxMediaSize  = 5 * 1024 * 1024
+	MaxAltText    = 1000
+)
+
+var mediaTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+type Media struct {
+	Id        int64     `json:"id" example:"1"`
+	TweetId   int64     `json:"tweetId" example:"1"`
+	MimeType  string    `json:"mimeType" example:"image/png"`
+	AltText   string    `json:"altText" example:"A cat asleep on a keyboard"`
+	Data      []byte    `json:"-"`
+	CreatedAt time.Time `json:"createdAt" example:"2022-09-09T10:29:07.559636Z"`
+	Modified  time.Time `json:"modified" example:"2022-09-09T10:29:07.559636Z"`
+}
+
+func (m *Media) Validate() error_utils.MessageErr {
+	m.AltText = strings.TrimSpace(m.AltText)
+
+	if len(m.Data) == 0 {
+		return error_utils.UnprocessableEntityError("Media cannot be empty")
+	}
+
+	if len(m.Data) > MaxMediaSize {
+		return error_utils.UnprocessableEntityError(fmt.Sprintf("Media cannot be larger than %d bytes", MaxMediaSize))
+	}
+
+	if !contains(mediaTypes, m.MimeType) {
+		return error_utils.UnprocessableEntityError("Media must be a jpeg, png, gif or webp image")
+	}
+
+	if len([]rune(m.AltText)) > MaxAltText {
+		return error_utils.UnprocessableEntityError(fmt.Sprintf("Alt text cannot be longer than %d characters", MaxAltText))
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package domain
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var mediaColumns = []string{"Id", "TweetId", "MimeType", "AltText", "Data", "CreatedAt", "Modified"}
+
+func TestMedia_Validate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		media := &Media{MimeType: "image/png", AltText: " alt ", Data: []byte("data")}
+
+		assert.Nil(t, media.Validate())
+		assert.Equal(t, "alt", media.AltText)
+	})
+
+	t.Run("Empty data", func(t *testing.T) {
+		media := &Media{MimeType: "image/png"}
+
+		err := media.Validate()
+
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.Equal(t, "Media cannot be empty", err.Message())
+	})
+
+	t.Run("Unsupported type", func(t *testing.T) {
+		media := &Media{MimeType: "video/mp4", Data: []byte("data")}
+
+		err := media.Validate()
+
+		assert.Equal(t, "Media must be a jpeg, png, gif or webp image", err.Message())
+	})
+
+	t.Run("Alt text too long", func(t *testing.T) {
+		media := &Media{MimeType: "image/png", Data: []byte("data"), AltText: strings.Repeat("a", MaxAltText+1)}
+
+		err := media.Validate()
+
+		assert.Equal(t, "Alt text cannot be longer than 1000 characters", err.Message())
+	})
+}
+
+func TestMediaRepo_Create(t *testing.T) {
+	var createdAt = time.Now().Local()
+	const recordId int64 = 1
+	const tweetId int64 = 2
+
+	request := &Media{
+		TweetId:   tweetId,
+		MimeType:  "image/png",
+		AltText:   "alt",
+		Data:      []byte("data"),
+		CreatedAt: createdAt,
+		Modified:  createdAt,
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitMediaRepository(db)
+
+		sqlReturn := sqlmock.NewRows([]string{"Id"}).AddRow(recordId)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT Id FROM tweets WHERE Id=\\$1 FOR UPDATE").WithArgs(tweetId).WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(tweetId))
+		mock.ExpectPrepare("INSERT INTO media").ExpectQuery().WithArgs(tweetId, "image/png", "alt", []byte("data"), createdAt, createdAt, MaxTweetMedia).WillReturnRows(sqlReturn)
+		mock.ExpectCommit()
+
+		got, crErr := s.Create(request)
+
+		assert.Nil(t, crErr)
+		assert.Equal(t, recordId, got.Id)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Tweet at the media limit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitMediaRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT Id FROM tweets").WithArgs(tweetId).WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(tweetId))
+		mock.ExpectPrepare("INSERT INTO media(.+)WHERE \\(SELECT count\\(\\*\\) FROM media WHERE TweetId=\\$1\\) < \\$7").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"Id"}))
+		mock.ExpectRollback()
+
+		got, crErr := s.Create(request)
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusUnprocessableEntity, crErr.Status())
+		assert.Equal(t, "A tweet can have at most 4 media attachments", crErr.Message())
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Tweet not found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitMediaRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT Id FROM tweets").WithArgs(tweetId).WillReturnRows(sqlmock.NewRows([]string{"Id"}))
+		mock.ExpectRollback()
+
+		got, crErr := s.Create(request)
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusNotFound, crErr.Status())
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid SQL query", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitMediaRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT Id FROM tweets").WithArgs(tweetId).WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(tweetId))
+		mock.ExpectPrepare("INSERT INTO media").WillReturnError(errors.New("invalid sql query"))
+		mock.ExpectRollback()
+
+		got, crErr := s.Create(request)
+
+		assert.Nil(t, got)
+		assert.Equal(t, "Error when trying to prepare all entries: invalid sql query", crErr.Message())
+	})
+}
+
+func TestMediaRepo_List(t *testing.T) {
+	var createdAt = time.Now().Local()
+	const tweetId int64 = 2
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitMediaRepository(db)
+
+		rows := sqlmock.NewRows(mediaColumns).
+			AddRow(1, tweetId, "image/png", "first", []byte("one"), createdAt, createdAt).
+			AddRow(2, tweetId, "image/jpeg", "second", []byte("two"), createdAt, createdAt)
+
+		mock.ExpectPrepare("SELECT (.+) FROM media").ExpectQuery().WithArgs(tweetId).WillReturnRows(rows)
+
+		got, lErr := s.List(tweetId)
+
+		assert.Nil(t, lErr)
+		assert.Len(t, got, 2)
+		assert.Equal(t, "second", got[1].AltText)
+		assert.Equal(t, []byte("one"), got[0].Data)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitMediaRepository(db)
+
+		mock.ExpectPrepare("SELECT (.+) FROM media").ExpectQuery().WithArgs(tweetId).WillReturnRows(sqlmock.NewRows(mediaColumns))
+
+		got, lErr := s.List(tweetId)
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusNotFound, lErr.Status())
+	})
+}
+
+func TestMediaRepo_Delete(t *testing.T) {
+	const recordId int64 = 1
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitMediaRepository(db)
+
+		mock.ExpectPrepare("DELETE FROM media").ExpectExec().WithArgs(recordId).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.Nil(t, s.Delete(recordId))
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitMediaRepository(db)
+
+		mock.ExpectPrepare("DELETE FROM media").ExpectExec().WithArgs(recordId).WillReturnError(errors.New("delete failed"))
+
+		dErr := s.Delete(recordId)
+
+		assert.Equal(t, "error when trying to delete record delete failed", dErr.Message())
+	})
+}
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/RemeJuan/lattr/utils/error_formats"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/lib/pq"
+)
+
+var (
+	OutboxRepo OutboxRepoInterface = &outboxRepo{}
+)
+
+const outboxColumns = "Id, AccountId, Network, Message, Kind, Target, ReplyTo, MediaCount, Visibility, ContentWarning, PollOptions, PollDurationMinutes, CreatedAt"
+
+var (
+	queryInsertOutboxEntry = "INSERT INTO outbox(AccountId, Network, Message, Kind, Target, ReplyTo, MediaCount, Visibility, ContentWarning, PollOptions, PollDurationMinutes, CreatedAt) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING Id;"
+	queryListOutbox        = "SELECT " + outboxColumns + " FROM outbox ORDER BY Id desc;"
+)
+
+type OutboxRepoInterface interface {
+	Initialize() *sql.DB
+	Create(*OutboxEntry) (*OutboxEntry, error_utils.MessageErr)
+	List() ([]OutboxEntry, error_utils.MessageErr)
+}
+
+type outboxRepo struct {
+	db *sql.DB
+}
+
+func InitOutboxRepository(db *sql.DB) OutboxRepoInterface {
+	return &outboxRepo{
+		db: db,
+	}
+}
+
+func (obr *outboxRepo) Initialize() *sql.DB {
+	var err error
+	obr.db, err = openPool()
+
+	checkError(err)
+
+	fmt.Println("Connected!")
+
+	return obr.db
+}
+
+func (obr *outboxRepo) Create(entry *OutboxEntry) (*OutboxEntry, error_utils.MessageErr) {
+	var id int64
+	stmt, err := obr.db.Prepare(queryInsertOutboxEntry)
+
+	if err != nil {
+		message := fmt.Sprintf("Error when trying to prepare all entries: %s", err.Error())
+		return nil, error_utils.InternalServerError(message)
+	}
+	defer stmt.Close()
+
+	insertResult, createErr := stmt.Query(entry.AccountId, entry.Network, entry.Message, entry.Kind, entry.Target, entry.ReplyTo, entry.MediaCount, entry.Visibility, entry.ContentWarning, pq.Array(entry.PollOptions), entry.PollDurationMinutes, entry.CreatedAt)
+	if createErr != nil {
+		return nil, error_formats.ParseError(createErr)
+	}
+
+	insertResult.Next()
+	if inErr := insertResult.Scan(&id); inErr != nil {
+		message := fmt.Sprintf("error when trying to save data: %s", inErr.Error())
+		return nil, error_utils.InternalServerError(message)
+	}
+
+	entry.Id = id
+	return entry, nil
+}
+
+// List returns everything in the outbox, newest first
+func (obr *outboxRepo) List() ([]OutboxEntry, error_utils.MessageErr) {
+	stmt, err := obr.db.Prepare(queryListOutbox)
+
+	if err != nil {
+		return nil, error_utils.InternalServerError(fmt.Sprintf("Error when trying to prepare all entries: %s", err.Error()))
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, error_formats.ParseError(err)
+	}
+	defer rows.Close()
+
+	results := make([]OutboxEntry, 0)
+
+	for rows.Next() {
+		var entry OutboxEntry
+		if getError := rows.Scan(&entry.Id, &entry.AccountId, &entry.Network, &entry.Message, &entry.Kind, &entry.Target, &entry.ReplyTo, &entry.MediaCount, &entry.Visibility, &entry.ContentWarning, pq.Array(&entry.PollOptions), &entry.PollDurationMinutes, &entry.CreatedAt); getError != nil {
+			message := fmt.Sprintf("Error when trying to get outbox entry: %s", getError.Error())
+			return nil, error_utils.InternalServerError(message)
+		}
+		results = append(results, entry)
+	}
+	if len(results) == 0 {
+		return nil, error_utils.NotFoundError("no records found")
+	}
+	return results, nil
+}
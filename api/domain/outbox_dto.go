@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// OutboxEntry is a post the scheduler would have published, recorded instead of posting while in dry-run mode
+type OutboxEntry struct {
+	Id int64 `json:"id" example:"1"`
+	// AccountId is the account the post would have been published as, 0 is the default account
+	AccountId int64  `json:"accountId" example:"1"`
+	Network   string `json:"network" example:"twitter"`
+	Message   string `json:"message" example:"TIL: Life is awesome"`
+	// Kind and Target are the kind of tweet and the status it retweets or quotes
+	Kind   string `json:"kind" example:"original"`
+	Target string `json:"target,omitempty" example:"1436255364069150720"`
+	// ReplyTo is the outbox ID of the post this one replies to, set for every part of a thread but the first
+	ReplyTo        string `json:"replyTo,omitempty" example:"1"`
+	MediaCount     int    `json:"mediaCount" example:"0"`
+	Visibility     string `json:"visibility,omitempty" example:"unlisted"`
+	ContentWarning string `json:"contentWarning,omitempty" example:"Spoilers"`
+	// PollOptions and PollDurationMinutes are the poll posted with the post, they are empty without one
+	PollOptions         []string  `json:"pollOptions,omitempty" example:"Tabs,Spaces"`
+	PollDurationMinutes int       `json:"pollDurationMinutes,omitempty" example:"1440"`
+	CreatedAt           time.Time `json:"createdAt" example:"2022-09-09T10:30:01.559636Z"`
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var outboxColumnNames = []string{"Id", "AccountId", "Network", "Message", "Kind", "Target", "ReplyTo", "MediaCount", "Visibility", "ContentWarning", "PollOptions", "PollDurationMinutes", "CreatedAt"}
+
+func TestOutboxRepo_Create(t *testing.T) {
+	var createdAt = time.Now().Local()
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitOutboxRepository(db)
+
+		mock.ExpectPrepare("INSERT INTO outbox").ExpectQuery().
+			WithArgs(int64(2), "mastodon", "the message", "original", "", "4", 1, "unlisted", "", pq.Array([]string(nil)), 0, createdAt).
+			WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(5))
+
+		entry, createErr := s.Create(&OutboxEntry{AccountId: 2, Network: "mastodon", Message: "the message", Kind: "original", ReplyTo: "4", MediaCount: 1, Visibility: "unlisted", CreatedAt: createdAt})
+
+		assert.Nil(t, createErr)
+		assert.EqualValues(t, 5, entry.Id)
+	})
+
+	t.Run("Invalid SQL Query", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitOutboxRepository(db)
+
+		mock.ExpectPrepare("INSERT INTO outbox").WillReturnError(errors.New("invalid sql query"))
+
+		entry, createErr := s.Create(&OutboxEntry{Message: "the message"})
+
+		assert.Nil(t, entry)
+		assert.Equal(t, "Error when trying to prepare all entries: invalid sql query", createErr.Message())
+	})
+}
+
+func TestOutboxRepo_List(t *testing.T) {
+	var createdAt = time.Now().Local()
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitOutboxRepository(db)
+
+		rows := sqlmock.NewRows(outboxColumnNames).
+			AddRow(2, 0, "twitter", "", "retweet", "1436255364069150720", "", 0, "", "", nil, 0, createdAt).
+			AddRow(1, 0, "twitter", "first", "original", "", "", 2, "", "", pq.Array([]string{"Tabs", "Spaces"}), 60, createdAt)
+		mock.ExpectPrepare("SELECT (.+) FROM outbox ORDER BY Id desc").ExpectQuery().WillReturnRows(rows)
+
+		entries, listErr := s.List()
+
+		assert.Nil(t, listErr)
+		assert.Len(t, entries, 2)
+		assert.Equal(t, OutboxEntry{Id: 2, Network: "twitter", Kind: "retweet", Target: "1436255364069150720", CreatedAt: createdAt}, entries[0])
+		assert.Equal(t, 2, entries[1].MediaCount)
+		assert.Equal(t, []string{"Tabs", "Spaces"}, entries[1].PollOptions)
+		assert.Equal(t, 60, entries[1].PollDurationMinutes)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitOutboxRepository(db)
+
+		mock.ExpectPrepare("SELECT (.+) FROM outbox").ExpectQuery().WillReturnRows(sqlmock.NewRows(outboxColumnNames))
+
+		entries, listErr := s.List()
+
+		assert.Nil(t, entries)
+		assert.EqualValues(t, http.StatusNotFound, listErr.Status())
+	})
+}
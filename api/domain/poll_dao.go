@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/RemeJuan/lattr/utils/error_formats"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/lib/pq"
+)
+
+var (
+	PollRepo PollRepoInterface = &pollRepo{}
+)
+
+var (
+	queryInsertPoll = "INSERT INTO polls(TweetId, Options, DurationMinutes, CreatedAt) VALUES($1, $2, $3, $4) RETURNING Id;"
+	queryGetPoll    = "SELECT Id, TweetId, Options, DurationMinutes, CreatedAt FROM polls WHERE TweetId=$1;"
+	queryDeletePoll = "DELETE FROM polls WHERE TweetId=$1;"
+)
+
+type PollRepoInterface interface {
+	Initialize() *sql.DB
+	Create(*Poll) (*Poll, error_utils.MessageErr)
+	Get(int64) (*Poll, error_utils.MessageErr)
+	Delete(int64) error_utils.MessageErr
+}
+
+type pollRepo struct {
+	db *sql.DB
+}
+
+func InitPollRepository(db *sql.DB) PollRepoInterface {
+	return &pollRepo{
+		db: db,
+	}
+}
+
+func (pr *pollRepo) Initialize() *sql.DB {
+	var err error
+	pr.db, err = openPool()
+
+	checkError(err)
+
+	fmt.Println("Connected!")
+
+	return pr.db
+}
+
+func (pr *pollRepo) Create(poll *Poll) (*Poll, error_utils.MessageErr) {
+	var id int64
+	stmt, err := pr.db.Prepare(queryInsertPoll)
+
+	if err != nil {
+		message := fmt.Sprintf("Error when trying to prepare all entries: %s", err.Error())
+		return nil, error_utils.InternalServerError(message)
+	}
+	defer stmt.Close()
+
+	insertResult, createErr := stmt.Query(poll.TweetId, pq.Array(poll.Options), poll.DurationMinutes, poll.CreatedAt)
+	if createErr != nil {
+		return nil, error_formats.ParseError(createErr)
+	}
+
+	insertResult.Next()
+	if inErr := insertResult.Scan(&id); inErr != nil {
+		message := fmt.Sprintf("error when trying to save data: %s", inErr.Error())
+		return nil, error_utils.InternalServerError(message)
+	}
+
+	poll.Id = id
+	return poll, nil
+}
+
+// Get returns the poll of a tweet, tweets without a poll are not found
+func (pr *pollRepo) Get(tweetId int64) (*Poll, error_utils.MessageErr) {
+	stmt, err := pr.db.Prepare(queryGetPoll)
+
+	if err != nil {
+		message := fmt.Sprintf("Error retrieving record: %s", err)
+		return nil, error_utils.InternalServerError(message)
+	}
+
+	defer stmt.Close()
+
+	var poll Poll
+	result := stmt.QueryRow(tweetId)
+
+	if getError := result.Scan(&poll.Id, &poll.TweetId, pq.Array(&poll.Options), &poll.DurationMinutes, &poll.CreatedAt); getError != nil {
+		return nil, error_formats.ParseError(getError)
+	}
+
+	return &poll, nil
+}
+
+// Delete removes the poll of a tweet
+func (pr *pollRepo) Delete(tweetId int64) error_utils.MessageErr {
+	stmt, err := pr.db.Prepare(queryDeletePoll)
+	if err != nil {
+		return error_utils.InternalServerError(fmt.Sprintf("error when trying to delete record: %s", err.Error()))
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(tweetId); err != nil {
+		return error_utils.InternalServerError(fmt.Sprintf("error when trying to delete record %s", err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/twittertext"
+)
+
+const (
+	MinPollOptions = 2
+	MaxPollOptions = 4
+	// MaxPollOptionLength is the longest a single option can be, in characters
+	MaxPollOptionLength = 25
+	// MinPollDuration and MaxPollDuration bound how long a poll stays open, in minutes
+	MinPollDuration = 5
+	MaxPollDuration = 7 * 24 * 60
+)
+
+// Poll is a set of options posted with a tweet that followers vote on until the poll closes
+type Poll struct {
+	Id      int64 `json:"id,omitempty" example:"1"`
+	TweetId int64 `json:"tweetId,omitempty" example:"1"`
+	// Options are the choices voted on, updating a tweet with an empty list removes its poll
+	Options []string `json:"options" example:"Tabs,Spaces"`
+	// DurationMinutes is how long the poll stays open once it is posted
+	DurationMinutes int       `json:"durationMinutes" example:"1440"`
+	CreatedAt       time.Time `json:"createdAt" example:"2022-09-09T10:29:07.559636Z"`
+}
+
+// Removes reports whether the poll was sent without options to remove the poll of the tweet it is updated on
+func (p *Poll) Removes() bool {
+	return len(p.Options) == 0
+}
+
+// Validate trims the options and checks them against the limits Twitter applies to polls
+func (p *Poll) Validate() error_utils.MessageErr {
+	if len(p.Options) < MinPollOptions || len(p.Options) > MaxPollOptions {
+		return error_utils.UnprocessableEntityError(fmt.Sprintf("A poll needs between %d and %d options", MinPollOptions, MaxPollOptions))
+	}
+
+	seen := make(map[string]bool, len(p.Options))
+
+	for i, option := range p.Options {
+		option = twittertext.Normalize(strings.TrimSpace(option))
+
+		if option == "" {
+			return error_utils.UnprocessableEntityError(fmt.Sprintf("Poll option %d cannot be empty", i+1))
+		}
+
+		if length := utf8.RuneCountInString(option); length > MaxPollOptionLength {
+			return error_utils.UnprocessableEntityError(fmt.Sprintf("Poll option %d is %d characters long, the limit is %d", i+1, length, MaxPollOptionLength))
+		}
+
+		if seen[option] {
+			return error_utils.UnprocessableEntityError(fmt.Sprintf("Poll option %d is listed more than once", i+1))
+		}
+
+		seen[option] = true
+		p.Options[i] = option
+	}
+
+	if p.DurationMinutes < MinPollDuration || p.DurationMinutes > MaxPollDuration {
+		return error_utils.UnprocessableEntityError(fmt.Sprintf("Poll duration must be between %d and %d minutes", MinPollDuration, MaxPollDuration))
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package domain
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var pollColumns = []string{"Id", "TweetId", "Options", "DurationMinutes", "CreatedAt"}
+
+func TestPoll_Validate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		poll := &Poll{Options: []string{" Tabs ", "Spaces"}, DurationMinutes: 1440}
+
+		assert.Nil(t, poll.Validate())
+		assert.Equal(t, []string{"Tabs", "Spaces"}, poll.Options)
+	})
+
+	t.Run("Too few options", func(t *testing.T) {
+		poll := &Poll{Options: []string{"Tabs"}, DurationMinutes: 1440}
+
+		err := poll.Validate()
+
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.Equal(t, "A poll needs between 2 and 4 options", err.Message())
+	})
+
+	t.Run("Too many options", func(t *testing.T) {
+		poll := &Poll{Options: []string{"a", "b", "c", "d", "e"}, DurationMinutes: 1440}
+
+		assert.Equal(t, "A poll needs between 2 and 4 options", poll.Validate().Message())
+	})
+
+	t.Run("Empty option", func(t *testing.T) {
+		poll := &Poll{Options: []string{"Tabs", " "}, DurationMinutes: 1440}
+
+		assert.Equal(t, "Poll option 2 cannot be empty", poll.Validate().Message())
+	})
+
+	t.Run("Option too long", func(t *testing.T) {
+		poll := &Poll{Options: []string{"Tabs", "Spaces, as many as it takes"}, DurationMinutes: 1440}
+
+		assert.Equal(t, "Poll option 2 is 27 characters long, the limit is 25", poll.Validate().Message())
+	})
+
+	t.Run("Duplicate option", func(t *testing.T) {
+		poll := &Poll{Options: []string{"Tabs", "Tabs "}, DurationMinutes: 1440}
+
+		assert.Equal(t, "Poll option 2 is listed more than once", poll.Validate().Message())
+	})
+
+	t.Run("Duration", func(t *testing.T) {
+		for _, minutes := range []int{0, 4, MaxPollDuration + 1} {
+			poll := &Poll{Options: []string{"Tabs", "Spaces"}, DurationMinutes: minutes}
+
+			assert.Equal(t, "Poll duration must be between 5 and 10080 minutes", poll.Validate().Message())
+		}
+	})
+
+	t.Run("Validated with the tweet", func(t *testing.T) {
+		tweet := &Tweet{Message: "Tabs or spaces?", Poll: &Poll{Options: []string{"Tabs"}, DurationMinutes: 60}}
+
+		assert.Equal(t, "A poll needs between 2 and 4 options", tweet.Validate().Message())
+	})
+
+	t.Run("Not on a quote tweet", func(t *testing.T) {
+		tweet := &Tweet{Message: "Tabs or spaces?", Kind: Quote, Target: "1436255364069150720", Poll: &Poll{Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}}
+
+		assert.Equal(t, "Retweets and quote tweets cannot have a poll", tweet.Validate().Message())
+	})
+}
+
+func TestPollRepo_Create(t *testing.T) {
+	var createdAt = time.Now().Local()
+	const recordId int64 = 1
+	const tweetId int64 = 2
+
+	request := func() *Poll {
+		return &Poll{TweetId: tweetId, Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60, CreatedAt: createdAt}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitPollRepository(db)
+
+		sqlReturn := sqlmock.NewRows([]string{"Id"}).AddRow(recordId)
+		mock.ExpectPrepare("INSERT INTO polls").ExpectQuery().WithArgs(tweetId, pq.Array([]string{"Tabs", "Spaces"}), 60, createdAt).WillReturnRows(sqlReturn)
+
+		got, crErr := s.Create(request())
+
+		assert.Nil(t, crErr)
+		assert.Equal(t, recordId, got.Id)
+	})
+
+	t.Run("Insert failed", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitPollRepository(db)
+
+		mock.ExpectPrepare("INSERT INTO polls").ExpectQuery().WillReturnError(errors.New("duplicate key value violates unique constraint"))
+
+		got, crErr := s.Create(request())
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusInternalServerError, crErr.Status())
+	})
+}
+
+func TestPollRepo_Get(t *testing.T) {
+	var createdAt = time.Now().Local()
+	const tweetId int64 = 2
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitPollRepository(db)
+
+		rows := sqlmock.NewRows(pollColumns).AddRow(1, tweetId, pq.Array([]string{"Tabs", "Spaces"}), 60, createdAt)
+		mock.ExpectPrepare("SELECT (.+) FROM polls").ExpectQuery().WithArgs(tweetId).WillReturnRows(rows)
+
+		got, getErr := s.Get(tweetId)
+
+		assert.Nil(t, getErr)
+		assert.Equal(t, &Poll{Id: 1, TweetId: tweetId, Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60, CreatedAt: createdAt}, got)
+	})
+
+	t.Run("No poll", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitPollRepository(db)
+
+		mock.ExpectPrepare("SELECT (.+) FROM polls").ExpectQuery().WithArgs(tweetId).WillReturnRows(sqlmock.NewRows(pollColumns))
+
+		got, getErr := s.Get(tweetId)
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusNotFound, getErr.Status())
+	})
+}
+
+func TestPollRepo_Delete(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitPollRepository(db)
+
+		mock.ExpectPrepare("DELETE FROM polls").ExpectExec().WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.Nil(t, s.Delete(2))
+	})
+}
@@ -3,7 +3,8 @@ package domain
 import (
 	"database/sql"
 	"fmt"
-	"os"
+	"sort"
+	"time"
 
 	"github.com/RemeJuan/lattr/utils/error_formats"
 	"github.com/RemeJuan/lattr/utils/error_utils"
@@ -14,14 +15,26 @@ var (
 	TweetRepo TweetRepoInterface = &tweetRepo{}
 )
 
+const tweetColumns = "Id, UserId, Message, PostTime, Status, CreatedAt, Modified, ThreadId, ThreadPosition, RemoteId, RemoteUrl, PostedAt, Attempts, LastError, NextAttemptAt, AccountId, Visibility, ContentWarning, Kind, Target, DeleteAfter, DeleteAt, ClaimedBy, LeaseExpiresAt"
+
 var (
-	queryGetTweet              = "SELECT Id, UserId, Message, PostTime, Status, CreatedAt, Modified FROM tweets WHERE id=$1;"
-	queryInsertTweet           = "INSERT INTO tweets(UserId, Message, PostTime, Status, CreatedAt, Modified) VALUES($1, $2, $3, $4, $5, $6) RETURNING ID;"
-	queryUpdateTweet           = "UPDATE tweets SET Message=$1, PostTime=$2, Status=$3, Modified=$4 WHERE id=$5;"
-	queryGetAllTweets          = "SELECT * FROM tweets WHERE UserId=$1;"
+	queryGetTweet              = "SELECT " + tweetColumns + " FROM tweets WHERE id=$1;"
+	queryInsertTweet           = "INSERT INTO tweets(UserId, Message, PostTime, Status, CreatedAt, Modified, ThreadId, ThreadPosition, AccountId, Visibility, ContentWarning, Kind, Target, DeleteAfter, DeleteAt) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING ID;"
+	queryUpdateTweet           = "UPDATE tweets SET Message=$1, PostTime=$2, Status=$3, Modified=$4, RemoteId=$5, RemoteUrl=$6, PostedAt=$7, Attempts=$8, LastError=$9, NextAttemptAt=$10, Visibility=$11, ContentWarning=$12, Kind=$13, Target=$14, DeleteAfter=$15, DeleteAt=$16, AccountId=$17, ClaimedBy='', LeaseExpiresAt=NULL WHERE id=$18;"
+	queryGetAllTweets          = "SELECT " + tweetColumns + " FROM tweets WHERE UserId=$1;"
 	queryDeleteTweet           = "DELETE FROM tweets WHERE id=$1;"
-	queryGetPendingTweets      = "SELECT * FROM tweets WHERE Status != 'Posted' AND PostTime <= now() order by PostTime asc LIMIT 1"
+	queryGetPendingTweets      = "SELECT " + tweetColumns + " FROM tweets WHERE Status NOT IN ('Posted', 'Failed', 'Skipped', 'Deleted', 'Posting') AND PostTime <= now() AND (NextAttemptAt IS NULL OR NextAttemptAt <= now()) order by PostTime asc, ThreadPosition asc LIMIT $1"
+	queryGetOverdueTweets      = "SELECT " + tweetColumns + " FROM tweets WHERE Status NOT IN ('Posted', 'Failed', 'Skipped', 'Deleted', 'Posting') AND ThreadId = '' AND PostTime < $1 AND Attempts = 0 AND NextAttemptAt IS NULL AND NOT EXISTS (SELECT 1 FROM destinations WHERE destinations.TweetId = tweets.Id AND destinations.Status = 'Posted') order by PostTime asc"
 	queryGetLastScheduledTweet = "SELECT PostTime FROM tweets ORDER by PostTime desc LIMIT 1"
+	queryRequeueFailedTweets   = "UPDATE tweets SET Status='Pending', Attempts=0, LastError='', NextAttemptAt=NULL, Modified=$1 WHERE Status='Failed';"
+	queryGetDueDeletions       = "SELECT " + tweetColumns + " FROM tweets WHERE Status IN ('Posted', 'Failed') AND RemoteId <> '' AND DeleteAt <= $1 ORDER BY DeleteAt asc;"
+	queryGetThread             = "SELECT " + tweetColumns + " FROM tweets WHERE ThreadId=$1 ORDER BY ThreadPosition asc;"
+	queryGetNextDue            = "SELECT MIN(CASE WHEN Status = 'Posting' THEN LeaseExpiresAt ELSE GREATEST(PostTime, COALESCE(NextAttemptAt, PostTime)) END) FROM tweets WHERE Status NOT IN ('Posted', 'Failed', 'Skipped', 'Deleted');"
+	queryClaimPendingTweets    = "UPDATE tweets SET Status='Posting', ClaimedBy=$2, LeaseExpiresAt=now() + $3 * interval '1 second' WHERE Id IN (SELECT Id FROM tweets WHERE (Status NOT IN ('Posted', 'Failed', 'Skipped', 'Deleted', 'Posting') OR (Status = 'Posting' AND LeaseExpiresAt <= now())) AND PostTime <= now() AND (NextAttemptAt IS NULL OR NextAttemptAt <= now()) ORDER BY PostTime asc, ThreadPosition asc LIMIT $1 FOR UPDATE SKIP LOCKED) RETURNING " + tweetColumns + ";"
+	queryClaimThread           = "UPDATE tweets SET Status='Posting', ClaimedBy=$2, LeaseExpiresAt=now() + $3 * interval '1 second' WHERE Id IN (SELECT Id FROM tweets WHERE ThreadId=$1 AND (Status NOT IN ('Posted', 'Failed', 'Skipped', 'Deleted', 'Posting') OR (Status = 'Posting' AND (ClaimedBy=$2 OR LeaseExpiresAt <= now()))) FOR UPDATE SKIP LOCKED);"
+	queryReleaseClaims         = "UPDATE tweets SET Status='Pending', ClaimedBy='', LeaseExpiresAt=NULL WHERE Status='Posting' AND ClaimedBy=$1;"
+	queryRenewClaim            = "UPDATE tweets SET LeaseExpiresAt=now() + $3 * interval '1 second' WHERE Id=$1 AND Status='Posting' AND ClaimedBy=$2;"
+	queryHasOutstanding        = "SELECT EXISTS (SELECT 1 FROM tweets WHERE COALESCE(AccountId, 0)=$1 AND Status NOT IN ('Posted', 'Failed', 'Skipped', 'Deleted'));"
 )
 
 type TweetRepoInterface interface {
@@ -31,8 +44,18 @@ type TweetRepoInterface interface {
 	GetAll(string) ([]Tweet, error_utils.MessageErr)
 	Update(*Tweet) (*Tweet, error_utils.MessageErr)
 	Delete(int64) error_utils.MessageErr
-	GetPending() ([]Tweet, error_utils.MessageErr)
+	GetPending(int) ([]Tweet, error_utils.MessageErr)
+	GetOverdue(time.Time) ([]Tweet, error_utils.MessageErr)
 	GetLast() (*Tweet, error_utils.MessageErr)
+	GetThread(string) ([]Tweet, error_utils.MessageErr)
+	RequeueFailed(time.Time) (int64, error_utils.MessageErr)
+	GetDueDeletions(time.Time) ([]Tweet, error_utils.MessageErr)
+	GetNextDue() (*time.Time, error_utils.MessageErr)
+	ClaimPending(int, string, time.Duration) ([]Tweet, error_utils.MessageErr)
+	ClaimThread(string, string, time.Duration) (int64, error_utils.MessageErr)
+	ReleaseClaims(string) (int64, error_utils.MessageErr)
+	RenewClaim(int64, string, time.Duration) (bool, error_utils.MessageErr)
+	HasOutstanding(int64) (bool, error_utils.MessageErr)
 }
 
 type tweetRepo struct {
@@ -47,7 +70,7 @@ func InitTweetRepository(db *sql.DB) TweetRepoInterface {
 
 func (tr *tweetRepo) Initialize() *sql.DB {
 	var err error
-	tr.db, err = sql.Open("postgres", os.Getenv("DATABASE_URL"))
+	tr.db, err = openPool()
 
 	checkError(err)
 
@@ -66,7 +89,7 @@ func (tr *tweetRepo) Create(tweet *Tweet) (*Tweet, error_utils.MessageErr) {
 	}
 	defer stmt.Close()
 
-	insertResult, createErr := stmt.Query(tweet.UserId, tweet.Message, tweet.PostTime, tweet.Status, tweet.CreatedAt, tweet.Modified)
+	insertResult, createErr := stmt.Query(tweet.UserId, tweet.Message, tweet.PostTime, tweet.Status, tweet.CreatedAt, tweet.Modified, tweet.ThreadId, tweet.ThreadPosition, nullableId(tweet.AccountId), tweet.Visibility, tweet.ContentWarning, tweet.Kind, tweet.Target, tweet.DeleteAfter, tweet.DeleteAt)
 	if createErr != nil {
 		return nil, error_formats.ParseError(createErr)
 	}
@@ -96,7 +119,7 @@ func (tr *tweetRepo) Get(id int64) (*Tweet, error_utils.MessageErr) {
 	var tweet Tweet
 	result := stmt.QueryRow(id)
 
-	if getError := result.Scan(&tweet.Id, &tweet.UserId, &tweet.Message, &tweet.PostTime, &tweet.Status, &tweet.CreatedAt, &tweet.Modified); getError != nil {
+	if getError := scanTweet(result, &tweet); getError != nil {
 		return nil, error_formats.ParseError(getError)
 	}
 
@@ -112,7 +135,7 @@ func (tr *tweetRepo) Update(tweet *Tweet) (*Tweet, error_utils.MessageErr) {
 	}
 	defer stmt.Close()
 
-	_, updateErr := stmt.Exec(tweet.Message, tweet.PostTime, tweet.Status, tweet.Modified, tweet.Id)
+	_, updateErr := stmt.Exec(tweet.Message, tweet.PostTime, tweet.Status, tweet.Modified, tweet.RemoteId, tweet.RemoteUrl, tweet.PostedAt, tweet.Attempts, tweet.LastError, tweet.NextAttemptAt, tweet.Visibility, tweet.ContentWarning, tweet.Kind, tweet.Target, tweet.DeleteAfter, tweet.DeleteAt, nullableId(tweet.AccountId), tweet.Id)
 	if updateErr != nil {
 		return nil, error_formats.ParseError(updateErr)
 	}
@@ -137,7 +160,7 @@ func (tr *tweetRepo) GetAll(userId string) ([]Tweet, error_utils.MessageErr) {
 
 	for rows.Next() {
 		var tweet Tweet
-		if getError := rows.Scan(&tweet.Id, &tweet.UserId, &tweet.Message, &tweet.PostTime, &tweet.Status, &tweet.CreatedAt, &tweet.Modified); getError != nil {
+		if getError := scanTweet(rows, &tweet); getError != nil {
 			message := fmt.Sprintf("Error when trying to get message: %s", getError.Error())
 			return nil, error_utils.InternalServerError(message)
 		}
@@ -162,15 +185,92 @@ func (tr *tweetRepo) Delete(id int64) error_utils.MessageErr {
 	return nil
 }
 
-func (tr *tweetRepo) GetPending() ([]Tweet, error_utils.MessageErr) {
-	stmt, err := tr.db.Prepare(queryGetPendingTweets)
+func (tr *tweetRepo) GetPending(limit int) ([]Tweet, error_utils.MessageErr) {
+	return tr.queryTweets(queryGetPendingTweets, "pending", limit)
+}
 
+// ClaimPending moves up to limit due tweets to Posting, held by owner for the lease. Rows another
+// replica is claiming at the same time are skipped, and claims whose lease expired are taken over
+func (tr *tweetRepo) ClaimPending(limit int, owner string, lease time.Duration) ([]Tweet, error_utils.MessageErr) {
+	tweets, err := tr.queryTweets(queryClaimPendingTweets, "claimed", limit, owner, int64(lease/time.Second))
 	if err != nil {
-		return nil, error_utils.InternalServerError(fmt.Sprintf("Error when trying to prepare pending entries: %s", err.Error()))
+		return nil, err
+	}
+
+	// the claimed rows are returned in no particular order
+	sort.SliceStable(tweets, func(i, j int) bool {
+		if !tweets[i].PostTime.Equal(tweets[j].PostTime) {
+			return tweets[i].PostTime.Before(tweets[j].PostTime)
+		}
+		return tweets[i].ThreadPosition < tweets[j].ThreadPosition
+	})
+
+	return tweets, nil
+}
+
+// ClaimThread claims every outstanding part of the thread for owner, renewing the parts it already
+// holds, and returns how many parts it holds. Parts held by another replica are left alone
+func (tr *tweetRepo) ClaimThread(threadId string, owner string, lease time.Duration) (int64, error_utils.MessageErr) {
+	return tr.exec(queryClaimThread, threadId, owner, int64(lease/time.Second))
+}
+
+// ReleaseClaims moves the tweets owner still holds back to Pending, for tweets a run did not post
+func (tr *tweetRepo) ReleaseClaims(owner string) (int64, error_utils.MessageErr) {
+	return tr.exec(queryReleaseClaims, owner)
+}
+
+// RenewClaim extends owner's claim on the tweet by the lease and reports whether owner still holds it.
+// A claim whose lease expired may have been taken over by another replica, it is not renewed then
+func (tr *tweetRepo) RenewClaim(id int64, owner string, lease time.Duration) (bool, error_utils.MessageErr) {
+	count, err := tr.exec(queryRenewClaim, id, owner, int64(lease/time.Second))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// exec runs an update and returns the number of rows it changed
+func (tr *tweetRepo) exec(query string, args ...interface{}) (int64, error_utils.MessageErr) {
+	stmt, err := tr.db.Prepare(query)
+	if err != nil {
+		return 0, error_utils.InternalServerError(fmt.Sprintf("error when trying to prepare update: %s", err.Error()))
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query()
+	result, err := stmt.Exec(args...)
+	if err != nil {
+		return 0, error_formats.ParseError(err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, error_formats.ParseError(err)
+	}
+
+	return count, nil
+}
+
+// GetOverdue lists every outstanding tweet, outside of threads, that was due before the cutoff and
+// was never attempted. Tweets waiting on a retry or partly cross-posted are left to the retries
+func (tr *tweetRepo) GetOverdue(cutoff time.Time) ([]Tweet, error_utils.MessageErr) {
+	return tr.queryTweets(queryGetOverdueTweets, "overdue", cutoff)
+}
+
+// GetDueDeletions lists the posted tweets whose DeleteAt passed before now
+func (tr *tweetRepo) GetDueDeletions(now time.Time) ([]Tweet, error_utils.MessageErr) {
+	return tr.queryTweets(queryGetDueDeletions, "due deletion", now)
+}
+
+func (tr *tweetRepo) queryTweets(query string, name string, args ...interface{}) ([]Tweet, error_utils.MessageErr) {
+	stmt, err := tr.db.Prepare(query)
+
+	if err != nil {
+		return nil, error_utils.InternalServerError(fmt.Sprintf("Error when trying to prepare %s entries: %s", name, err.Error()))
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(args...)
 	if err != nil {
 		return nil, error_formats.ParseError(err)
 	}
@@ -180,7 +280,7 @@ func (tr *tweetRepo) GetPending() ([]Tweet, error_utils.MessageErr) {
 
 	for rows.Next() {
 		var tweet Tweet
-		if getError := rows.Scan(&tweet.Id, &tweet.UserId, &tweet.Message, &tweet.PostTime, &tweet.Status, &tweet.CreatedAt, &tweet.Modified); getError != nil {
+		if getError := scanTweet(rows, &tweet); getError != nil {
 			message := fmt.Sprintf("Error when trying to get message: %s", getError.Error())
 			return nil, error_utils.InternalServerError(message)
 		}
@@ -211,3 +311,69 @@ func (tr *tweetRepo) GetLast() (*Tweet, error_utils.MessageErr) {
 
 	return &tweet, nil
 }
+
+// GetNextDue returns the earliest time an outstanding tweet can be posted, its post time or, while
+// it waits for a retry, its next attempt
+func (tr *tweetRepo) GetNextDue() (*time.Time, error_utils.MessageErr) {
+	stmt, err := tr.db.Prepare(queryGetNextDue)
+
+	if err != nil {
+		message := fmt.Sprintf("Error retrieving record: %s", err)
+		return nil, error_utils.InternalServerError(message)
+	}
+
+	defer stmt.Close()
+
+	var due *time.Time
+
+	if getError := stmt.QueryRow().Scan(&due); getError != nil {
+		return nil, error_formats.ParseError(getError)
+	}
+
+	if due == nil {
+		return nil, error_utils.NotFoundError("no records found")
+	}
+
+	return due, nil
+}
+
+// HasOutstanding reports whether the account still has tweets to post, account 0 is the default account
+func (tr *tweetRepo) HasOutstanding(accountId int64) (bool, error_utils.MessageErr) {
+	stmt, err := tr.db.Prepare(queryHasOutstanding)
+
+	if err != nil {
+		message := fmt.Sprintf("Error retrieving record: %s", err)
+		return false, error_utils.InternalServerError(message)
+	}
+
+	defer stmt.Close()
+
+	var outstanding bool
+
+	if getError := stmt.QueryRow(accountId).Scan(&outstanding); getError != nil {
+		return false, error_formats.ParseError(getError)
+	}
+
+	return outstanding, nil
+}
+
+func (tr *tweetRepo) GetThread(threadId string) ([]Tweet, error_utils.MessageErr) {
+	return tr.queryTweets(queryGetThread, "thread", threadId)
+}
+
+// RequeueFailed moves every failed tweet back to pending with a clean retry state
+func (tr *tweetRepo) RequeueFailed(modified time.Time) (int64, error_utils.MessageErr) {
+	return tr.exec(queryRequeueFailedTweets, modified)
+}
+
+// scanTweet reads a row selected with tweetColumns into the tweet
+func scanTweet(row scanner, tweet *Tweet) error {
+	var accountId sql.NullInt64
+
+	if err := row.Scan(&tweet.Id, &tweet.UserId, &tweet.Message, &tweet.PostTime, &tweet.Status, &tweet.CreatedAt, &tweet.Modified, &tweet.ThreadId, &tweet.ThreadPosition, &tweet.RemoteId, &tweet.RemoteUrl, &tweet.PostedAt, &tweet.Attempts, &tweet.LastError, &tweet.NextAttemptAt, &accountId, &tweet.Visibility, &tweet.ContentWarning, &tweet.Kind, &tweet.Target, &tweet.DeleteAfter, &tweet.DeleteAt, &tweet.ClaimedBy, &tweet.LeaseExpiresAt); err != nil {
+		return err
+	}
+
+	tweet.AccountId = accountId.Int64
+	return nil
+}
@@ -1,10 +1,12 @@
 package domain
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/twittertext"
 )
 
 type tweetStatus string
@@ -13,24 +15,259 @@ const (
 	Pending   = tweetStatus("Pending")
 	Posted    = tweetStatus("Posted")
 	Scheduled = tweetStatus("Scheduled")
+	Failed    = tweetStatus("Failed")
+	Skipped   = tweetStatus("Skipped")
+	// Deleted tweets were posted and then removed again at their DeleteAt time
+	Deleted = tweetStatus("Deleted")
+	// Posting tweets are claimed by a scheduler replica until ClaimedBy's lease expires at LeaseExpiresAt
+	Posting = tweetStatus("Posting")
 )
 
+type tweetKind string
+
+const (
+	// Original tweets post their own message
+	Original = tweetKind("original")
+	// Retweet shares the target status as is
+	Retweet = tweetKind("retweet")
+	// Quote posts the message with the target status attached
+	Quote = tweetKind("quote")
+)
+
+const MaxThreadLength = 25
+
+// visibilities are the Mastodon status visibilities, an empty visibility uses the account default
+var visibilities = map[string]bool{"": true, "public": true, "unlisted": true, "private": true, "direct": true}
+
 type Tweet struct {
-	Id        int64       `json:"id" example:"1"`
-	Message   string      `json:"message" example:"TIL: Life is awesome"`
-	UserId    string      `json:"userId" example:"IFTTT"`
-	Status    tweetStatus `json:"status" example:"Pending"`
-	PostTime  time.Time   `json:"postTime" example:"2022-09-09T10:29:07.559636Z"`
-	CreatedAt time.Time   `json:"createdAt" example:"2022-09-09T10:29:07.559636Z"`
-	Modified  time.Time   `json:"modified" example:"2022-09-09T10:29:07.559636Z"`
+	Id             int64       `json:"id" example:"1"`
+	Message        string      `json:"message" example:"TIL: Life is awesome"`
+	UserId         string      `json:"userId" example:"IFTTT"`
+	Status         tweetStatus `json:"status" example:"Pending"`
+	PostTime       time.Time   `json:"postTime" example:"2022-09-09T10:29:07.559636Z"`
+	CreatedAt      time.Time   `json:"createdAt" example:"2022-09-09T10:29:07.559636Z"`
+	Modified       time.Time   `json:"modified" example:"2022-09-09T10:29:07.559636Z"`
+	ThreadId       string      `json:"threadId,omitempty" example:"1d6dcc23-51c4-4540-b659-b2834efad5bc"`
+	ThreadPosition int         `json:"threadPosition" example:"0"`
+	RemoteId       string      `json:"remoteId,omitempty" example:"1436255364069150720"`
+	RemoteUrl      string      `json:"remoteUrl,omitempty" example:"https://twitter.com/lattr/status/1436255364069150720"`
+	PostedAt       *time.Time  `json:"postedAt,omitempty" example:"2022-09-09T10:30:01.559636Z"`
+	Attempts       int         `json:"attempts" example:"0"`
+	LastError      string      `json:"lastError,omitempty" example:"twitter: 130 Over capacity"`
+	NextAttemptAt  *time.Time  `json:"nextAttemptAt,omitempty" example:"2022-09-09T10:35:01.559636Z"`
+	// AccountId is the account the tweet is posted as, tweets without one use the default credentials
+	AccountId int64 `json:"accountId,omitempty" example:"1"`
+	// Visibility and ContentWarning are only used by destinations that support them, such as Mastodon
+	Visibility     string `json:"visibility,omitempty" example:"unlisted"`
+	ContentWarning string `json:"contentWarning,omitempty" example:"Spoilers"`
+	// Destinations cross-post the tweet to several accounts, they are stored separately from the tweet
+	Destinations []Destination `json:"destinations,omitempty"`
+	// Kind defaults to original, retweets and quotes refer to an existing status with Target
+	Kind tweetKind `json:"kind" example:"quote"`
+	// Target is the status that is retweeted or quoted, given as its ID or link and stored as the ID
+	Target string `json:"target,omitempty" example:"1436255364069150720"`
+	// DeleteAfter removes the tweet again this long after it was posted, DeleteAt removes it at a fixed time.
+	// DeleteAt is filled in from DeleteAfter once the tweet is posted
+	DeleteAfter string     `json:"deleteAfter,omitempty" example:"24h"`
+	DeleteAt    *time.Time `json:"deleteAt,omitempty" example:"2022-09-10T10:29:07.559636Z"`
+	// Poll is posted with the tweet, it is stored in its own table and loaded with the tweet
+	Poll *Poll `json:"poll,omitempty"`
+	// ClaimedBy is the scheduler replica posting the tweet, its claim lapses at LeaseExpiresAt so
+	// another replica takes the tweet over when it stops before finishing
+	ClaimedBy      string     `json:"claimedBy,omitempty" example:"web.1-3f2a9c1e"`
+	LeaseExpiresAt *time.Time `json:"leaseExpiresAt,omitempty" example:"2022-09-09T10:45:01.559636Z"`
+}
+
+// Thread is a group of messages that are posted as a chain of replies
+type Thread struct {
+	UserId         string        `json:"userId" example:"IFTTT"`
+	AccountId      int64         `json:"accountId,omitempty" example:"1"`
+	PostTime       time.Time     `json:"postTime" example:"2022-09-09T10:29:07.559636Z"`
+	Messages       []string      `json:"messages" example:"First part of the thread,Second part of the thread"`
+	Visibility     string        `json:"visibility,omitempty" example:"unlisted"`
+	ContentWarning string        `json:"contentWarning,omitempty" example:"Spoilers"`
+	Destinations   []Destination `json:"destinations,omitempty"`
+	DeleteAfter    string        `json:"deleteAfter,omitempty" example:"24h"`
+	DeleteAt       *time.Time    `json:"deleteAt,omitempty" example:"2022-09-10T10:29:07.559636Z"`
 }
 
 func (t *Tweet) Validate() error_utils.MessageErr {
-	t.Message = strings.TrimSpace(t.Message)
+	t.Message = twittertext.Normalize(strings.TrimSpace(t.Message))
+
+	if err := t.validateKind(); err != nil {
+		return err
+	}
 
-	if t.Message == "" {
+	if t.Message == "" && t.Kind != Retweet {
 		return error_utils.UnprocessableEntityError("Body cannot be empty")
 	}
 
+	if length := twittertext.WeightedLength(t.Message); length > twittertext.MaxWeightedLength {
+		return error_utils.UnprocessableEntityError(fmt.Sprintf("Message is %d characters long, the limit is %d", length, twittertext.MaxWeightedLength))
+	}
+
+	if err := validateDestinations(t.AccountId, t.Destinations); err != nil {
+		return err
+	}
+
+	if err := validateDeletion(t.PostTime, t.DeleteAfter, t.DeleteAt); err != nil {
+		return err
+	}
+
+	if t.Poll != nil {
+		if t.RefersToStatus() {
+			return error_utils.UnprocessableEntityError("Retweets and quote tweets cannot have a poll")
+		}
+
+		if err := t.Poll.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return validateVisibility(t.Visibility)
+}
+
+// validateDeletion checks that a self-deleting tweet sets one of deleteAfter or deleteAt
+func validateDeletion(postTime time.Time, deleteAfter string, deleteAt *time.Time) error_utils.MessageErr {
+	if deleteAfter != "" && deleteAt != nil {
+		return error_utils.UnprocessableEntityError("Set either deleteAfter or deleteAt, not both")
+	}
+
+	if deleteAfter != "" {
+		if after, err := time.ParseDuration(deleteAfter); err != nil || after <= 0 {
+			return error_utils.UnprocessableEntityError("deleteAfter must be a positive duration such as 90m or 24h")
+		}
+	}
+
+	if deleteAt != nil && !deleteAt.After(postTime) {
+		return error_utils.UnprocessableEntityError("deleteAt must be after postTime")
+	}
+
 	return nil
 }
+
+// ScheduleDeletion sets DeleteAt of a posted tweet from DeleteAfter, counted from when it was posted.
+// Tweets without a remote status cannot be deleted and are left as they are
+func (t *Tweet) ScheduleDeletion() {
+	if t.DeleteAfter == "" || t.DeleteAt != nil || t.PostedAt == nil || t.RemoteId == "" {
+		return
+	}
+
+	after, err := time.ParseDuration(t.DeleteAfter)
+	if err != nil {
+		return
+	}
+
+	deleteAt := t.PostedAt.Add(after)
+	t.DeleteAt = &deleteAt
+}
+
+// validateKind checks the status a retweet or quote refers to and stores it by its ID
+func (t *Tweet) validateKind() error_utils.MessageErr {
+	switch t.Kind {
+	case "", Original:
+		t.Kind = Original
+
+		if t.Target != "" {
+			return error_utils.UnprocessableEntityError("Only retweets and quote tweets can have a target")
+		}
+		return nil
+	case Retweet, Quote:
+	default:
+		return error_utils.UnprocessableEntityError("Kind must be one of original, retweet or quote")
+	}
+
+	id, ok := twittertext.StatusID(strings.TrimSpace(t.Target))
+	if !ok {
+		return error_utils.UnprocessableEntityError("Target must be a status ID or a link to a status")
+	}
+	t.Target = id
+
+	if t.Kind == Retweet && t.Message != "" {
+		return error_utils.UnprocessableEntityError("A retweet cannot have a message")
+	}
+
+	if t.IsThread() {
+		return error_utils.UnprocessableEntityError("Retweets and quote tweets cannot be part of a thread")
+	}
+
+	if len(t.Destinations) > 0 {
+		return error_utils.UnprocessableEntityError("Retweets and quote tweets cannot have destinations")
+	}
+
+	return nil
+}
+
+// RefersToStatus reports whether the tweet retweets or quotes another status
+func (t *Tweet) RefersToStatus() bool {
+	return t.Kind == Retweet || t.Kind == Quote
+}
+
+func validateVisibility(visibility string) error_utils.MessageErr {
+	if !visibilities[visibility] {
+		return error_utils.UnprocessableEntityError("Visibility must be one of public, unlisted, private or direct")
+	}
+
+	return nil
+}
+
+// IsThread reports whether the tweet is part of a thread
+func (t *Tweet) IsThread() bool {
+	return t.ThreadId != ""
+}
+
+func (th *Thread) Validate() error_utils.MessageErr {
+	if len(th.Messages) < 2 {
+		return error_utils.UnprocessableEntityError("A thread needs at least 2 messages")
+	}
+
+	if len(th.Messages) > MaxThreadLength {
+		return error_utils.UnprocessableEntityError(fmt.Sprintf("A thread cannot have more than %d messages", MaxThreadLength))
+	}
+
+	for i, msg := range th.Messages {
+		th.Messages[i] = twittertext.Normalize(strings.TrimSpace(msg))
+
+		if th.Messages[i] == "" {
+			return error_utils.UnprocessableEntityError(fmt.Sprintf("Message %d cannot be empty", i+1))
+		}
+
+		if length := twittertext.WeightedLength(th.Messages[i]); length > twittertext.MaxWeightedLength {
+			return error_utils.UnprocessableEntityError(fmt.Sprintf("Message %d is %d characters long, the limit is %d", i+1, length, twittertext.MaxWeightedLength))
+		}
+	}
+
+	if err := validateDestinations(th.AccountId, th.Destinations); err != nil {
+		return err
+	}
+
+	if err := validateDeletion(th.PostTime, th.DeleteAfter, th.DeleteAt); err != nil {
+		return err
+	}
+
+	return validateVisibility(th.Visibility)
+}
+
+// Tweets expands the thread into its individual tweets, in posting order
+func (th *Thread) Tweets(threadId string, status tweetStatus) []Tweet {
+	tweets := make([]Tweet, 0, len(th.Messages))
+
+	for i, msg := range th.Messages {
+		tweets = append(tweets, Tweet{
+			Message:        msg,
+			UserId:         th.UserId,
+			AccountId:      th.AccountId,
+			Status:         status,
+			PostTime:       th.PostTime,
+			ThreadId:       threadId,
+			ThreadPosition: i,
+			Visibility:     th.Visibility,
+			ContentWarning: th.ContentWarning,
+			Destinations:   append([]Destination(nil), th.Destinations...),
+			DeleteAfter:    th.DeleteAfter,
+			DeleteAt:       th.DeleteAt,
+		})
+	}
+
+	return tweets
+}
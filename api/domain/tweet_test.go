@@ -1,7 +1,10 @@
 package domain
 
 import (
+	"database/sql/driver"
 	"errors"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +18,31 @@ func TestTweetRepo_Initialize(t *testing.T) {
 
 const layout = "2021-07-12 10:55:50 +0000"
 
+var tweetColumnNames = []string{"Id", "UserId", "Message", "PostTime", "Status", "CreatedAt", "Modified", "ThreadId", "ThreadPosition", "RemoteId", "RemoteUrl", "PostedAt", "Attempts", "LastError", "NextAttemptAt", "AccountId", "Visibility", "ContentWarning", "Kind", "Target", "DeleteAfter", "DeleteAt", "ClaimedBy", "LeaseExpiresAt"}
+
+func tweetRow(tweet Tweet) []driver.Value {
+	return []driver.Value{tweet.Id, tweet.UserId, tweet.Message, tweet.PostTime, tweet.Status, tweet.CreatedAt, tweet.Modified, tweet.ThreadId, tweet.ThreadPosition, tweet.RemoteId, tweet.RemoteUrl, tweet.PostedAt, tweet.Attempts, tweet.LastError, tweet.NextAttemptAt, accountIdValue(tweet.AccountId), tweet.Visibility, tweet.ContentWarning, tweet.Kind, tweet.Target, tweet.DeleteAfter, tweet.DeleteAt, tweet.ClaimedBy, tweet.LeaseExpiresAt}
+}
+
+// accountIdValue is the column value of an optional account reference
+func accountIdValue(id int64) driver.Value {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// tweetUpdateArgs lists the tweet values in the order queryUpdateTweet expects them
+func tweetUpdateArgs(tweet *Tweet) []driver.Value {
+	return []driver.Value{tweet.Message, tweet.PostTime, tweet.Status, tweet.Modified, tweet.RemoteId, tweet.RemoteUrl, tweet.PostedAt, tweet.Attempts, tweet.LastError, tweet.NextAttemptAt, tweet.Visibility, tweet.ContentWarning, tweet.Kind, tweet.Target, tweet.DeleteAfter, tweet.DeleteAt, accountIdValue(tweet.AccountId), tweet.Id}
+}
+
+// invalidCreatedAt replaces the CreatedAt value with one that cannot be scanned
+func invalidCreatedAt(row []driver.Value) []driver.Value {
+	row[5] = "createdAt"
+	return row
+}
+
 func TestTweetRepo_Create(t *testing.T) {
 	var createdAt = time.Now().Local()
 	var modified = time.Now().Local()
@@ -57,7 +85,7 @@ func TestTweetRepo_Create(t *testing.T) {
 
 		sqlQuery := "INSERT INTO tweets"
 		sqlReturn := sqlmock.NewRows([]string{"Id"}).AddRow(recordId)
-		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(userId, message, postTime, Pending, createdAt, modified).WillReturnRows(sqlReturn)
+		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(userId, message, postTime, Pending, createdAt, modified, "", 0, nil, "", "", "", "", "", nil).WillReturnRows(sqlReturn)
 
 		request.Message = message
 
@@ -83,7 +111,7 @@ func TestTweetRepo_Create(t *testing.T) {
 
 		sqlQuery := "INSERT INTO tweets"
 		sqlReturn := errors.New("empty title")
-		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(userId, message, postTime, Pending, createdAt, modified).WillReturnError(sqlReturn)
+		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(userId, message, postTime, Pending, createdAt, modified, "", 0, nil, "", "", "", "", "", nil).WillReturnError(sqlReturn)
 
 		request.Message = message
 
@@ -135,7 +163,7 @@ func TestTweetRepo_Get(t *testing.T) {
 
 		s := InitTweetRepository(db)
 
-		rows := sqlmock.NewRows([]string{"Id", "UserId", "Message", "PostTime", "Status", "CreatedAt", "Modified"}).AddRow(recordId, userId, message, postTime, Pending, createdAt, modified)
+		rows := sqlmock.NewRows(tweetColumnNames).AddRow(tweetRow(Tweet{Id: recordId, UserId: userId, Message: message, PostTime: postTime, Status: Pending, CreatedAt: createdAt, Modified: modified, AccountId: 3})...)
 
 		expected := &Tweet{
 			Id:        1,
@@ -145,6 +173,7 @@ func TestTweetRepo_Get(t *testing.T) {
 			Status:    Pending,
 			CreatedAt: createdAt,
 			Modified:  modified,
+			AccountId: 3,
 		}
 
 		const sqlQuery = "SELECT (.+) FROM tweets"
@@ -168,7 +197,7 @@ func TestTweetRepo_Get(t *testing.T) {
 
 		const expected = "no record matching given id"
 
-		rows := sqlmock.NewRows([]string{"Id", "UserId", "Message", "PostTime", "Status", "CreatedAt", "Modified"})
+		rows := sqlmock.NewRows(tweetColumnNames)
 
 		const sqlQuery = "SELECT (.+) FROM tweets"
 		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(recordId).WillReturnRows(rows)
@@ -238,7 +267,7 @@ func TestTweetRepo_Update(t *testing.T) {
 
 		const sqlQuery = "UPDATE tweets"
 		sqlReturn := sqlmock.NewResult(0, 1)
-		mock.ExpectPrepare(sqlQuery).ExpectExec().WithArgs(message, postTime, status, modified, recordId).WillReturnResult(sqlReturn)
+		mock.ExpectPrepare(sqlQuery).ExpectExec().WithArgs(tweetUpdateArgs(request)...).WillReturnResult(sqlReturn)
 
 		got, upErr := s.Update(request)
 
@@ -282,7 +311,7 @@ func TestTweetRepo_Update(t *testing.T) {
 
 		const sqlQuery = "UPDATE tweets"
 		var sqlReturn = errors.New("invalid update id")
-		mock.ExpectPrepare(sqlQuery).ExpectExec().WithArgs(message, postTime, status, modified, recordId).WillReturnError(sqlReturn)
+		mock.ExpectPrepare(sqlQuery).ExpectExec().WithArgs(tweetUpdateArgs(request)...).WillReturnError(sqlReturn)
 
 		got, upErr := s.Update(request)
 
@@ -304,7 +333,7 @@ func TestTweetRepo_Update(t *testing.T) {
 
 		const sqlQuery = "UPDATE tweets"
 		sqlReturn := errors.New("please enter a valid title")
-		mock.ExpectPrepare(sqlQuery).ExpectExec().WithArgs(message, postTime, status, modified, recordId).WillReturnError(sqlReturn)
+		mock.ExpectPrepare(sqlQuery).ExpectExec().WithArgs(tweetUpdateArgs(request)...).WillReturnError(sqlReturn)
 
 		got, upErr := s.Update(request)
 
@@ -326,7 +355,7 @@ func TestTweetRepo_Update(t *testing.T) {
 
 		const sqlQuery = "UPDATE tweets"
 		sqlReturn := errors.New("update failed")
-		mock.ExpectPrepare(sqlQuery).ExpectExec().WithArgs(message, postTime, status, modified, recordId).WillReturnError(sqlReturn)
+		mock.ExpectPrepare(sqlQuery).ExpectExec().WithArgs(tweetUpdateArgs(request)...).WillReturnError(sqlReturn)
 
 		got, upErr := s.Update(request)
 
@@ -378,7 +407,7 @@ func TestTweetRepo_GetAll(t *testing.T) {
 			},
 		}
 
-		rows := sqlmock.NewRows([]string{"Id", "UserId", "Message", "PostTime", "Status", "CreatedAt", "Modified"}).AddRow(recordId, userId, message, postTime, status, createdAt, modified).AddRow(002, userId, message, postTime, status, createdAt, modified)
+		rows := sqlmock.NewRows(tweetColumnNames).AddRow(tweetRow(Tweet{Id: recordId, UserId: userId, Message: message, PostTime: postTime, Status: status, CreatedAt: createdAt, Modified: modified})...).AddRow(tweetRow(Tweet{Id: 002, UserId: userId, Message: message, PostTime: postTime, Status: status, CreatedAt: createdAt, Modified: modified})...)
 
 		const sqlQuery = "SELECT (.+) FROM tweets"
 		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(userId).WillReturnRows(rows)
@@ -445,7 +474,7 @@ func TestTweetRepo_GetAll(t *testing.T) {
 
 		expected := "Error when trying to get message: sql: Scan error on column index 5, name \"CreatedAt\": unsupported Scan, storing driver.Value type string into type *time.Time"
 
-		rows := sqlmock.NewRows([]string{"Id", "UserId", "Message", "PostTime", "Status", "CreatedAt", "Modified"}).AddRow(recordId, userId, message, postTime, status, createdAt, modified).AddRow(002, userId, message, postTime, status, "createdAt", modified)
+		rows := sqlmock.NewRows(tweetColumnNames).AddRow(tweetRow(Tweet{Id: recordId, UserId: userId, Message: message, PostTime: postTime, Status: status, CreatedAt: createdAt, Modified: modified})...).AddRow(invalidCreatedAt(tweetRow(Tweet{Id: 002, UserId: userId, Message: message, PostTime: postTime, Status: status, CreatedAt: createdAt, Modified: modified}))...)
 
 		const sqlQuery = "SELECT (.+) FROM tweets"
 		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(userId).WillReturnRows(rows)
@@ -468,7 +497,7 @@ func TestTweetRepo_GetAll(t *testing.T) {
 
 		const expected = "no records found"
 
-		rows := sqlmock.NewRows([]string{"Id", "UserId", "Message", "PostTime", "Status", "CreatedAt", "Modified"})
+		rows := sqlmock.NewRows(tweetColumnNames)
 
 		const sqlQuery = "SELECT (.+) FROM tweets"
 		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(userId).WillReturnRows(rows)
@@ -551,6 +580,7 @@ func TestTweetRepo_GetPending(t *testing.T) {
 	const recordId int64 = 001
 	const status = Posted
 	const userId = "001"
+	const batchSize = 10
 	var message = "message"
 	postTime, _ := time.Parse(layout, "2021-07-12 10:55:50 +0000")
 
@@ -585,12 +615,12 @@ func TestTweetRepo_GetPending(t *testing.T) {
 			},
 		}
 
-		rows := sqlmock.NewRows([]string{"Id", "UserId", "Message", "PostTime", "Status", "CreatedAt", "Modified"}).AddRow(recordId, userId, message, postTime, status, createdAt, modified).AddRow(002, userId, message, postTime, status, createdAt, modified)
+		rows := sqlmock.NewRows(tweetColumnNames).AddRow(tweetRow(Tweet{Id: recordId, UserId: userId, Message: message, PostTime: postTime, Status: status, CreatedAt: createdAt, Modified: modified})...).AddRow(tweetRow(Tweet{Id: 002, UserId: userId, Message: message, PostTime: postTime, Status: status, CreatedAt: createdAt, Modified: modified})...)
 
 		const sqlQuery = "SELECT (.+) FROM tweets"
-		mock.ExpectPrepare(sqlQuery).ExpectQuery().WillReturnRows(rows)
+		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(batchSize).WillReturnRows(rows)
 
-		got, gaErr := s.GetPending()
+		got, gaErr := s.GetPending(batchSize)
 
 		assert.Nil(t, gaErr)
 		assert.Equal(t, expected, got)
@@ -612,7 +642,7 @@ func TestTweetRepo_GetPending(t *testing.T) {
 		sqlResult := errors.New("invalid syntax")
 		mock.ExpectPrepare(sqlQuery).WillReturnError(sqlResult)
 
-		got, gaErr := s.GetPending()
+		got, gaErr := s.GetPending(batchSize)
 
 		assert.Nil(t, got)
 		assert.Equal(t, expected, gaErr.Message())
@@ -632,9 +662,9 @@ func TestTweetRepo_GetPending(t *testing.T) {
 
 		const sqlQuery = "SELECT (.+) FROM tweets"
 		sqlResult := errors.New("invalid query")
-		mock.ExpectPrepare(sqlQuery).ExpectQuery().WillReturnError(sqlResult)
+		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(batchSize).WillReturnError(sqlResult)
 
-		got, gaErr := s.GetPending()
+		got, gaErr := s.GetPending(batchSize)
 
 		assert.Nil(t, got)
 		assert.Equal(t, expected, gaErr.Message())
@@ -652,12 +682,12 @@ func TestTweetRepo_GetPending(t *testing.T) {
 
 		expected := "Error when trying to get message: sql: Scan error on column index 5, name \"CreatedAt\": unsupported Scan, storing driver.Value type string into type *time.Time"
 
-		rows := sqlmock.NewRows([]string{"Id", "UserId", "Message", "PostTime", "Status", "CreatedAt", "Modified"}).AddRow(recordId, userId, message, postTime, status, createdAt, modified).AddRow(002, userId, message, postTime, status, "createdAt", modified)
+		rows := sqlmock.NewRows(tweetColumnNames).AddRow(tweetRow(Tweet{Id: recordId, UserId: userId, Message: message, PostTime: postTime, Status: status, CreatedAt: createdAt, Modified: modified})...).AddRow(invalidCreatedAt(tweetRow(Tweet{Id: 002, UserId: userId, Message: message, PostTime: postTime, Status: status, CreatedAt: createdAt, Modified: modified}))...)
 
 		const sqlQuery = "SELECT (.+) FROM tweets"
-		mock.ExpectPrepare(sqlQuery).ExpectQuery().WillReturnRows(rows)
+		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(batchSize).WillReturnRows(rows)
 
-		got, gaErr := s.GetPending()
+		got, gaErr := s.GetPending(batchSize)
 
 		assert.Nil(t, got)
 		assert.Equal(t, expected, gaErr.Message())
@@ -675,18 +705,104 @@ func TestTweetRepo_GetPending(t *testing.T) {
 
 		const expected = "no records found"
 
-		rows := sqlmock.NewRows([]string{"Id", "UserId", "Message", "PostTime", "Status", "CreatedAt", "Modified"})
+		rows := sqlmock.NewRows(tweetColumnNames)
 
 		const sqlQuery = "SELECT (.+) FROM tweets"
-		mock.ExpectPrepare(sqlQuery).ExpectQuery().WillReturnRows(rows)
+		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(batchSize).WillReturnRows(rows)
 
-		got, gaErr := s.GetPending()
+		got, gaErr := s.GetPending(batchSize)
 
 		assert.Nil(t, got)
 		assert.Equal(t, expected, gaErr.Message())
 	})
 }
 
+func TestTweetRepo_HasOutstanding(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		const sqlQuery = "SELECT EXISTS \\(SELECT 1 FROM tweets WHERE COALESCE\\(AccountId, 0\\)=\\$1 AND Status NOT IN"
+		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(0).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		got, getErr := s.HasOutstanding(0)
+
+		assert.Nil(t, getErr)
+		assert.True(t, got)
+	})
+
+	t.Run("Invalid SQL Syntax", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		mock.ExpectPrepare("SELECT EXISTS").WillReturnError(errors.New("invalid syntax"))
+
+		got, getErr := s.HasOutstanding(0)
+
+		assert.False(t, got)
+		assert.EqualValues(t, http.StatusInternalServerError, getErr.Status())
+	})
+}
+
+func TestTweetRepo_GetNextDue(t *testing.T) {
+	postTime, _ := time.Parse(layout, "2021-07-12 10:55:50 +0000")
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		rows := sqlmock.NewRows([]string{"min"}).AddRow(postTime)
+
+		const sqlQuery = "SELECT MIN\\(CASE WHEN Status = 'Posting' THEN LeaseExpiresAt ELSE (.+) END\\) FROM tweets"
+		mock.ExpectPrepare(sqlQuery).ExpectQuery().WillReturnRows(rows)
+
+		got, getErr := s.GetNextDue()
+
+		assert.Nil(t, getErr)
+		assert.Equal(t, postTime, *got)
+	})
+
+	t.Run("Nothing outstanding", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		rows := sqlmock.NewRows([]string{"min"}).AddRow(nil)
+
+		const sqlQuery = "SELECT (.+) FROM tweets"
+		mock.ExpectPrepare(sqlQuery).ExpectQuery().WillReturnRows(rows)
+
+		got, getErr := s.GetNextDue()
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusNotFound, getErr.Status())
+		assert.Equal(t, "no records found", getErr.Message())
+	})
+}
+
 func TestTweetRepo_GetLast(t *testing.T) {
 	postTime, _ := time.Parse(layout, "2021-07-12 10:55:50 +0000")
 
@@ -760,3 +876,570 @@ func TestTweetRepo_GetLast(t *testing.T) {
 		assert.Equal(t, expected, gotErr.Message())
 	})
 }
+
+func TestTweetRepo_GetThread(t *testing.T) {
+	var createdAt = time.Now().Local()
+	const threadId = "1d6dcc23-51c4-4540-b659-b2834efad5bc"
+	postTime, _ := time.Parse(layout, "2021-07-12 10:55:50 +0000")
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		expected := []Tweet{
+			{Id: 1, UserId: "001", Message: "first", PostTime: postTime, Status: Posted, CreatedAt: createdAt, Modified: createdAt, ThreadId: threadId, ThreadPosition: 0, RemoteId: "1436255364069150720"},
+			{Id: 2, UserId: "001", Message: "second", PostTime: postTime, Status: Pending, CreatedAt: createdAt, Modified: createdAt, ThreadId: threadId, ThreadPosition: 1},
+		}
+
+		rows := sqlmock.NewRows(tweetColumnNames).AddRow(tweetRow(expected[0])...).AddRow(tweetRow(expected[1])...)
+
+		const sqlQuery = "SELECT (.+) FROM tweets WHERE ThreadId"
+		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(threadId).WillReturnRows(rows)
+
+		got, gtErr := s.GetThread(threadId)
+
+		assert.Nil(t, gtErr)
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		const sqlQuery = "SELECT (.+) FROM tweets WHERE ThreadId"
+		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(threadId).WillReturnRows(sqlmock.NewRows(tweetColumnNames))
+
+		got, gtErr := s.GetThread(threadId)
+
+		assert.Nil(t, got)
+		assert.Equal(t, "no records found", gtErr.Message())
+	})
+}
+
+func TestThread_Validate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		thread := &Thread{Messages: []string{" first ", "second"}}
+
+		assert.Nil(t, thread.Validate())
+		assert.Equal(t, "first", thread.Messages[0])
+	})
+
+	t.Run("Too short", func(t *testing.T) {
+		thread := &Thread{Messages: []string{"first"}}
+
+		assert.Equal(t, "A thread needs at least 2 messages", thread.Validate().Message())
+	})
+
+	t.Run("Empty message", func(t *testing.T) {
+		thread := &Thread{Messages: []string{"first", " "}}
+
+		assert.Equal(t, "Message 2 cannot be empty", thread.Validate().Message())
+	})
+
+	t.Run("Message too long", func(t *testing.T) {
+		thread := &Thread{Messages: []string{"first", strings.Repeat("字", 141)}}
+
+		err := thread.Validate()
+
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.Equal(t, "Message 2 is 282 characters long, the limit is 280", err.Message())
+	})
+
+	t.Run("Unknown visibility", func(t *testing.T) {
+		thread := &Thread{Messages: []string{"first", "second"}, Visibility: "friends"}
+
+		assert.Equal(t, "Visibility must be one of public, unlisted, private or direct", thread.Validate().Message())
+	})
+}
+
+func TestTweet_Validate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		tweet := &Tweet{Message: " the message ", Visibility: "unlisted"}
+
+		assert.Nil(t, tweet.Validate())
+		assert.Equal(t, "the message", tweet.Message)
+	})
+
+	t.Run("Normalizes the message", func(t *testing.T) {
+		tweet := &Tweet{Message: "cafe\u0301"}
+
+		assert.Nil(t, tweet.Validate())
+		assert.Equal(t, "caf\u00e9", tweet.Message)
+	})
+
+	t.Run("Weighted length limit", func(t *testing.T) {
+		link := "https://example.com/" + strings.Repeat("long/", 60)
+		tweet := &Tweet{Message: strings.Repeat("a", 256) + " " + link}
+
+		assert.Nil(t, tweet.Validate())
+	})
+
+	t.Run("Too long", func(t *testing.T) {
+		tweet := &Tweet{Message: strings.Repeat("a", 270) + " 👋👋👋👋👋"}
+
+		err := tweet.Validate()
+
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.Equal(t, "Message is 281 characters long, the limit is 280", err.Message())
+	})
+
+	t.Run("Unknown visibility", func(t *testing.T) {
+		tweet := &Tweet{Message: "the message", Visibility: "friends"}
+
+		err := tweet.Validate()
+
+		assert.Equal(t, "Visibility must be one of public, unlisted, private or direct", err.Message())
+	})
+}
+
+func TestTweet_ValidateKind(t *testing.T) {
+	t.Run("Defaults to original", func(t *testing.T) {
+		tweet := &Tweet{Message: "the message"}
+
+		assert.Nil(t, tweet.Validate())
+		assert.EqualValues(t, Original, tweet.Kind)
+	})
+
+	t.Run("Retweet by link", func(t *testing.T) {
+		tweet := &Tweet{Kind: Retweet, Target: " https://twitter.com/lattr/status/1436255364069150720?s=20 "}
+
+		assert.Nil(t, tweet.Validate())
+		assert.Equal(t, "1436255364069150720", tweet.Target)
+		assert.True(t, tweet.RefersToStatus())
+	})
+
+	t.Run("Quote by ID", func(t *testing.T) {
+		tweet := &Tweet{Kind: Quote, Message: "so true", Target: "1436255364069150720"}
+
+		assert.Nil(t, tweet.Validate())
+		assert.Equal(t, "1436255364069150720", tweet.Target)
+	})
+
+	tests := []struct {
+		name     string
+		tweet    Tweet
+		expected string
+	}{
+		{"Unknown kind", Tweet{Kind: "reply", Message: "the message"}, "Kind must be one of original, retweet or quote"},
+		{"Original with a target", Tweet{Message: "the message", Target: "1"}, "Only retweets and quote tweets can have a target"},
+		{"Target does not parse", Tweet{Kind: Retweet, Target: "https://example.com/lattr/status/1"}, "Target must be a status ID or a link to a status"},
+		{"Missing target", Tweet{Kind: Quote, Message: "the message"}, "Target must be a status ID or a link to a status"},
+		{"Retweet with a message", Tweet{Kind: Retweet, Message: "the message", Target: "1"}, "A retweet cannot have a message"},
+		{"Quote without a message", Tweet{Kind: Quote, Target: "1"}, "Body cannot be empty"},
+		{"Thread", Tweet{Kind: Quote, Message: "the message", Target: "1", ThreadId: "thread"}, "Retweets and quote tweets cannot be part of a thread"},
+		{"Destinations", Tweet{Kind: Retweet, Target: "1", Destinations: []Destination{{AccountId: 2}}}, "Retweets and quote tweets cannot have destinations"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tweet.Validate()
+
+			assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+			assert.Equal(t, tt.expected, err.Message())
+		})
+	}
+}
+
+func TestTweet_ValidateDeletion(t *testing.T) {
+	postTime := time.Now().Add(time.Hour)
+
+	t.Run("Delete after", func(t *testing.T) {
+		tweet := &Tweet{Message: "the message", PostTime: postTime, DeleteAfter: "90m"}
+
+		assert.Nil(t, tweet.Validate())
+	})
+
+	t.Run("Delete at", func(t *testing.T) {
+		deleteAt := postTime.Add(24 * time.Hour)
+		tweet := &Tweet{Message: "the message", PostTime: postTime, DeleteAt: &deleteAt}
+
+		assert.Nil(t, tweet.Validate())
+	})
+
+	t.Run("Both", func(t *testing.T) {
+		deleteAt := postTime.Add(24 * time.Hour)
+		tweet := &Tweet{Message: "the message", PostTime: postTime, DeleteAfter: "24h", DeleteAt: &deleteAt}
+
+		assert.Equal(t, "Set either deleteAfter or deleteAt, not both", tweet.Validate().Message())
+	})
+
+	t.Run("Invalid duration", func(t *testing.T) {
+		for _, after := range []string{"tomorrow", "-1h", "0s"} {
+			tweet := &Tweet{Message: "the message", PostTime: postTime, DeleteAfter: after}
+
+			err := tweet.Validate()
+
+			assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+			assert.Equal(t, "deleteAfter must be a positive duration such as 90m or 24h", err.Message())
+		}
+	})
+
+	t.Run("Delete at before post time", func(t *testing.T) {
+		thread := &Thread{Messages: []string{"first", "second"}, PostTime: postTime, DeleteAt: &postTime}
+
+		assert.Equal(t, "deleteAt must be after postTime", thread.Validate().Message())
+	})
+
+	t.Run("Threads pass the deletion to every part", func(t *testing.T) {
+		thread := &Thread{Messages: []string{"first", "second"}, PostTime: postTime, DeleteAfter: "1h"}
+
+		for _, tweet := range thread.Tweets("thread", Pending) {
+			assert.Equal(t, "1h", tweet.DeleteAfter)
+		}
+	})
+}
+
+func TestTweet_ScheduleDeletion(t *testing.T) {
+	postedAt := time.Now()
+
+	t.Run("Counts from when it was posted", func(t *testing.T) {
+		tweet := &Tweet{RemoteId: "100", PostedAt: &postedAt, DeleteAfter: "90m"}
+
+		tweet.ScheduleDeletion()
+
+		assert.Equal(t, postedAt.Add(90*time.Minute), *tweet.DeleteAt)
+	})
+
+	t.Run("Keeps a fixed time", func(t *testing.T) {
+		deleteAt := postedAt.Add(time.Hour)
+		tweet := &Tweet{RemoteId: "100", PostedAt: &postedAt, DeleteAt: &deleteAt}
+
+		tweet.ScheduleDeletion()
+
+		assert.Equal(t, &deleteAt, tweet.DeleteAt)
+	})
+
+	t.Run("Not without a remote status", func(t *testing.T) {
+		tweet := &Tweet{PostedAt: &postedAt, DeleteAfter: "90m"}
+
+		tweet.ScheduleDeletion()
+
+		assert.Nil(t, tweet.DeleteAt)
+	})
+
+	t.Run("Once every destination is done", func(t *testing.T) {
+		tweet := &Tweet{Status: Pending, DeleteAfter: "1h"}
+
+		tweet.Settle([]Destination{{Id: 1, Status: Posted, RemoteId: "1", PostedAt: &postedAt}, {Id: 2, Status: Pending}})
+
+		assert.Nil(t, tweet.DeleteAt)
+
+		tweet.Settle([]Destination{{Id: 1, Status: Posted, RemoteId: "1", PostedAt: &postedAt}, {Id: 2, Status: Posted, RemoteId: "2"}})
+
+		assert.Equal(t, postedAt.Add(time.Hour), *tweet.DeleteAt)
+	})
+}
+
+func TestTweetRepo_RequeueFailed(t *testing.T) {
+	var modified = time.Now().Local()
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		const sqlQuery = "UPDATE tweets SET Status='Pending'"
+		mock.ExpectPrepare(sqlQuery).ExpectExec().WithArgs(modified).WillReturnResult(sqlmock.NewResult(0, 3))
+
+		count, rqErr := s.RequeueFailed(modified)
+
+		assert.Nil(t, rqErr)
+		assert.EqualValues(t, 3, count)
+	})
+
+	t.Run("Invalid SQL Query", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		const sqlQuery = "UPDATE tweets SET Status='Pending'"
+		mock.ExpectPrepare(sqlQuery).WillReturnError(errors.New("invalid sql query"))
+
+		count, rqErr := s.RequeueFailed(modified)
+
+		assert.EqualValues(t, 0, count)
+		assert.Equal(t, "error when trying to prepare update: invalid sql query", rqErr.Message())
+	})
+}
+
+func TestTweetRepo_ClaimPending(t *testing.T) {
+	var createdAt = time.Now().Local()
+	postTime := createdAt.Add(-time.Hour)
+	leaseExpiresAt := createdAt.Add(15 * time.Minute)
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		claimed := func(id int64, postTime time.Time, position int) Tweet {
+			return Tweet{Id: id, UserId: "001", Message: "message", PostTime: postTime, Status: Posting, CreatedAt: createdAt, Modified: createdAt, ThreadPosition: position, ClaimedBy: "web.1", LeaseExpiresAt: &leaseExpiresAt}
+		}
+		expected := []Tweet{claimed(1, postTime, 0), claimed(2, postTime, 1), claimed(3, postTime.Add(time.Minute), 0)}
+		rows := sqlmock.NewRows(tweetColumnNames).AddRow(tweetRow(expected[2])...).AddRow(tweetRow(expected[1])...).AddRow(tweetRow(expected[0])...)
+
+		const sqlQuery = "UPDATE tweets SET Status='Posting'(.+)FOR UPDATE SKIP LOCKED\\) RETURNING"
+		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(10, "web.1", int64(900)).WillReturnRows(rows)
+
+		got, claimErr := s.ClaimPending(10, "web.1", 15*time.Minute)
+
+		assert.Nil(t, claimErr)
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("Nothing due", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		mock.ExpectPrepare("UPDATE tweets SET Status='Posting'").ExpectQuery().WillReturnRows(sqlmock.NewRows(tweetColumnNames))
+
+		got, claimErr := s.ClaimPending(10, "web.1", 15*time.Minute)
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusNotFound, claimErr.Status())
+	})
+}
+
+func TestTweetRepo_ClaimThread(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := InitTweetRepository(db)
+
+	const sqlQuery = "UPDATE tweets SET Status='Posting'(.+)WHERE ThreadId=\\$1(.+)FOR UPDATE SKIP LOCKED"
+	mock.ExpectPrepare(sqlQuery).ExpectExec().WithArgs("thread", "web.1", int64(900)).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	count, claimErr := s.ClaimThread("thread", "web.1", 15*time.Minute)
+
+	assert.Nil(t, claimErr)
+	assert.EqualValues(t, 2, count)
+}
+
+func TestTweetRepo_ReleaseClaims(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		const sqlQuery = "UPDATE tweets SET Status='Pending', ClaimedBy='', LeaseExpiresAt=NULL WHERE Status='Posting' AND ClaimedBy=\\$1"
+		mock.ExpectPrepare(sqlQuery).ExpectExec().WithArgs("web.1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		count, releaseErr := s.ReleaseClaims("web.1")
+
+		assert.Nil(t, releaseErr)
+		assert.EqualValues(t, 1, count)
+	})
+
+	t.Run("Update failed", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		mock.ExpectPrepare("UPDATE tweets").ExpectExec().WithArgs("web.1").WillReturnError(errors.New("connection reset"))
+
+		count, releaseErr := s.ReleaseClaims("web.1")
+
+		assert.EqualValues(t, 0, count)
+		assert.EqualValues(t, http.StatusInternalServerError, releaseErr.Status())
+	})
+}
+
+func TestTweetRepo_RenewClaim(t *testing.T) {
+	const sqlQuery = "UPDATE tweets SET LeaseExpiresAt=now\\(\\) \\+ \\$3(.+)WHERE Id=\\$1 AND Status='Posting' AND ClaimedBy=\\$2"
+
+	t.Run("Still held", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		mock.ExpectPrepare(sqlQuery).ExpectExec().WithArgs(int64(1), "web.1", int64(900)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		held, renewErr := s.RenewClaim(1, "web.1", 15*time.Minute)
+
+		assert.Nil(t, renewErr)
+		assert.True(t, held)
+	})
+
+	t.Run("Taken over after the lease expired", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		mock.ExpectPrepare(sqlQuery).ExpectExec().WithArgs(int64(1), "web.1", int64(900)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		held, renewErr := s.RenewClaim(1, "web.1", 15*time.Minute)
+
+		assert.Nil(t, renewErr)
+		assert.False(t, held)
+	})
+
+	t.Run("Update failed", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		mock.ExpectPrepare("UPDATE tweets").ExpectExec().WithArgs(int64(1), "web.1", int64(900)).WillReturnError(errors.New("connection reset"))
+
+		held, renewErr := s.RenewClaim(1, "web.1", 15*time.Minute)
+
+		assert.False(t, held)
+		assert.EqualValues(t, http.StatusInternalServerError, renewErr.Status())
+	})
+}
+
+func TestTweetRepo_GetOverdue(t *testing.T) {
+	var createdAt = time.Now().Local()
+	cutoff := time.Now().Add(-time.Hour)
+	postTime := cutoff.Add(-time.Hour)
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		expected := []Tweet{{Id: 1, UserId: "001", Message: "message", PostTime: postTime, Status: Pending, CreatedAt: createdAt, Modified: createdAt}}
+		rows := sqlmock.NewRows(tweetColumnNames).AddRow(tweetRow(expected[0])...)
+
+		const sqlQuery = "SELECT (.+) FROM tweets WHERE (.+) PostTime < \\$1 AND Attempts = 0 AND NextAttemptAt IS NULL AND NOT EXISTS \\(SELECT 1 FROM destinations (.+) destinations.Status = 'Posted'\\)"
+		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(cutoff).WillReturnRows(rows)
+
+		got, odErr := s.GetOverdue(cutoff)
+
+		assert.Nil(t, odErr)
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("Invalid SQL Syntax", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		const sqlQuery = "SELECT (.+) FROM tweets"
+		mock.ExpectPrepare(sqlQuery).WillReturnError(errors.New("invalid syntax"))
+
+		got, odErr := s.GetOverdue(cutoff)
+
+		assert.Nil(t, got)
+		assert.Equal(t, "Error when trying to prepare overdue entries: invalid syntax", odErr.Message())
+	})
+}
+
+func TestTweetRepo_GetDueDeletions(t *testing.T) {
+	var createdAt = time.Now().Local()
+	now := time.Now()
+	postedAt := now.Add(-2 * time.Hour)
+	deleteAt := now.Add(-time.Minute)
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		expected := []Tweet{{Id: 1, UserId: "001", Message: "message", PostTime: postedAt, Status: Posted, CreatedAt: createdAt, Modified: createdAt, RemoteId: "100", PostedAt: &postedAt, DeleteAfter: "1h", DeleteAt: &deleteAt}}
+		rows := sqlmock.NewRows(tweetColumnNames).AddRow(tweetRow(expected[0])...)
+
+		const sqlQuery = "SELECT (.+) FROM tweets WHERE (.+) DeleteAt <= "
+		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(now).WillReturnRows(rows)
+
+		got, ddErr := s.GetDueDeletions(now)
+
+		assert.Nil(t, ddErr)
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("None Due", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		s := InitTweetRepository(db)
+
+		const sqlQuery = "SELECT (.+) FROM tweets WHERE (.+) DeleteAt <= "
+		mock.ExpectPrepare(sqlQuery).ExpectQuery().WithArgs(now).WillReturnRows(sqlmock.NewRows(tweetColumnNames))
+
+		got, ddErr := s.GetDueDeletions(now)
+
+		assert.Nil(t, got)
+		assert.Equal(t, http.StatusNotFound, ddErr.Status())
+	})
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/RemeJuan/lattr/app"
 	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/services"
 	"github.com/RemeJuan/lattr/utils/scheduler"
 )
 
@@ -28,12 +29,32 @@ import (
 // @name Authorization
 // @scope.tweet:create Grants write access
 // @scope.tweet:read Grants read and write access to administrative information
+// @scope.account:create Grants access to add posting accounts
+// @scope.admin:read Grants read access to the scheduler status
 
 func main() {
 	log.Println("server started")
 
 	domain.TweetRepo.Initialize()
 	domain.TokenRepo.Initialize()
+	domain.MediaRepo.Initialize()
+	domain.AccountRepo.Initialize()
+	domain.ConnectionRepo.Initialize()
+	domain.DestinationRepo.Initialize()
+	domain.OutboxRepo.Initialize()
+	domain.PollRepo.Initialize()
+	domain.LeaderRepo.Initialize()
+
+	// `lattr reencrypt` re-seals the stored credentials after a new master key was added
+	if len(os.Args) > 1 && os.Args[1] == "reencrypt" {
+		count, err := services.AccountService.Reencrypt()
+		if err != nil {
+			log.Fatalln("re-encrypt failed:", err.Message())
+		}
+
+		log.Printf("re-encrypted credentials of %d accounts\n", count)
+		return
+	}
 
 	if os.Getenv("GIN_MODE") == "release" {
 		scheduler.Scheduler()
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/bluesky"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/secrets"
+)
+
+var (
+	AccountService accountServiceInterface = &accountService{}
+)
+
+type accountService struct{}
+
+type accountServiceInterface interface {
+	Create(*domain.Account) (*domain.Account, error_utils.MessageErr)
+	Get(int64) (*domain.Account, error_utils.MessageErr)
+	List() ([]domain.Account, error_utils.MessageErr)
+	Update(*domain.Account) (*domain.Account, error_utils.MessageErr)
+	Delete(int64) error_utils.MessageErr
+	Reencrypt() (int, error_utils.MessageErr)
+}
+
+// Accounts are returned without their credentials, which are sealed before they are stored
+// and only opened by the scheduler when posting
+
+func (as accountService) Create(account *domain.Account) (*domain.Account, error_utils.MessageErr) {
+	if err := account.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := sealCredentials(account); err != nil {
+		return nil, err
+	}
+
+	account.CreatedAt = time.Now().Local()
+	account.Modified = time.Now().Local()
+
+	acc, err := domain.AccountRepo.Create(account)
+	if err != nil {
+		return nil, err
+	}
+
+	return acc.Redact(), nil
+}
+
+func (as accountService) Get(id int64) (*domain.Account, error_utils.MessageErr) {
+	account, err := domain.AccountRepo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return account.Redact(), nil
+}
+
+func (as accountService) List() ([]domain.Account, error_utils.MessageErr) {
+	accounts, err := domain.AccountRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range accounts {
+		accounts[i].Redact()
+	}
+	return accounts, nil
+}
+
+// Update renames the account, the credentials are only replaced when a full new set is given.
+// The network cannot be changed and the instance URL, handle and API version are kept unless new ones are given
+func (as accountService) Update(account *domain.Account) (*domain.Account, error_utils.MessageErr) {
+	current, err := domain.AccountRepo.Get(account.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	account.Network = current.Network
+	if account.InstanceUrl == "" {
+		account.InstanceUrl = current.InstanceUrl
+	}
+	if account.Handle == "" {
+		account.Handle = current.Handle
+	}
+	if account.ApiVersion == "" {
+		account.ApiVersion = current.ApiVersion
+	}
+
+	keepCredentials := account.ConsumerKey == "" && account.ConsumerSecret == "" && account.AccessToken == "" && account.AccessTokenSecret == ""
+
+	if keepCredentials {
+		account.ConsumerKey = current.ConsumerKey
+		account.ConsumerSecret = current.ConsumerSecret
+		account.AccessToken = current.AccessToken
+		account.AccessTokenSecret = current.AccessTokenSecret
+	}
+
+	if err := account.Validate(); err != nil {
+		return nil, err
+	}
+
+	if !keepCredentials {
+		if err := sealCredentials(account); err != nil {
+			return nil, err
+		}
+	}
+
+	account.CreatedAt = current.CreatedAt
+	account.Modified = time.Now().Local()
+
+	updated, err := domain.AccountRepo.Update(account)
+	if err != nil {
+		return nil, err
+	}
+	return updated.Redact(), nil
+}
+
+// Delete removes an account that no tweet or destination posts as, tweets keep their account once
+// posted so their deletions and replies still go through it
+func (as accountService) Delete(id int64) error_utils.MessageErr {
+	account, err := domain.AccountRepo.Get(id)
+	if err != nil {
+		return err
+	}
+
+	references, err := domain.AccountRepo.CountReferences(account.Id)
+	if err != nil {
+		return err
+	}
+
+	if references > 0 {
+		return error_utils.UnprocessableEntityError(fmt.Sprintf("Account is used by %d tweets or destinations and cannot be deleted", references))
+	}
+
+	return domain.AccountRepo.Delete(account.Id)
+}
+
+// Reencrypt re-seals every account's credentials under the current master key, it is run after
+// a new key is added to ENCRYPTION_KEYS so the old one can be retired. Returns how many accounts changed
+func (as accountService) Reencrypt() (int, error_utils.MessageErr) {
+	keyring, keyErr := secrets.LoadKeyring()
+	if keyErr != nil {
+		return 0, error_utils.InternalServerError(keyErr.Error())
+	}
+
+	accounts, err := domain.AccountRepo.List()
+	if err != nil {
+		if err.Status() == http.StatusNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var count int
+
+	for i := range accounts {
+		account := &accounts[i]
+		var changed bool
+
+		for _, field := range account.Credentials() {
+			rotated, fieldChanged, rotErr := keyring.Rotate(*field)
+			if rotErr != nil {
+				return count, error_utils.InternalServerError(fmt.Sprintf("unable to re-encrypt account %d: %s", account.Id, rotErr.Error()))
+			}
+
+			*field = rotated
+			changed = changed || fieldChanged
+		}
+
+		if !changed {
+			continue
+		}
+
+		account.Modified = time.Now().Local()
+		if _, err := domain.AccountRepo.Update(account); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// sealCredentials encrypts the account's credentials before they are stored
+func sealCredentials(account *domain.Account) error_utils.MessageErr {
+	keyring, err := secrets.LoadKeyring()
+	if err != nil {
+		return error_utils.InternalServerError(fmt.Sprintf("unable to encrypt credentials: %s", err.Error()))
+	}
+
+	for _, field := range account.Credentials() {
+		sealed, sealErr := keyring.Seal(*field)
+		if sealErr != nil {
+			return error_utils.InternalServerError(fmt.Sprintf("unable to encrypt credentials: %s", sealErr.Error()))
+		}
+		*field = sealed
+	}
+
+	return nil
+}
+
+// checkAccount makes sure a tweet is not linked to an account that does not exist
+func checkAccount(id int64) error_utils.MessageErr {
+	if id == 0 {
+		return nil
+	}
+
+	if _, err := domain.AccountRepo.Get(id); err != nil {
+		if err.Status() == http.StatusNotFound {
+			return error_utils.UnprocessableEntityError(fmt.Sprintf("Account %d does not exist", id))
+		}
+		return err
+	}
+
+	return nil
+}
+
+// checkRetweetNetwork rejects retweets and quote tweets for an account whose network cannot post them,
+// so they fail when created rather than when they are due
+func checkRetweetNetwork(accountId int64) error_utils.MessageErr {
+	account, err := networkAccount(accountId)
+	if err != nil {
+		return err
+	}
+
+	if !account.Network.PostsRetweets() {
+		return error_utils.UnprocessableEntityError(fmt.Sprintf("Retweets and quote tweets cannot be posted to %s accounts", account.Network))
+	}
+
+	return nil
+}
+
+// checkMessageLength rejects a message that is too long for Bluesky when one of the accounts posts
+// there. Tweets are validated against Twitter's weighted length, where a link always counts as 23
+// characters, so a post with long links would otherwise only fail once due
+func checkMessageLength(message string, accountIds []int64) error_utils.MessageErr {
+	length := bluesky.Graphemes(message)
+	if length <= bluesky.MaxGraphemes {
+		return nil
+	}
+
+	for _, id := range accountIds {
+		account, err := networkAccount(id)
+		if err != nil {
+			return err
+		}
+
+		if account.Network == domain.BlueskyNetwork {
+			return error_utils.UnprocessableEntityError(fmt.Sprintf("Message is %d characters long, the limit on Bluesky is %d", length, bluesky.MaxGraphemes))
+		}
+	}
+
+	return nil
+}
+
+// networkAccount returns the account, account 0 posts to the default network
+func networkAccount(accountId int64) (*domain.Account, error_utils.MessageErr) {
+	if accountId == 0 {
+		return &domain.Account{Network: domain.DefaultNetwork()}, nil
+	}
+
+	return domain.AccountRepo.Get(accountId)
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/secrets"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	createAccountDomain   func(account *domain.Account) (*domain.Account, error_utils.MessageErr)
+	getAccountDomain      func(id int64) (*domain.Account, error_utils.MessageErr)
+	listAccountsDomain    func() ([]domain.Account, error_utils.MessageErr)
+	updateAccountDomain   func(account *domain.Account) (*domain.Account, error_utils.MessageErr)
+	deleteAccountDomain   func(id int64) error_utils.MessageErr
+	countReferencesDomain func(id int64) (int64, error_utils.MessageErr)
+)
+
+type accountDbMock struct{}
+
+func (m *accountDbMock) Create(account *domain.Account) (*domain.Account, error_utils.MessageErr) {
+	return createAccountDomain(account)
+}
+func (m *accountDbMock) Get(id int64) (*domain.Account, error_utils.MessageErr) {
+	return getAccountDomain(id)
+}
+func (m *accountDbMock) List() ([]domain.Account, error_utils.MessageErr) {
+	return listAccountsDomain()
+}
+func (m *accountDbMock) Update(account *domain.Account) (*domain.Account, error_utils.MessageErr) {
+	return updateAccountDomain(account)
+}
+func (m *accountDbMock) Delete(id int64) error_utils.MessageErr {
+	return deleteAccountDomain(id)
+}
+func (m *accountDbMock) CountReferences(id int64) (int64, error_utils.MessageErr) {
+	return countReferencesDomain(id)
+}
+func (m *accountDbMock) Initialize() *sql.DB {
+	return nil
+}
+
+var (
+	oldMasterKey = "1:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32)))
+	newMasterKey = "2:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("b", 32)))
+)
+
+// withMasterKeys configures ENCRYPTION_KEYS for the duration of the test
+func withMasterKeys(t *testing.T, keys string) *secrets.Keyring {
+	_ = os.Setenv("ENCRYPTION_KEYS", keys)
+	t.Cleanup(func() { _ = os.Unsetenv("ENCRYPTION_KEYS") })
+
+	keyring, err := secrets.LoadKeyring()
+	if err != nil {
+		t.Fatalf("unexpected keyring error: %s", err)
+	}
+	return keyring
+}
+
+// opened decrypts a stored credential
+func opened(t *testing.T, keyring *secrets.Keyring, sealed string) string {
+	plaintext, err := keyring.Open(sealed)
+	if err != nil {
+		t.Fatalf("unable to open %q: %s", sealed, err)
+	}
+	return plaintext
+}
+
+func storedAccount() *domain.Account {
+	return &domain.Account{Id: 1, Name: "lattr", ApiVersion: domain.TwitterApiV2, ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: "at", AccessTokenSecret: "ats", CreatedAt: tm, Modified: tm}
+}
+
+func TestAccountService_Create(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		keyring := withMasterKeys(t, oldMasterKey)
+		domain.AccountRepo = &accountDbMock{}
+
+		var stored domain.Account
+		createAccountDomain = func(account *domain.Account) (*domain.Account, error_utils.MessageErr) {
+			stored = *account
+			account.Id = 1
+			return account, nil
+		}
+
+		got, err := AccountService.Create(&domain.Account{Name: "lattr", ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: "at", AccessTokenSecret: "ats"})
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 1, got.Id)
+		assert.True(t, secrets.IsSealed(stored.AccessTokenSecret), "credentials should be stored sealed")
+		assert.Equal(t, "ats", opened(t, keyring, stored.AccessTokenSecret))
+		assert.Equal(t, "ck", opened(t, keyring, stored.ConsumerKey))
+		assert.Empty(t, got.AccessTokenSecret, "credentials should not be returned")
+		assert.False(t, got.CreatedAt.IsZero())
+	})
+
+	t.Run("Encryption not configured", func(t *testing.T) {
+		domain.AccountRepo = &accountDbMock{}
+
+		got, err := AccountService.Create(&domain.Account{Name: "lattr", ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: "at", AccessTokenSecret: "ats"})
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusInternalServerError, err.Status())
+	})
+
+	t.Run("Validation failed", func(t *testing.T) {
+		domain.AccountRepo = &accountDbMock{}
+
+		got, err := AccountService.Create(&domain.Account{Name: "lattr"})
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+	})
+}
+
+func TestAccountService_Get(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		domain.AccountRepo = &accountDbMock{}
+
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return storedAccount(), nil
+		}
+
+		got, err := AccountService.Get(1)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "lattr", got.Name)
+		assert.Empty(t, got.ConsumerSecret)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		domain.AccountRepo = &accountDbMock{}
+
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no record matching given id")
+		}
+
+		got, err := AccountService.Get(1)
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusNotFound, err.Status())
+	})
+}
+
+func TestAccountService_List(t *testing.T) {
+	domain.AccountRepo = &accountDbMock{}
+
+	listAccountsDomain = func() ([]domain.Account, error_utils.MessageErr) {
+		return []domain.Account{*storedAccount(), *storedAccount()}, nil
+	}
+
+	got, err := AccountService.List()
+
+	assert.Nil(t, err)
+	assert.Len(t, got, 2)
+	assert.Empty(t, got[1].AccessToken)
+}
+
+func TestAccountService_Update(t *testing.T) {
+	t.Run("Keeps credentials when none are given", func(t *testing.T) {
+		domain.AccountRepo = &accountDbMock{}
+
+		var stored domain.Account
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return storedAccount(), nil
+		}
+		updateAccountDomain = func(account *domain.Account) (*domain.Account, error_utils.MessageErr) {
+			stored = *account
+			return account, nil
+		}
+
+		got, err := AccountService.Update(&domain.Account{Id: 1, Name: "renamed"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "renamed", got.Name)
+		assert.Equal(t, "ck", stored.ConsumerKey)
+		assert.Equal(t, "ats", stored.AccessTokenSecret)
+		assert.Equal(t, domain.TwitterApiV2, stored.ApiVersion)
+		assert.Equal(t, tm, stored.CreatedAt)
+	})
+
+	t.Run("Switches the API version", func(t *testing.T) {
+		domain.AccountRepo = &accountDbMock{}
+
+		var stored domain.Account
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return storedAccount(), nil
+		}
+		updateAccountDomain = func(account *domain.Account) (*domain.Account, error_utils.MessageErr) {
+			stored = *account
+			return account, nil
+		}
+
+		_, err := AccountService.Update(&domain.Account{Id: 1, Name: "lattr", ApiVersion: domain.TwitterApiV1})
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.TwitterApiV1, stored.ApiVersion)
+	})
+
+	t.Run("Replaces a full set of credentials", func(t *testing.T) {
+		keyring := withMasterKeys(t, oldMasterKey)
+		domain.AccountRepo = &accountDbMock{}
+
+		var stored domain.Account
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return storedAccount(), nil
+		}
+		updateAccountDomain = func(account *domain.Account) (*domain.Account, error_utils.MessageErr) {
+			stored = *account
+			return account, nil
+		}
+
+		_, err := AccountService.Update(&domain.Account{Id: 1, Name: "lattr", ConsumerKey: "ck2", ConsumerSecret: "cs2", AccessToken: "at2", AccessTokenSecret: "ats2"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "at2", opened(t, keyring, stored.AccessToken))
+	})
+
+	t.Run("Rejects a partial set of credentials", func(t *testing.T) {
+		domain.AccountRepo = &accountDbMock{}
+
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return storedAccount(), nil
+		}
+
+		got, err := AccountService.Update(&domain.Account{Id: 1, Name: "lattr", AccessToken: "at2"})
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+	})
+
+	t.Run("Keeps the network and instance", func(t *testing.T) {
+		keyring := withMasterKeys(t, oldMasterKey)
+		domain.AccountRepo = &accountDbMock{}
+
+		var stored domain.Account
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return &domain.Account{Id: 1, Name: "lattr", Network: domain.MastodonNetwork, InstanceUrl: "https://mastodon.social", AccessToken: "at", CreatedAt: tm, Modified: tm}, nil
+		}
+		updateAccountDomain = func(account *domain.Account) (*domain.Account, error_utils.MessageErr) {
+			stored = *account
+			return account, nil
+		}
+
+		got, err := AccountService.Update(&domain.Account{Id: 1, Name: "lattr", Network: domain.TwitterNetwork, AccessToken: "at2"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.MastodonNetwork, got.Network)
+		assert.Equal(t, "https://mastodon.social", got.InstanceUrl)
+		assert.Equal(t, "at2", opened(t, keyring, stored.AccessToken))
+	})
+}
+
+func TestAccountService_Delete(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		domain.AccountRepo = &accountDbMock{}
+
+		var deleted int64
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return storedAccount(), nil
+		}
+		countReferencesDomain = func(id int64) (int64, error_utils.MessageErr) {
+			return 0, nil
+		}
+		deleteAccountDomain = func(id int64) error_utils.MessageErr {
+			deleted = id
+			return nil
+		}
+
+		assert.Nil(t, AccountService.Delete(1))
+		assert.EqualValues(t, 1, deleted)
+	})
+
+	t.Run("Still used by tweets", func(t *testing.T) {
+		domain.AccountRepo = &accountDbMock{}
+
+		var deleted bool
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return storedAccount(), nil
+		}
+		countReferencesDomain = func(id int64) (int64, error_utils.MessageErr) {
+			return 2, nil
+		}
+		deleteAccountDomain = func(id int64) error_utils.MessageErr {
+			deleted = true
+			return nil
+		}
+
+		err := AccountService.Delete(1)
+
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.Equal(t, "Account is used by 2 tweets or destinations and cannot be deleted", err.Message())
+		assert.False(t, deleted)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		domain.AccountRepo = &accountDbMock{}
+
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no record matching given id")
+		}
+
+		err := AccountService.Delete(1)
+
+		assert.EqualValues(t, http.StatusNotFound, err.Status())
+	})
+}
+
+func TestAccountService_Reencrypt(t *testing.T) {
+	t.Run("Re-seals under the current key", func(t *testing.T) {
+		old := withMasterKeys(t, oldMasterKey)
+		sealedAccount := storedAccount()
+		for _, field := range sealedAccount.Credentials() {
+			*field, _ = old.Seal(*field)
+		}
+		plainAccount := storedAccount()
+		plainAccount.Id = 2
+
+		keyring := withMasterKeys(t, newMasterKey+","+oldMasterKey)
+		current := storedAccount()
+		current.Id = 3
+		for _, field := range current.Credentials() {
+			*field, _ = keyring.Seal(*field)
+		}
+
+		domain.AccountRepo = &accountDbMock{}
+
+		updated := make(map[int64]domain.Account)
+		listAccountsDomain = func() ([]domain.Account, error_utils.MessageErr) {
+			return []domain.Account{*sealedAccount, *plainAccount, *current}, nil
+		}
+		updateAccountDomain = func(account *domain.Account) (*domain.Account, error_utils.MessageErr) {
+			updated[account.Id] = *account
+			return account, nil
+		}
+
+		count, err := AccountService.Reencrypt()
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, count)
+		assert.Len(t, updated, 2)
+
+		newOnly := withMasterKeys(t, newMasterKey)
+		assert.Equal(t, "ats", opened(t, newOnly, updated[1].AccessTokenSecret))
+		assert.Equal(t, "cs", opened(t, newOnly, updated[2].ConsumerSecret))
+	})
+
+	t.Run("No accounts", func(t *testing.T) {
+		withMasterKeys(t, oldMasterKey)
+		domain.AccountRepo = &accountDbMock{}
+
+		listAccountsDomain = func() ([]domain.Account, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no records found")
+		}
+
+		count, err := AccountService.Reencrypt()
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Unknown key", func(t *testing.T) {
+		old := withMasterKeys(t, oldMasterKey)
+		account := storedAccount()
+		account.AccessToken, _ = old.Seal("at")
+
+		withMasterKeys(t, newMasterKey)
+		domain.AccountRepo = &accountDbMock{}
+
+		listAccountsDomain = func() ([]domain.Account, error_utils.MessageErr) {
+			return []domain.Account{*account}, nil
+		}
+
+		count, err := AccountService.Reencrypt()
+
+		assert.Equal(t, 0, count)
+		assert.EqualValues(t, http.StatusInternalServerError, err.Status())
+	})
+}
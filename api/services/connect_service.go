@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/secrets"
+	"github.com/RemeJuan/lattr/utils/twitter"
+)
+
+var (
+	ConnectService connectServiceInterface = &connectService{}
+)
+
+type connectService struct{}
+
+// connectServiceInterface runs the three-legged OAuth flow that connects a Twitter account
+type connectServiceInterface interface {
+	Start(*domain.Connection) (*domain.Connection, error_utils.MessageErr)
+	Complete(requestToken string, verifier string) (*domain.Account, error_utils.MessageErr)
+	Cancel(requestToken string) error_utils.MessageErr
+}
+
+// Start requests a token from Twitter and keeps its sealed secret until the user returns to the
+// callback. Requests that were abandoned are cleared out on the way
+func (cs connectService) Start(connection *domain.Connection) (*domain.Connection, error_utils.MessageErr) {
+	if err := connection.Validate(); err != nil {
+		return nil, err
+	}
+
+	keyring, keyErr := secrets.LoadKeyring()
+	if keyErr != nil {
+		return nil, error_utils.InternalServerError(fmt.Sprintf("unable to start the connection: %s", keyErr.Error()))
+	}
+
+	if err := domain.ConnectionRepo.DeleteExpired(time.Now().Local().Add(-domain.ConnectionTTL)); err != nil {
+		return nil, err
+	}
+
+	token, secret, authURL, err := twitter.RequestToken()
+	if err != nil {
+		return nil, error_utils.InternalServerError(fmt.Sprintf("unable to start the connection: %s", err.Error()))
+	}
+
+	sealed, sealErr := keyring.Seal(secret)
+	if sealErr != nil {
+		return nil, error_utils.InternalServerError(fmt.Sprintf("unable to start the connection: %s", sealErr.Error()))
+	}
+
+	connection.RequestToken = token
+	connection.RequestSecret = sealed
+	connection.AuthorizationUrl = authURL
+	connection.CreatedAt = time.Now().Local()
+
+	return domain.ConnectionRepo.Create(connection)
+}
+
+// Complete exchanges the authorized request token for the user's credentials and stores them
+// as a new account. A request can only be completed once
+func (cs connectService) Complete(requestToken string, verifier string) (*domain.Account, error_utils.MessageErr) {
+	connection, err := pendingConnection(requestToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if connection.Expired(time.Now()) {
+		return nil, error_utils.UnprocessableEntityError("Connection request has expired")
+	}
+
+	keyring, keyErr := secrets.LoadKeyring()
+	if keyErr != nil {
+		return nil, error_utils.InternalServerError(fmt.Sprintf("unable to complete the connection: %s", keyErr.Error()))
+	}
+
+	secret, openErr := keyring.Open(connection.RequestSecret)
+	if openErr != nil {
+		return nil, error_utils.InternalServerError(fmt.Sprintf("unable to complete the connection: %s", openErr.Error()))
+	}
+
+	creds, credErr := twitter.AccessToken(connection.RequestToken, secret, verifier)
+	if credErr != nil {
+		return nil, error_utils.ForbiddenError(fmt.Sprintf("Twitter did not authorize the connection: %s", credErr.Error()))
+	}
+
+	return AccountService.Create(&domain.Account{
+		Name:              connection.Name,
+		ConsumerKey:       creds.ConsumerKey,
+		ConsumerSecret:    creds.ConsumerSecret,
+		AccessToken:       creds.AccessToken,
+		AccessTokenSecret: creds.AccessTokenSecret,
+	})
+}
+
+// Cancel discards a request the user declined to authorize
+func (cs connectService) Cancel(requestToken string) error_utils.MessageErr {
+	_, err := pendingConnection(requestToken)
+	return err
+}
+
+// pendingConnection takes the request out of the store, whatever the callback does with it
+// the request cannot be used again
+func pendingConnection(requestToken string) (*domain.Connection, error_utils.MessageErr) {
+	connection, err := domain.ConnectionRepo.Take(requestToken)
+	if err != nil {
+		if err.Status() == http.StatusNotFound {
+			return nil, error_utils.NotFoundError("Unknown connection request")
+		}
+		return nil, err
+	}
+
+	return connection, nil
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/secrets"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	createConnectionDomain        func(connection *domain.Connection) (*domain.Connection, error_utils.MessageErr)
+	takeConnectionDomain          func(requestToken string) (*domain.Connection, error_utils.MessageErr)
+	deleteExpiredConnectionDomain func(before time.Time) error_utils.MessageErr
+)
+
+type connectionDbMock struct{}
+
+func (m *connectionDbMock) Create(connection *domain.Connection) (*domain.Connection, error_utils.MessageErr) {
+	return createConnectionDomain(connection)
+}
+func (m *connectionDbMock) Take(requestToken string) (*domain.Connection, error_utils.MessageErr) {
+	return takeConnectionDomain(requestToken)
+}
+func (m *connectionDbMock) DeleteExpired(before time.Time) error_utils.MessageErr {
+	return deleteExpiredConnectionDomain(before)
+}
+func (m *connectionDbMock) Initialize() *sql.DB {
+	return nil
+}
+
+// stubOAuthServer stands in for Twitter's OAuth endpoints during the connect flow
+func stubOAuthServer(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/request_token", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("oauth_token=request-token&oauth_token_secret=request-secret&oauth_callback_confirmed=true"))
+	})
+	mux.HandleFunc("/access_token", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), `oauth_verifier="the-verifier"`) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("oauth_token=access-token&oauth_token_secret=access-secret"))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	for key, val := range map[string]string{
+		"OAUTH_BASE_URL":     server.URL,
+		"OAUTH_CALLBACK_URL": "https://api.lattr.app/accounts/connect/callback",
+		"CONSUMER_KEY":       "consumer-key",
+		"CONSUMER_SECRET":    "consumer-secret",
+	} {
+		_ = os.Setenv(key, val)
+		key := key
+		t.Cleanup(func() { _ = os.Unsetenv(key) })
+	}
+}
+
+func TestConnectService_Start(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		stubOAuthServer(t)
+		keyring := withMasterKeys(t, oldMasterKey)
+		domain.ConnectionRepo = &connectionDbMock{}
+
+		var expiredBefore time.Time
+		deleteExpiredConnectionDomain = func(before time.Time) error_utils.MessageErr {
+			expiredBefore = before
+			return nil
+		}
+		var stored domain.Connection
+		createConnectionDomain = func(connection *domain.Connection) (*domain.Connection, error_utils.MessageErr) {
+			stored = *connection
+			return connection, nil
+		}
+
+		got, err := ConnectService.Start(&domain.Connection{Name: "lattr"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "request-token", got.RequestToken)
+		assert.Equal(t, os.Getenv("OAUTH_BASE_URL")+"/authorize?oauth_token=request-token", got.AuthorizationUrl)
+		assert.True(t, secrets.IsSealed(stored.RequestSecret))
+		assert.Equal(t, "request-secret", opened(t, keyring, stored.RequestSecret))
+		assert.False(t, stored.CreatedAt.IsZero())
+		assert.WithinDuration(t, time.Now().Add(-domain.ConnectionTTL), expiredBefore, time.Minute)
+	})
+
+	t.Run("No master key", func(t *testing.T) {
+		stubOAuthServer(t)
+		domain.ConnectionRepo = &connectionDbMock{}
+
+		got, err := ConnectService.Start(&domain.Connection{Name: "lattr"})
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusInternalServerError, err.Status())
+		assert.Equal(t, "unable to start the connection: secrets: ENCRYPTION_KEYS is not configured", err.Message())
+	})
+
+	t.Run("Validation failed", func(t *testing.T) {
+		got, err := ConnectService.Start(&domain.Connection{})
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+	})
+
+	t.Run("Twitter unavailable", func(t *testing.T) {
+		stubOAuthServer(t)
+		withMasterKeys(t, oldMasterKey)
+		domain.ConnectionRepo = &connectionDbMock{}
+		deleteExpiredConnectionDomain = func(before time.Time) error_utils.MessageErr {
+			return nil
+		}
+		_ = os.Setenv("OAUTH_BASE_URL", "http://127.0.0.1:0")
+
+		got, err := ConnectService.Start(&domain.Connection{Name: "lattr"})
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusInternalServerError, err.Status())
+	})
+}
+
+func TestConnectService_Complete(t *testing.T) {
+	pending := func(keyring *secrets.Keyring, createdAt time.Time) func(string) (*domain.Connection, error_utils.MessageErr) {
+		sealed, err := keyring.Seal("request-secret")
+		if err != nil {
+			t.Fatalf("unexpected seal error: %s", err)
+		}
+		return func(requestToken string) (*domain.Connection, error_utils.MessageErr) {
+			return &domain.Connection{RequestToken: requestToken, RequestSecret: sealed, Name: "lattr", CreatedAt: createdAt}, nil
+		}
+	}
+
+	t.Run("Stores the connected account", func(t *testing.T) {
+		stubOAuthServer(t)
+		keyring := withMasterKeys(t, oldMasterKey)
+		domain.ConnectionRepo = &connectionDbMock{}
+		domain.AccountRepo = &accountDbMock{}
+
+		var taken string
+		var stored domain.Account
+		take := pending(keyring, time.Now())
+		takeConnectionDomain = func(requestToken string) (*domain.Connection, error_utils.MessageErr) {
+			taken = requestToken
+			return take(requestToken)
+		}
+		createAccountDomain = func(account *domain.Account) (*domain.Account, error_utils.MessageErr) {
+			stored = *account
+			account.Id = 3
+			return account, nil
+		}
+
+		got, err := ConnectService.Complete("request-token", "the-verifier")
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 3, got.Id)
+		assert.Equal(t, "lattr", got.Name)
+		assert.Empty(t, got.AccessToken)
+		assert.Equal(t, "request-token", taken)
+		assert.Equal(t, "lattr", stored.Name)
+		assert.Equal(t, "consumer-key", opened(t, keyring, stored.ConsumerKey))
+		assert.Equal(t, "consumer-secret", opened(t, keyring, stored.ConsumerSecret))
+		assert.Equal(t, "access-token", opened(t, keyring, stored.AccessToken))
+		assert.Equal(t, "access-secret", opened(t, keyring, stored.AccessTokenSecret))
+	})
+
+	t.Run("Unknown request token", func(t *testing.T) {
+		domain.ConnectionRepo = &connectionDbMock{}
+
+		takeConnectionDomain = func(requestToken string) (*domain.Connection, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no record matching given id")
+		}
+
+		got, err := ConnectService.Complete("request-token", "the-verifier")
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusNotFound, err.Status())
+		assert.Equal(t, "Unknown connection request", err.Message())
+	})
+
+	t.Run("Request already used", func(t *testing.T) {
+		stubOAuthServer(t)
+		keyring := withMasterKeys(t, oldMasterKey)
+		domain.ConnectionRepo = &connectionDbMock{}
+		domain.AccountRepo = &accountDbMock{}
+
+		take := pending(keyring, time.Now())
+		var used bool
+		takeConnectionDomain = func(requestToken string) (*domain.Connection, error_utils.MessageErr) {
+			if used {
+				return nil, error_utils.NotFoundError("no record matching given id")
+			}
+			used = true
+			return take(requestToken)
+		}
+
+		_, _ = ConnectService.Complete("request-token", "wrong")
+		got, err := ConnectService.Complete("request-token", "the-verifier")
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusNotFound, err.Status())
+	})
+
+	t.Run("Expired request", func(t *testing.T) {
+		keyring := withMasterKeys(t, oldMasterKey)
+		domain.ConnectionRepo = &connectionDbMock{}
+
+		takeConnectionDomain = pending(keyring, time.Now().Add(-time.Hour))
+
+		got, err := ConnectService.Complete("request-token", "the-verifier")
+
+		assert.Nil(t, got)
+		assert.Equal(t, "Connection request has expired", err.Message())
+	})
+
+	t.Run("Secret sealed with an unknown key", func(t *testing.T) {
+		keyring := withMasterKeys(t, oldMasterKey)
+		domain.ConnectionRepo = &connectionDbMock{}
+
+		takeConnectionDomain = pending(keyring, time.Now())
+		withMasterKeys(t, newMasterKey)
+
+		got, err := ConnectService.Complete("request-token", "the-verifier")
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusInternalServerError, err.Status())
+	})
+
+	t.Run("Rejected verifier", func(t *testing.T) {
+		stubOAuthServer(t)
+		keyring := withMasterKeys(t, oldMasterKey)
+		domain.ConnectionRepo = &connectionDbMock{}
+
+		takeConnectionDomain = pending(keyring, time.Now())
+
+		got, err := ConnectService.Complete("request-token", "wrong")
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusForbidden, err.Status())
+	})
+}
+
+func TestConnectService_Cancel(t *testing.T) {
+	domain.ConnectionRepo = &connectionDbMock{}
+
+	var taken string
+	takeConnectionDomain = func(requestToken string) (*domain.Connection, error_utils.MessageErr) {
+		taken = requestToken
+		return &domain.Connection{RequestToken: requestToken}, nil
+	}
+
+	assert.Nil(t, ConnectService.Cancel("request-token"))
+	assert.Equal(t, "request-token", taken)
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+)
+
+var (
+	DestinationService destinationServiceInterface = &destinationService{}
+)
+
+type destinationService struct{}
+
+type destinationServiceInterface interface {
+	List(int64) ([]domain.Destination, error_utils.MessageErr)
+	Update(tweetId int64, destination *domain.Destination) (*domain.Destination, error_utils.MessageErr)
+}
+
+func (ds destinationService) List(tweetId int64) ([]domain.Destination, error_utils.MessageErr) {
+	destinations, err := domain.DestinationRepo.List(tweetId)
+	if err != nil {
+		return nil, err
+	}
+	return destinations, nil
+}
+
+// Update skips a destination or re-queues it with a clean retry state, the status of the tweet
+// is then settled again from all of its destinations
+func (ds destinationService) Update(tweetId int64, destination *domain.Destination) (*domain.Destination, error_utils.MessageErr) {
+	current, err := domain.DestinationRepo.Get(destination.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.TweetId != tweetId {
+		return nil, error_utils.NotFoundError("no record matching given id")
+	}
+
+	if current.Status == domain.Posted {
+		return nil, error_utils.UnprocessableEntityError("A posted destination cannot be changed")
+	}
+
+	// a claimed tweet is settled by the replica posting it, saving it here would drop the claim
+	tweet, err := domain.TweetRepo.Get(tweetId)
+	if err != nil {
+		return nil, err
+	}
+
+	if tweet.Status == domain.Posting {
+		return nil, error_utils.UnprocessableEntityError("A tweet that is being posted cannot be changed")
+	}
+
+	switch destination.Status {
+	case domain.Skipped:
+		current.Status = domain.Skipped
+		current.NextAttemptAt = nil
+	case domain.Pending:
+		current.Status = domain.Pending
+		current.Attempts = 0
+		current.LastError = ""
+		current.NextAttemptAt = nil
+	default:
+		return nil, error_utils.UnprocessableEntityError("Status must be one of Pending or Skipped")
+	}
+
+	current.Modified = time.Now().Local()
+
+	if _, err = domain.DestinationRepo.Update(current); err != nil {
+		return nil, err
+	}
+
+	if err = settleTweet(tweetId, current.Modified); err != nil {
+		return nil, err
+	}
+
+	return current, nil
+}
+
+// settleTweet updates the status of a cross-posted tweet after one of its destinations changed
+func settleTweet(tweetId int64, modified time.Time) error_utils.MessageErr {
+	tweet, err := domain.TweetRepo.Get(tweetId)
+	if err != nil {
+		return err
+	}
+
+	destinations, err := domain.DestinationRepo.List(tweetId)
+	if err != nil {
+		return err
+	}
+
+	tweet.Settle(destinations)
+	tweet.Modified = modified
+
+	if _, err = domain.TweetRepo.Update(tweet); err != nil {
+		return err
+	}
+
+	// a destination moved back to pending makes the tweet due again
+	TweetsChanged()
+	return nil
+}
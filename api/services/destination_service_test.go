@@ -0,0 +1,177 @@
+package services
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	createDestinationDomain        func(destination *domain.Destination) (*domain.Destination, error_utils.MessageErr)
+	getDestinationDomain           func(id int64) (*domain.Destination, error_utils.MessageErr)
+	listDestinationsDomain         func(tweetId int64) ([]domain.Destination, error_utils.MessageErr)
+	updateDestinationDomain        func(destination *domain.Destination) (*domain.Destination, error_utils.MessageErr)
+	requeueFailedDestinationDomain func(modified time.Time) (int64, error_utils.MessageErr)
+)
+
+type destinationDbMock struct{}
+
+func (m *destinationDbMock) Create(destination *domain.Destination) (*domain.Destination, error_utils.MessageErr) {
+	return createDestinationDomain(destination)
+}
+func (m *destinationDbMock) Get(id int64) (*domain.Destination, error_utils.MessageErr) {
+	return getDestinationDomain(id)
+}
+func (m *destinationDbMock) List(tweetId int64) ([]domain.Destination, error_utils.MessageErr) {
+	return listDestinationsDomain(tweetId)
+}
+func (m *destinationDbMock) Update(destination *domain.Destination) (*domain.Destination, error_utils.MessageErr) {
+	return updateDestinationDomain(destination)
+}
+func (m *destinationDbMock) RequeueFailed(modified time.Time) (int64, error_utils.MessageErr) {
+	return requeueFailedDestinationDomain(modified)
+}
+func (m *destinationDbMock) Initialize() *sql.DB {
+	return nil
+}
+
+func noDestinations(tweetId int64) ([]domain.Destination, error_utils.MessageErr) {
+	return nil, error_utils.NotFoundError("no records found")
+}
+
+func TestDestinationService_Update(t *testing.T) {
+	const tweetId int64 = 1
+
+	t.Run("Skip", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+
+		var saved *domain.Tweet
+		getDestinationDomain = func(id int64) (*domain.Destination, error_utils.MessageErr) {
+			return &domain.Destination{Id: id, TweetId: tweetId, Status: domain.Failed, Attempts: 5, LastError: "mastodon: 401 invalid token"}, nil
+		}
+		updateDestinationDomain = func(destination *domain.Destination) (*domain.Destination, error_utils.MessageErr) {
+			return destination, nil
+		}
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: tweetId, Status: domain.Failed}, nil
+		}
+		listDestinationsDomain = func(id int64) ([]domain.Destination, error_utils.MessageErr) {
+			return []domain.Destination{{Id: 1, Status: domain.Posted, RemoteId: "7"}, {Id: 2, Status: domain.Skipped}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			saved = msg
+			return msg, nil
+		}
+
+		destination, err := DestinationService.Update(tweetId, &domain.Destination{Id: 2, Status: domain.Skipped})
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, domain.Skipped, destination.Status)
+		assert.EqualValues(t, domain.Posted, saved.Status)
+		assert.Equal(t, "7", saved.RemoteId)
+	})
+
+	t.Run("Tweet being posted", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+
+		var updated bool
+		getDestinationDomain = func(id int64) (*domain.Destination, error_utils.MessageErr) {
+			return &domain.Destination{Id: id, TweetId: tweetId, Status: domain.Pending, Attempts: 1}, nil
+		}
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: tweetId, Status: domain.Posting, ClaimedBy: "replica-1"}, nil
+		}
+		updateDestinationDomain = func(destination *domain.Destination) (*domain.Destination, error_utils.MessageErr) {
+			updated = true
+			return destination, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = true
+			return msg, nil
+		}
+
+		destination, err := DestinationService.Update(tweetId, &domain.Destination{Id: 2, Status: domain.Skipped})
+
+		assert.Nil(t, destination)
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.Equal(t, "A tweet that is being posted cannot be changed", err.Message())
+		assert.False(t, updated)
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+
+		var saved *domain.Tweet
+		getDestinationDomain = func(id int64) (*domain.Destination, error_utils.MessageErr) {
+			return &domain.Destination{Id: id, TweetId: tweetId, Status: domain.Failed, Attempts: 5, LastError: "gone"}, nil
+		}
+		updateDestinationDomain = func(destination *domain.Destination) (*domain.Destination, error_utils.MessageErr) {
+			return destination, nil
+		}
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: tweetId, Status: domain.Failed, LastError: "destination 2: gone"}, nil
+		}
+		listDestinationsDomain = func(id int64) ([]domain.Destination, error_utils.MessageErr) {
+			return []domain.Destination{{Id: 1, Status: domain.Posted}, {Id: 2, Status: domain.Pending}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			saved = msg
+			return msg, nil
+		}
+
+		destination, err := DestinationService.Update(tweetId, &domain.Destination{Id: 2, Status: domain.Pending})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, destination.Attempts)
+		assert.Empty(t, destination.LastError)
+		assert.EqualValues(t, domain.Pending, saved.Status)
+	})
+
+	t.Run("Posted", func(t *testing.T) {
+		domain.DestinationRepo = &destinationDbMock{}
+
+		getDestinationDomain = func(id int64) (*domain.Destination, error_utils.MessageErr) {
+			return &domain.Destination{Id: id, TweetId: tweetId, Status: domain.Posted}, nil
+		}
+
+		destination, err := DestinationService.Update(tweetId, &domain.Destination{Id: 2, Status: domain.Skipped})
+
+		assert.Nil(t, destination)
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.Equal(t, "A posted destination cannot be changed", err.Message())
+	})
+
+	t.Run("Invalid status", func(t *testing.T) {
+		domain.DestinationRepo = &destinationDbMock{}
+
+		getDestinationDomain = func(id int64) (*domain.Destination, error_utils.MessageErr) {
+			return &domain.Destination{Id: id, TweetId: tweetId, Status: domain.Failed}, nil
+		}
+
+		destination, err := DestinationService.Update(tweetId, &domain.Destination{Id: 2, Status: domain.Posted})
+
+		assert.Nil(t, destination)
+		assert.Equal(t, "Status must be one of Pending or Skipped", err.Message())
+	})
+
+	t.Run("Other tweet", func(t *testing.T) {
+		domain.DestinationRepo = &destinationDbMock{}
+
+		getDestinationDomain = func(id int64) (*domain.Destination, error_utils.MessageErr) {
+			return &domain.Destination{Id: id, TweetId: 9, Status: domain.Failed}, nil
+		}
+
+		destination, err := DestinationService.Update(tweetId, &domain.Destination{Id: 2, Status: domain.Skipped})
+
+		assert.Nil(t, destination)
+		assert.EqualValues(t, http.StatusNotFound, err.Status())
+	})
+}
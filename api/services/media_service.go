@@ -0,0 +1,82 @@
+package services
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+)
+
+var (
+	MediaService mediaServiceInterface = &mediaService{}
+)
+
+type mediaService struct{}
+
+type mediaServiceInterface interface {
+	Create(*domain.Media) (*domain.Media, error_utils.MessageErr)
+	List(int64) ([]domain.Media, error_utils.MessageErr)
+	Delete(tweetId int64, id int64) error_utils.MessageErr
+}
+
+func (ms mediaService) Create(media *domain.Media) (*domain.Media, error_utils.MessageErr) {
+	if err := media.Validate(); err != nil {
+		return nil, err
+	}
+
+	tweet, err := domain.TweetRepo.Get(media.TweetId)
+	if err != nil {
+		return nil, err
+	}
+
+	if tweet.Status != domain.Pending && tweet.Status != domain.Scheduled {
+		return nil, error_utils.UnprocessableEntityError("Media can only be added to a pending or scheduled tweet")
+	}
+
+	if tweet.Kind == domain.Retweet {
+		return nil, error_utils.UnprocessableEntityError("Media cannot be added to a retweet")
+	}
+
+	// Twitter does not accept media alongside a quoted tweet
+	if tweet.Kind == domain.Quote {
+		return nil, error_utils.UnprocessableEntityError("Media cannot be added to a quote tweet")
+	}
+
+	if _, err := domain.PollRepo.Get(tweet.Id); err == nil {
+		return nil, error_utils.UnprocessableEntityError("Media cannot be added to a tweet with a poll")
+	} else if err.Status() != http.StatusNotFound {
+		return nil, err
+	}
+
+	media.CreatedAt = time.Now().Local()
+	media.Modified = time.Now().Local()
+
+	md, err := domain.MediaRepo.Create(media)
+	if err != nil {
+		return nil, err
+	}
+
+	return md, nil
+}
+
+func (ms mediaService) List(tweetId int64) ([]domain.Media, error_utils.MessageErr) {
+	media, err := domain.MediaRepo.List(tweetId)
+	if err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+func (ms mediaService) Delete(tweetId int64, id int64) error_utils.MessageErr {
+	media, err := domain.MediaRepo.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if media.TweetId != tweetId {
+		return error_utils.NotFoundError("no record matching given id")
+	}
+
+	return domain.MediaRepo.Delete(media.Id)
+}
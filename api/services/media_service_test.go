@@ -0,0 +1,189 @@
+package services
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	createMediaDomain func(media *domain.Media) (*domain.Media, error_utils.MessageErr)
+	getMediaDomain    func(id int64) (*domain.Media, error_utils.MessageErr)
+	listMediaDomain   func(tweetId int64) ([]domain.Media, error_utils.MessageErr)
+	deleteMediaDomain func(id int64) error_utils.MessageErr
+)
+
+type mediaDbMock struct{}
+
+func (m *mediaDbMock) Create(media *domain.Media) (*domain.Media, error_utils.MessageErr) {
+	return createMediaDomain(media)
+}
+func (m *mediaDbMock) Get(id int64) (*domain.Media, error_utils.MessageErr) {
+	return getMediaDomain(id)
+}
+func (m *mediaDbMock) List(tweetId int64) ([]domain.Media, error_utils.MessageErr) {
+	return listMediaDomain(tweetId)
+}
+func (m *mediaDbMock) Delete(id int64) error_utils.MessageErr {
+	return deleteMediaDomain(id)
+}
+func (m *mediaDbMock) Initialize() *sql.DB {
+	return nil
+}
+
+func TestMediaService_Create(t *testing.T) {
+	const tweetId int64 = 1
+	request := func() *domain.Media {
+		return &domain.Media{TweetId: tweetId, MimeType: "image/png", AltText: "alt", Data: []byte("data")}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: tweetId, Status: domain.Pending}, nil
+		}
+		createMediaDomain = func(media *domain.Media) (*domain.Media, error_utils.MessageErr) {
+			media.Id = 5
+			return media, nil
+		}
+
+		got, err := MediaService.Create(request())
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 5, got.Id)
+		assert.False(t, got.CreatedAt.IsZero())
+	})
+
+	t.Run("Too many attachments", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: tweetId, Status: domain.Pending}, nil
+		}
+		createMediaDomain = func(media *domain.Media) (*domain.Media, error_utils.MessageErr) {
+			return nil, error_utils.UnprocessableEntityError("A tweet can have at most 4 media attachments")
+		}
+
+		got, err := MediaService.Create(request())
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.EqualValues(t, "A tweet can have at most 4 media attachments", err.Message())
+	})
+
+	t.Run("Tweet no longer pending", func(t *testing.T) {
+		for _, tweet := range []domain.Tweet{{Status: domain.Posting}, {Status: domain.Posted}, {Status: domain.Failed}, {Status: domain.Skipped}, {Status: domain.Deleted}} {
+			status := tweet.Status
+			domain.TweetRepo = &tweetDbMock{}
+			domain.MediaRepo = &mediaDbMock{}
+
+			getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+				return &domain.Tweet{Id: tweetId, Status: status}, nil
+			}
+
+			got, err := MediaService.Create(request())
+
+			assert.Nil(t, got)
+			assert.EqualValues(t, "Media can only be added to a pending or scheduled tweet", err.Message(), status)
+		}
+	})
+
+	t.Run("Retweet", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: tweetId, Status: domain.Pending, Kind: domain.Retweet, Target: "1436255364069150720"}, nil
+		}
+
+		got, err := MediaService.Create(request())
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, "Media cannot be added to a retweet", err.Message())
+	})
+
+	t.Run("Quote", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: tweetId, Status: domain.Pending, Kind: domain.Quote, Target: "1436255364069150720"}, nil
+		}
+
+		got, err := MediaService.Create(request())
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.EqualValues(t, "Media cannot be added to a quote tweet", err.Message())
+	})
+
+	t.Run("Poll", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: tweetId, Status: domain.Pending}, nil
+		}
+		getPollDomain = func(id int64) (*domain.Poll, error_utils.MessageErr) {
+			return &domain.Poll{TweetId: id, Options: []string{"Yes", "No"}, DurationMinutes: 60}, nil
+		}
+
+		got, err := MediaService.Create(request())
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, "Media cannot be added to a tweet with a poll", err.Message())
+	})
+
+	t.Run("Tweet not found", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no record matching given id")
+		}
+
+		got, err := MediaService.Create(request())
+
+		assert.Nil(t, got)
+		assert.EqualValues(t, http.StatusNotFound, err.Status())
+	})
+}
+
+func TestMediaService_Delete(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		domain.MediaRepo = &mediaDbMock{}
+
+		getMediaDomain = func(id int64) (*domain.Media, error_utils.MessageErr) {
+			return &domain.Media{Id: id, TweetId: 1}, nil
+		}
+		deleteMediaDomain = func(id int64) error_utils.MessageErr {
+			return nil
+		}
+
+		assert.Nil(t, MediaService.Delete(1, 2))
+	})
+
+	t.Run("Belongs to another tweet", func(t *testing.T) {
+		domain.MediaRepo = &mediaDbMock{}
+
+		getMediaDomain = func(id int64) (*domain.Media, error_utils.MessageErr) {
+			return &domain.Media{Id: id, TweetId: 3}, nil
+		}
+
+		err := MediaService.Delete(1, 2)
+
+		assert.EqualValues(t, http.StatusNotFound, err.Status())
+	})
+}
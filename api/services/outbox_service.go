@@ -0,0 +1,24 @@
+package services
+
+import (
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+)
+
+var (
+	OutboxService outboxServiceInterface = &outboxService{}
+)
+
+type outboxService struct{}
+
+type outboxServiceInterface interface {
+	List() ([]domain.OutboxEntry, error_utils.MessageErr)
+}
+
+func (obs outboxService) List() ([]domain.OutboxEntry, error_utils.MessageErr) {
+	entries, err := domain.OutboxRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
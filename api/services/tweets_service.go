@@ -1,16 +1,25 @@
 package services
 
 import (
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/bluesky"
 	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/twittertext"
+	"github.com/google/uuid"
 )
 
 var (
 	TweetService tweetServiceInterface = &tweetService{}
 )
 
+// TweetsChanged is called whenever tweets are created, updated, deleted or re-queued so the scheduler
+// can re-arm its dispatcher for the next due tweet, the scheduler sets it when it starts
+var TweetsChanged = func() {}
+
 type tweetService struct{}
 
 type tweetServiceInterface interface {
@@ -19,8 +28,13 @@ type tweetServiceInterface interface {
 	GetAll(string) ([]domain.Tweet, error_utils.MessageErr)
 	Update(*domain.Tweet) (*domain.Tweet, error_utils.MessageErr)
 	Delete(int64) error_utils.MessageErr
-	GetPending() ([]domain.Tweet, error_utils.MessageErr)
+	GetPending(int) ([]domain.Tweet, error_utils.MessageErr)
 	GetLast() (*domain.Tweet, error_utils.MessageErr)
+	CreateThread(*domain.Thread) ([]domain.Tweet, error_utils.MessageErr)
+	CreateSplit(*domain.Tweet) ([]domain.Tweet, error_utils.MessageErr)
+	GetThread(string) ([]domain.Tweet, error_utils.MessageErr)
+	Requeue(int64) (*domain.Tweet, error_utils.MessageErr)
+	RequeueFailed() (int64, error_utils.MessageErr)
 }
 
 func (ts tweetService) Create(tweet *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
@@ -28,23 +42,189 @@ func (ts tweetService) Create(tweet *domain.Tweet) (*domain.Tweet, error_utils.M
 		return nil, err
 	}
 
+	if err := checkAccount(tweet.AccountId); err != nil {
+		return nil, err
+	}
+
+	if tweet.RefersToStatus() {
+		if err := checkRetweetNetwork(tweet.AccountId); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, d := range tweet.Destinations {
+		if err := checkAccount(d.AccountId); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkMessageLength(tweet.Message, postingAccounts(tweet.AccountId, tweet.Destinations)); err != nil {
+		return nil, err
+	}
+
 	tweet.CreatedAt = time.Now().Local()
 	tweet.Modified = time.Now().Local()
 	tweet.PostTime = tweet.PostTime.Local()
+	if tweet.DeleteAt != nil {
+		deleteAt := tweet.DeleteAt.Local()
+		tweet.DeleteAt = &deleteAt
+	}
+
+	destinations := tweet.Destinations
+	poll := tweet.Poll
 
 	tw, err := domain.TweetRepo.Create(tweet)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(destinations) > 0 {
+		if tw.Destinations, err = createDestinations(tw, destinations); err != nil {
+			// a tweet missing some of its destinations would silently skip them
+			_ = domain.TweetRepo.Delete(tw.Id)
+			return nil, err
+		}
+	}
+
+	if poll != nil {
+		if tw.Poll, err = createPoll(tw, poll); err != nil {
+			_ = domain.TweetRepo.Delete(tw.Id)
+			return nil, err
+		}
+	}
+
+	TweetsChanged()
+
 	return tw, nil
 }
 
+// createPoll stores the poll of a tweet
+func createPoll(tweet *domain.Tweet, poll *domain.Poll) (*domain.Poll, error_utils.MessageErr) {
+	return domain.PollRepo.Create(&domain.Poll{
+		TweetId:         tweet.Id,
+		Options:         poll.Options,
+		DurationMinutes: poll.DurationMinutes,
+		CreatedAt:       tweet.CreatedAt,
+	})
+}
+
+// checkRefersToStatus validates a change to a retweet or quote against the stored tweet and its account,
+// the request does not carry its thread or destinations and may leave out the poll it keeps
+func checkRefersToStatus(current *domain.Tweet, keepsPoll bool) error_utils.MessageErr {
+	if current.IsThread() {
+		return error_utils.UnprocessableEntityError("Retweets and quote tweets cannot be part of a thread")
+	}
+
+	if keepsPoll {
+		return error_utils.UnprocessableEntityError("Retweets and quote tweets cannot have a poll")
+	}
+
+	if err := checkRetweetNetwork(current.AccountId); err != nil {
+		return err
+	}
+
+	// a retweet would drop the media and Twitter rejects a quote that carries it
+	media, err := domain.MediaRepo.List(current.Id)
+	if err != nil && err.Status() != http.StatusNotFound {
+		return err
+	}
+	if len(media) > 0 {
+		return error_utils.UnprocessableEntityError("Retweets and quote tweets cannot have media")
+	}
+
+	destinations, err := domain.DestinationRepo.List(current.Id)
+	if err != nil && err.Status() != http.StatusNotFound {
+		return err
+	}
+	if len(destinations) > 0 {
+		return error_utils.UnprocessableEntityError("Retweets and quote tweets cannot have destinations")
+	}
+
+	return nil
+}
+
+// postingAccounts lists the accounts a tweet is posted through, its destinations or else its own account
+func postingAccounts(accountId int64, destinations []domain.Destination) []int64 {
+	if len(destinations) == 0 {
+		return []int64{accountId}
+	}
+
+	ids := make([]int64, 0, len(destinations))
+	for _, d := range destinations {
+		ids = append(ids, d.AccountId)
+	}
+
+	return ids
+}
+
+// checkUpdatedLength checks a changed message against the networks of the stored tweet
+func checkUpdatedLength(current *domain.Tweet, message string) error_utils.MessageErr {
+	if bluesky.Graphemes(message) <= bluesky.MaxGraphemes {
+		return nil
+	}
+
+	destinations, err := domain.DestinationRepo.List(current.Id)
+	if err != nil && err.Status() != http.StatusNotFound {
+		return err
+	}
+
+	return checkMessageLength(message, postingAccounts(current.AccountId, destinations))
+}
+
+// samePoll reports whether the stored poll has the options and duration of the requested one
+func samePoll(stored *domain.Poll, requested *domain.Poll) bool {
+	if stored == nil || stored.DurationMinutes != requested.DurationMinutes || len(stored.Options) != len(requested.Options) {
+		return false
+	}
+
+	for i := range stored.Options {
+		if stored.Options[i] != requested.Options[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// createDestinations stores the destinations of a newly created cross-posted tweet
+func createDestinations(tweet *domain.Tweet, destinations []domain.Destination) ([]domain.Destination, error_utils.MessageErr) {
+	created := make([]domain.Destination, 0, len(destinations))
+
+	for _, d := range destinations {
+		dest, err := domain.DestinationRepo.Create(&domain.Destination{
+			TweetId:   tweet.Id,
+			AccountId: d.AccountId,
+			Status:    domain.Pending,
+			CreatedAt: tweet.CreatedAt,
+			Modified:  tweet.Modified,
+		})
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, *dest)
+	}
+
+	return created, nil
+}
+
 func (ts tweetService) Get(id int64) (*domain.Tweet, error_utils.MessageErr) {
 	message, err := domain.TweetRepo.Get(id)
 	if err != nil {
 		return nil, err
 	}
+
+	destinations, err := domain.DestinationRepo.List(id)
+	if err != nil && err.Status() != http.StatusNotFound {
+		return nil, err
+	}
+	message.Destinations = destinations
+
+	poll, err := domain.PollRepo.Get(id)
+	if err != nil && err.Status() != http.StatusNotFound {
+		return nil, err
+	}
+	message.Poll = poll
+
 	return message, nil
 }
 
@@ -56,26 +236,129 @@ func (ts tweetService) GetAll(userId string) ([]domain.Tweet, error_utils.Messag
 	return messages, nil
 }
 
+// Update saves the changes to a tweet. The poll is only replaced when the request has one, a poll
+// without options removes it
 func (ts tweetService) Update(tweet *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+	removePoll := tweet.Poll != nil && tweet.Poll.Removes()
+	if removePoll {
+		tweet.Poll = nil
+	}
+
 	if err := tweet.Validate(); err != nil {
 		return nil, err
 	}
+	if tweet.Status == domain.Posting {
+		return nil, error_utils.UnprocessableEntityError("Only the scheduler can move a tweet to Posting")
+	}
+
 	current, err := domain.TweetRepo.Get(tweet.Id)
 	if err != nil {
 		return nil, err
 	}
+
+	// saving the tweet would end the claim of the replica posting it
+	if current.Status == domain.Posting {
+		return nil, error_utils.UnprocessableEntityError("A tweet that is being posted cannot be changed")
+	}
+
+	if err := changeAccount(current, tweet.AccountId); err != nil {
+		return nil, err
+	}
+
+	if err := checkUpdatedLength(current, tweet.Message); err != nil {
+		return nil, err
+	}
+
+	poll, err := domain.PollRepo.Get(current.Id)
+	if err != nil && err.Status() != http.StatusNotFound {
+		return nil, err
+	}
+
+	pollChanged := (removePoll && poll != nil) || (tweet.Poll != nil && !samePoll(poll, tweet.Poll))
+
+	if tweet.RefersToStatus() {
+		if err := checkRefersToStatus(current, poll != nil && !removePoll); err != nil {
+			return nil, err
+		}
+	}
+
+	if pollChanged && (current.Status == domain.Posted || current.Status == domain.Deleted) {
+		return nil, error_utils.UnprocessableEntityError("The poll of a posted tweet cannot be changed")
+	}
+
+	if pollChanged && tweet.Poll != nil {
+		media, err := domain.MediaRepo.List(tweet.Id)
+		if err != nil && err.Status() != http.StatusNotFound {
+			return nil, err
+		}
+		if len(media) > 0 {
+			return nil, error_utils.UnprocessableEntityError("A tweet with media cannot have a poll")
+		}
+	}
+
 	current.Message = tweet.Message
 	current.PostTime = tweet.PostTime
 	current.Status = tweet.Status
+	current.Visibility = tweet.Visibility
+	current.ContentWarning = tweet.ContentWarning
+	current.Kind = tweet.Kind
+	current.Target = tweet.Target
+	current.DeleteAfter = tweet.DeleteAfter
+	current.DeleteAt = tweet.DeleteAt
+	// a tweet that is already posted counts a new deleteAfter from when it was posted
+	current.ScheduleDeletion()
 	current.Modified = time.Now().Local()
 
 	updateMsg, err := domain.TweetRepo.Update(current)
 	if err != nil {
 		return nil, err
 	}
+
+	updateMsg.Poll = poll
+
+	// the poll is replaced as a whole
+	if pollChanged {
+		if err := domain.PollRepo.Delete(updateMsg.Id); err != nil {
+			return nil, err
+		}
+		updateMsg.Poll = nil
+
+		if tweet.Poll != nil {
+			if updateMsg.Poll, err = createPoll(updateMsg, tweet.Poll); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	TweetsChanged()
+
 	return updateMsg, nil
 }
 
+// changeAccount moves the tweet to another account, leaving the account out keeps the current one.
+// Posted tweets are deleted through the account that posted them and thread parts reply to the part
+// before them on the same account, so neither can move
+func changeAccount(current *domain.Tweet, accountId int64) error_utils.MessageErr {
+	if accountId == 0 || accountId == current.AccountId {
+		return nil
+	}
+
+	if current.Status == domain.Posted || current.Status == domain.Deleted {
+		return error_utils.UnprocessableEntityError("The account of a posted tweet cannot be changed")
+	}
+
+	if current.IsThread() {
+		return error_utils.UnprocessableEntityError("The account of a thread part cannot be changed")
+	}
+
+	if err := checkAccount(accountId); err != nil {
+		return err
+	}
+
+	current.AccountId = accountId
+	return nil
+}
+
 func (ts tweetService) Delete(id int64) error_utils.MessageErr {
 	msg, err := domain.TweetRepo.Get(id)
 	if err != nil {
@@ -85,11 +368,12 @@ func (ts tweetService) Delete(id int64) error_utils.MessageErr {
 	if deleteErr != nil {
 		return deleteErr
 	}
+	TweetsChanged()
 	return nil
 }
 
-func (ts tweetService) GetPending() ([]domain.Tweet, error_utils.MessageErr) {
-	messages, err := domain.TweetRepo.GetPending()
+func (ts tweetService) GetPending(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+	messages, err := domain.TweetRepo.GetPending(limit)
 	if err != nil {
 		return nil, err
 	}
@@ -103,3 +387,140 @@ func (ts tweetService) GetLast() (*domain.Tweet, error_utils.MessageErr) {
 	}
 	return messages, nil
 }
+
+func (ts tweetService) CreateThread(thread *domain.Thread) ([]domain.Tweet, error_utils.MessageErr) {
+	if err := thread.Validate(); err != nil {
+		return nil, err
+	}
+
+	return ts.createThread(thread.Tweets(uuid.New().String(), domain.Pending))
+}
+
+// CreateSplit creates the tweet, splitting its message into a numbered thread when it is too long for a single tweet.
+// Retweets, quotes and polls are never split
+func (ts tweetService) CreateSplit(tweet *domain.Tweet) ([]domain.Tweet, error_utils.MessageErr) {
+	parts := twittertext.Split(strings.TrimSpace(tweet.Message), twittertext.MaxWeightedLength)
+
+	// a thread cannot retweet, quote or carry a poll, so those are only validated
+	if len(parts) == 1 || tweet.RefersToStatus() || tweet.Poll != nil {
+		tw, err := ts.Create(tweet)
+		if err != nil {
+			return nil, err
+		}
+		return []domain.Tweet{*tw}, nil
+	}
+
+	thread := &domain.Thread{
+		UserId:         tweet.UserId,
+		AccountId:      tweet.AccountId,
+		PostTime:       tweet.PostTime,
+		Messages:       parts,
+		Visibility:     tweet.Visibility,
+		ContentWarning: tweet.ContentWarning,
+		Destinations:   tweet.Destinations,
+		DeleteAfter:    tweet.DeleteAfter,
+		DeleteAt:       tweet.DeleteAt,
+	}
+
+	if err := thread.Validate(); err != nil {
+		return nil, err
+	}
+
+	// the parts keep the status of the submitted tweet, so webhook threads stay scheduled
+	return ts.createThread(thread.Tweets(uuid.New().String(), tweet.Status))
+}
+
+// createThread creates every part of a thread, removing the created parts again if one fails
+func (ts tweetService) createThread(tweets []domain.Tweet) ([]domain.Tweet, error_utils.MessageErr) {
+	created := make([]domain.Tweet, 0, len(tweets))
+
+	for i := range tweets {
+		tw, err := ts.Create(&tweets[i])
+		if err != nil {
+			// don't leave a partial thread behind that would post with gaps
+			for _, c := range created {
+				_ = domain.TweetRepo.Delete(c.Id)
+			}
+			return nil, err
+		}
+		created = append(created, *tw)
+	}
+
+	return created, nil
+}
+
+func (ts tweetService) GetThread(threadId string) ([]domain.Tweet, error_utils.MessageErr) {
+	tweets, err := domain.TweetRepo.GetThread(threadId)
+	if err != nil {
+		return nil, err
+	}
+	return tweets, nil
+}
+
+// Requeue moves a single failed tweet back to pending so the scheduler retries it
+func (ts tweetService) Requeue(id int64) (*domain.Tweet, error_utils.MessageErr) {
+	current, err := domain.TweetRepo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Status != domain.Failed {
+		return nil, error_utils.UnprocessableEntityError("Only failed tweets can be re-queued")
+	}
+
+	current.Status = domain.Pending
+	current.Attempts = 0
+	current.LastError = ""
+	current.NextAttemptAt = nil
+	current.Modified = time.Now().Local()
+
+	destinations, err := domain.DestinationRepo.List(id)
+	if err != nil && err.Status() != http.StatusNotFound {
+		return nil, err
+	}
+
+	// only the failed destinations are retried, the posted ones are not posted again
+	for i := range destinations {
+		if destinations[i].Status != domain.Failed {
+			continue
+		}
+
+		destinations[i].Status = domain.Pending
+		destinations[i].Attempts = 0
+		destinations[i].LastError = ""
+		destinations[i].NextAttemptAt = nil
+		destinations[i].Modified = current.Modified
+
+		if _, err = domain.DestinationRepo.Update(&destinations[i]); err != nil {
+			return nil, err
+		}
+	}
+	current.Destinations = destinations
+
+	updated, err := domain.TweetRepo.Update(current)
+	if err != nil {
+		return nil, err
+	}
+
+	TweetsChanged()
+
+	return updated, nil
+}
+
+// RequeueFailed moves every failed tweet and destination back to pending and returns how many tweets were re-queued
+func (ts tweetService) RequeueFailed() (int64, error_utils.MessageErr) {
+	now := time.Now().Local()
+
+	if _, err := domain.DestinationRepo.RequeueFailed(now); err != nil {
+		return 0, err
+	}
+
+	count, err := domain.TweetRepo.RequeueFailed(now)
+	if err != nil {
+		return 0, err
+	}
+
+	TweetsChanged()
+
+	return count, nil
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"database/sql"
 	"net/http"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,8 +19,10 @@ var (
 	updateTweetDomain      func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr)
 	deleteTweetDomain      func(messageId int64) error_utils.MessageErr
 	getAllTweetsDomain     func(userId string) ([]domain.Tweet, error_utils.MessageErr)
-	getPendingTweetsDomain func() ([]domain.Tweet, error_utils.MessageErr)
+	getPendingTweetsDomain func(limit int) ([]domain.Tweet, error_utils.MessageErr)
 	getLastTweetsDomain    func() (*domain.Tweet, error_utils.MessageErr)
+	getThreadDomain        func(threadId string) ([]domain.Tweet, error_utils.MessageErr)
+	requeueFailedDomain    func(modified time.Time) (int64, error_utils.MessageErr)
 )
 
 type tweetDbMock struct {
@@ -40,16 +44,54 @@ func (m *tweetDbMock) Update(msg *domain.Tweet) (*domain.Tweet, error_utils.Mess
 func (m *tweetDbMock) Delete(messageId int64) error_utils.MessageErr {
 	return deleteTweetDomain(messageId)
 }
-func (m *tweetDbMock) GetPending() ([]domain.Tweet, error_utils.MessageErr) {
-	return getPendingTweetsDomain()
+func (m *tweetDbMock) GetPending(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+	return getPendingTweetsDomain(limit)
 }
 func (m *tweetDbMock) GetLast() (*domain.Tweet, error_utils.MessageErr) {
 	return getLastTweetsDomain()
 }
+func (m *tweetDbMock) GetThread(threadId string) ([]domain.Tweet, error_utils.MessageErr) {
+	return getThreadDomain(threadId)
+}
+func (m *tweetDbMock) RequeueFailed(modified time.Time) (int64, error_utils.MessageErr) {
+	return requeueFailedDomain(modified)
+}
 func (m *tweetDbMock) Initialize() *sql.DB {
 	return nil
 }
 
+var (
+	createPollDomain func(poll *domain.Poll) (*domain.Poll, error_utils.MessageErr)
+	getPollDomain    func(tweetId int64) (*domain.Poll, error_utils.MessageErr)
+	deletePollDomain func(tweetId int64) error_utils.MessageErr
+)
+
+type pollDbMock struct {
+	domain.PollRepoInterface
+}
+
+func (m *pollDbMock) Create(poll *domain.Poll) (*domain.Poll, error_utils.MessageErr) {
+	return createPollDomain(poll)
+}
+func (m *pollDbMock) Get(tweetId int64) (*domain.Poll, error_utils.MessageErr) {
+	return getPollDomain(tweetId)
+}
+func (m *pollDbMock) Delete(tweetId int64) error_utils.MessageErr {
+	return deletePollDomain(tweetId)
+}
+
+func noPoll(tweetId int64) (*domain.Poll, error_utils.MessageErr) {
+	return nil, error_utils.NotFoundError("no record matching given id")
+}
+
+// countChanges counts the TweetsChanged notifications sent during the test
+func countChanges(t *testing.T) *int {
+	changes := 0
+	TweetsChanged = func() { changes++ }
+	t.Cleanup(func() { TweetsChanged = func() {} })
+	return &changes
+}
+
 const layout = "2021-07-12 10:55:50 +0000"
 
 func TestTweetService_Create(t *testing.T) {
@@ -58,6 +100,7 @@ func TestTweetService_Create(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		domain.TweetRepo = &tweetDbMock{}
+		changes := countChanges(t)
 
 		const message = "the message"
 
@@ -78,6 +121,7 @@ func TestTweetService_Create(t *testing.T) {
 		msg, err := TweetService.Create(request)
 
 		assert.Nil(t, err)
+		assert.Equal(t, 1, *changes)
 		assert.NotNil(t, msg)
 		assert.EqualValues(t, recordId, msg.Id)
 		assert.EqualValues(t, message, msg.Message)
@@ -113,6 +157,223 @@ func TestTweetService_Create(t *testing.T) {
 		assert.EqualValues(t, "Body cannot be empty", err.Message())
 	})
 
+	t.Run("Unknown account", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.AccountRepo = &accountDbMock{}
+
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no record matching given id")
+		}
+
+		request := &domain.Tweet{
+			Message:   "message",
+			PostTime:  postTime,
+			AccountId: 7,
+		}
+		msg, err := TweetService.Create(request)
+
+		assert.Nil(t, msg)
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.EqualValues(t, "Account 7 does not exist", err.Message())
+	})
+
+	t.Run("Cross-posted", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.AccountRepo = &accountDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return &domain.Account{Id: id}, nil
+		}
+		createTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			msg.Id = recordId
+			return msg, nil
+		}
+		createDestinationDomain = func(destination *domain.Destination) (*domain.Destination, error_utils.MessageErr) {
+			destination.Id = destination.AccountId + 10
+			return destination, nil
+		}
+
+		request := &domain.Tweet{
+			Message:      "message",
+			PostTime:     postTime,
+			Destinations: []domain.Destination{{}, {AccountId: 2, Status: domain.Posted}},
+		}
+		msg, err := TweetService.Create(request)
+
+		assert.Nil(t, err)
+		assert.Len(t, msg.Destinations, 2)
+		assert.EqualValues(t, recordId, msg.Destinations[1].TweetId)
+		assert.EqualValues(t, 12, msg.Destinations[1].Id)
+		assert.EqualValues(t, domain.Pending, msg.Destinations[1].Status)
+	})
+
+	t.Run("Destination failed", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+
+		var deleted int64
+		createTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			msg.Id = recordId
+			return msg, nil
+		}
+		createDestinationDomain = func(destination *domain.Destination) (*domain.Destination, error_utils.MessageErr) {
+			return nil, error_utils.InternalServerError("error when trying to save data")
+		}
+		deleteTweetDomain = func(messageId int64) error_utils.MessageErr {
+			deleted = messageId
+			return nil
+		}
+
+		request := &domain.Tweet{Message: "message", PostTime: postTime, Destinations: []domain.Destination{{}}}
+		msg, err := TweetService.Create(request)
+
+		assert.Nil(t, msg)
+		assert.EqualValues(t, http.StatusInternalServerError, err.Status())
+		assert.EqualValues(t, recordId, deleted)
+	})
+
+	t.Run("With a poll", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.PollRepo = &pollDbMock{}
+
+		createTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			msg.Id = recordId
+			return msg, nil
+		}
+		createPollDomain = func(poll *domain.Poll) (*domain.Poll, error_utils.MessageErr) {
+			poll.Id = 3
+			return poll, nil
+		}
+
+		request := &domain.Tweet{Message: "Tabs or spaces?", PostTime: postTime, Poll: &domain.Poll{Options: []string{" Tabs", "Spaces "}, DurationMinutes: 60}}
+		msg, err := TweetService.Create(request)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 3, msg.Poll.Id)
+		assert.EqualValues(t, recordId, msg.Poll.TweetId)
+		assert.Equal(t, []string{"Tabs", "Spaces"}, msg.Poll.Options)
+		assert.Equal(t, 60, msg.Poll.DurationMinutes)
+	})
+
+	t.Run("Poll failed", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.PollRepo = &pollDbMock{}
+
+		var deleted int64
+		createTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			msg.Id = recordId
+			return msg, nil
+		}
+		createPollDomain = func(poll *domain.Poll) (*domain.Poll, error_utils.MessageErr) {
+			return nil, error_utils.InternalServerError("error when trying to save data")
+		}
+		deleteTweetDomain = func(messageId int64) error_utils.MessageErr {
+			deleted = messageId
+			return nil
+		}
+
+		request := &domain.Tweet{Message: "Tabs or spaces?", PostTime: postTime, Poll: &domain.Poll{Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}}
+		msg, err := TweetService.Create(request)
+
+		assert.Nil(t, msg)
+		assert.EqualValues(t, http.StatusInternalServerError, err.Status())
+		assert.EqualValues(t, recordId, deleted)
+	})
+
+	t.Run("Retweets on networks that cannot post them", func(t *testing.T) {
+		t.Run("Account", func(t *testing.T) {
+			domain.TweetRepo = &tweetDbMock{}
+			domain.AccountRepo = &accountDbMock{}
+
+			var created bool
+			getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+				return &domain.Account{Id: id, Network: domain.MastodonNetwork}, nil
+			}
+			createTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+				created = true
+				return msg, nil
+			}
+
+			msg, err := TweetService.Create(&domain.Tweet{PostTime: postTime, Kind: domain.Retweet, Target: "1436255364069150720", AccountId: 2})
+
+			assert.Nil(t, msg)
+			assert.False(t, created)
+			assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+			assert.Equal(t, "Retweets and quote tweets cannot be posted to mastodon accounts", err.Message())
+		})
+
+		t.Run("Default account", func(t *testing.T) {
+			domain.TweetRepo = &tweetDbMock{}
+			_ = os.Setenv("PUBLISHER", "bluesky")
+			defer os.Unsetenv("PUBLISHER")
+
+			msg, err := TweetService.Create(&domain.Tweet{Message: "so true", PostTime: postTime, Kind: domain.Quote, Target: "1436255364069150720"})
+
+			assert.Nil(t, msg)
+			assert.Equal(t, "Retweets and quote tweets cannot be posted to bluesky accounts", err.Message())
+		})
+	})
+
+	t.Run("Messages too long for Bluesky", func(t *testing.T) {
+		// three links count as 71 characters on Twitter but 362 graphemes on Bluesky
+		link := "https://example.com/" + strings.Repeat("a", 100)
+		message := strings.Join([]string{link, link, link}, " ")
+
+		setup := func() *bool {
+			var created bool
+			domain.TweetRepo = &tweetDbMock{}
+			domain.AccountRepo = &accountDbMock{}
+			domain.DestinationRepo = &destinationDbMock{}
+			getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+				if id == 3 {
+					return &domain.Account{Id: id, Network: domain.BlueskyNetwork}, nil
+				}
+				return &domain.Account{Id: id, Network: domain.TwitterNetwork}, nil
+			}
+			createTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+				created = true
+				msg.Id = recordId
+				return msg, nil
+			}
+			createDestinationDomain = func(destination *domain.Destination) (*domain.Destination, error_utils.MessageErr) {
+				return destination, nil
+			}
+			return &created
+		}
+
+		t.Run("Account", func(t *testing.T) {
+			created := setup()
+
+			msg, err := TweetService.Create(&domain.Tweet{Message: message, PostTime: postTime, AccountId: 3})
+
+			assert.Nil(t, msg)
+			assert.False(t, *created)
+			assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+			assert.Equal(t, "Message is 362 characters long, the limit on Bluesky is 300", err.Message())
+		})
+
+		t.Run("Destination", func(t *testing.T) {
+			created := setup()
+
+			msg, err := TweetService.Create(&domain.Tweet{Message: message, PostTime: postTime, Destinations: []domain.Destination{{AccountId: 2}, {AccountId: 3}}})
+
+			assert.Nil(t, msg)
+			assert.False(t, *created)
+			assert.Equal(t, "Message is 362 characters long, the limit on Bluesky is 300", err.Message())
+		})
+
+		t.Run("Twitter account", func(t *testing.T) {
+			created := setup()
+
+			msg, err := TweetService.Create(&domain.Tweet{Message: message, PostTime: postTime, AccountId: 2})
+
+			assert.Nil(t, err)
+			assert.NotNil(t, msg)
+			assert.True(t, *created)
+		})
+	})
+
 	t.Run("Create failed", func(t *testing.T) {
 		domain.TweetRepo = &tweetDbMock{}
 
@@ -144,6 +405,10 @@ func TestTweetService_Get(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		domain.TweetRepo = &tweetDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		listDestinationsDomain = noDestinations
+		getPollDomain = noPoll
 
 		message = "the message"
 
@@ -189,6 +454,8 @@ func TestTweetService_GetAll(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		domain.TweetRepo = &tweetDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
 
 		message = "the message"
 
@@ -253,6 +520,9 @@ func TestTweetService_Update(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		domain.TweetRepo = &tweetDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		changes := countChanges(t)
 
 		const message = "the message"
 
@@ -282,6 +552,7 @@ func TestTweetService_Update(t *testing.T) {
 		msg, err := TweetService.Update(request)
 
 		assert.Nil(t, err)
+		assert.Equal(t, 1, *changes)
 		assert.NotNil(t, msg)
 		assert.EqualValues(t, recordId, msg.Id)
 		assert.EqualValues(t, message, msg.Message)
@@ -289,6 +560,403 @@ func TestTweetService_Update(t *testing.T) {
 		assert.EqualValues(t, tm, msg.CreatedAt)
 	})
 
+	t.Run("Schedules the deletion of a posted tweet", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		postedAt := postTime.Add(time.Minute)
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Message: "the message", PostTime: postTime, Status: domain.Posted, RemoteId: "100", PostedAt: &postedAt}, nil
+		}
+
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "the message", PostTime: postTime, Status: domain.Posted, DeleteAfter: "2h"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "2h", msg.DeleteAfter)
+		assert.Equal(t, postedAt.Add(2*time.Hour), *msg.DeleteAt)
+	})
+
+	t.Run("Tweet being posted", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Message: "the message", PostTime: postTime, Status: domain.Posting, ClaimedBy: "web.1-3f2a9c1e"}, nil
+		}
+
+		msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "changed", PostTime: postTime, Status: domain.Pending})
+
+		assert.Nil(t, msg)
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.Equal(t, "A tweet that is being posted cannot be changed", err.Message())
+	})
+
+	t.Run("Cannot move a tweet to Posting", func(t *testing.T) {
+		msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "the message", PostTime: postTime, Status: domain.Posting})
+
+		assert.Nil(t, msg)
+		assert.Equal(t, "Only the scheduler can move a tweet to Posting", err.Message())
+	})
+
+	t.Run("Moves the tweet to another account", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		domain.AccountRepo = &accountDbMock{}
+		getPollDomain = noPoll
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Message: "the message", PostTime: postTime, Status: domain.Pending, AccountId: 1}, nil
+		}
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return &domain.Account{Id: id}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "the message", PostTime: postTime, Status: domain.Pending, AccountId: 2})
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 2, msg.AccountId)
+	})
+
+	t.Run("Leaving the account out keeps it", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Message: "the message", PostTime: postTime, Status: domain.Pending, AccountId: 1}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "changed", PostTime: postTime, Status: domain.Pending})
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 1, msg.AccountId)
+	})
+
+	t.Run("Account cannot be changed", func(t *testing.T) {
+		postedAt := postTime.Add(time.Minute)
+		cases := []struct {
+			name    string
+			current domain.Tweet
+			message string
+		}{
+			{"Posted", domain.Tweet{Status: domain.Posted, RemoteId: "100", PostedAt: &postedAt}, "The account of a posted tweet cannot be changed"},
+			{"Thread part", domain.Tweet{Status: domain.Pending, ThreadId: "thread", ThreadPosition: 1}, "The account of a thread part cannot be changed"},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				domain.TweetRepo = &tweetDbMock{}
+
+				getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+					current := tc.current
+					current.Id, current.Message, current.PostTime, current.AccountId = recordId, "the message", postTime, 1
+					return &current, nil
+				}
+
+				msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "the message", PostTime: postTime, Status: tc.current.Status, AccountId: 2})
+
+				assert.Nil(t, msg)
+				assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+				assert.Equal(t, tc.message, err.Message())
+			})
+		}
+	})
+
+	t.Run("Account does not exist", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.AccountRepo = &accountDbMock{}
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Message: "the message", PostTime: postTime, Status: domain.Pending}, nil
+		}
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no record matching given id")
+		}
+
+		msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "the message", PostTime: postTime, Status: domain.Pending, AccountId: 7})
+
+		assert.Nil(t, msg)
+		assert.Equal(t, "Account 7 does not exist", err.Message())
+	})
+
+	t.Run("Replaces the poll", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		listMediaDomain = func(id int64) ([]domain.Media, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no records found")
+		}
+
+		var deleted int64
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Message: "Tabs or spaces?", PostTime: postTime}, nil
+		}
+		getPollDomain = func(tweetId int64) (*domain.Poll, error_utils.MessageErr) {
+			return &domain.Poll{Id: 1, TweetId: tweetId, Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+		deletePollDomain = func(tweetId int64) error_utils.MessageErr {
+			deleted = tweetId
+			return nil
+		}
+		createPollDomain = func(poll *domain.Poll) (*domain.Poll, error_utils.MessageErr) {
+			return poll, nil
+		}
+
+		msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "Tabs or spaces?", PostTime: postTime, Poll: &domain.Poll{Options: []string{"Tabs", "Spaces", "Both"}, DurationMinutes: 60}})
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, recordId, deleted)
+		assert.Equal(t, []string{"Tabs", "Spaces", "Both"}, msg.Poll.Options)
+	})
+
+	t.Run("Turns the tweet into a quote", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = func(id int64) ([]domain.Media, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no records found")
+		}
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Message: "the message", PostTime: postTime, Status: domain.Pending}, nil
+		}
+		listDestinationsDomain = func(tweetId int64) ([]domain.Destination, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no records found")
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "the message", PostTime: postTime, Status: domain.Pending, Kind: domain.Quote, Target: "1436255364069150720"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.Quote, msg.Kind)
+		assert.Equal(t, "1436255364069150720", msg.Target)
+	})
+
+	t.Run("Quote on a Mastodon account", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		domain.AccountRepo = &accountDbMock{}
+		getPollDomain = noPoll
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Message: "the message", PostTime: postTime, Status: domain.Pending, AccountId: 2}, nil
+		}
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return &domain.Account{Id: id, Network: domain.MastodonNetwork}, nil
+		}
+
+		msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "the message", PostTime: postTime, Status: domain.Pending, Kind: domain.Quote, Target: "1436255364069150720"})
+
+		assert.Nil(t, msg)
+		assert.Equal(t, "Retweets and quote tweets cannot be posted to mastodon accounts", err.Message())
+	})
+
+	t.Run("Message too long for a Bluesky destination", func(t *testing.T) {
+		link := "https://example.com/" + strings.Repeat("a", 100)
+		domain.TweetRepo = &tweetDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		domain.AccountRepo = &accountDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+		getPollDomain = noPoll
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Message: "the message", PostTime: postTime, Status: domain.Pending}, nil
+		}
+		listDestinationsDomain = func(tweetId int64) ([]domain.Destination, error_utils.MessageErr) {
+			return []domain.Destination{{Id: 1, TweetId: tweetId, AccountId: 3}}, nil
+		}
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return &domain.Account{Id: id, Network: domain.BlueskyNetwork}, nil
+		}
+
+		msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: strings.Join([]string{link, link, link}, " "), PostTime: postTime, Status: domain.Pending})
+
+		assert.Nil(t, msg)
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.Equal(t, "Message is 362 characters long, the limit on Bluesky is 300", err.Message())
+	})
+
+	t.Run("Kind is checked against the stored tweet", func(t *testing.T) {
+		cases := []struct {
+			name         string
+			current      domain.Tweet
+			poll         *domain.Poll
+			media        []domain.Media
+			destinations []domain.Destination
+			message      string
+		}{
+			{"Thread part", domain.Tweet{ThreadId: "thread", ThreadPosition: 1}, nil, nil, nil, "Retweets and quote tweets cannot be part of a thread"},
+			{"Poll", domain.Tweet{}, &domain.Poll{Id: 1, Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}, nil, nil, "Retweets and quote tweets cannot have a poll"},
+			{"Media", domain.Tweet{}, nil, []domain.Media{{Id: 1, MimeType: "image/png"}}, nil, "Retweets and quote tweets cannot have media"},
+			{"Destinations", domain.Tweet{}, nil, nil, []domain.Destination{{Id: 1, AccountId: 2}}, "Retweets and quote tweets cannot have destinations"},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				domain.TweetRepo = &tweetDbMock{}
+				domain.PollRepo = &pollDbMock{}
+				domain.MediaRepo = &mediaDbMock{}
+				domain.DestinationRepo = &destinationDbMock{}
+
+				listMediaDomain = func(id int64) ([]domain.Media, error_utils.MessageErr) {
+					if tc.media == nil {
+						return nil, error_utils.NotFoundError("no records found")
+					}
+					return tc.media, nil
+				}
+				getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+					current := tc.current
+					current.Id, current.Message, current.PostTime, current.Status = recordId, "the message", postTime, domain.Pending
+					return &current, nil
+				}
+				getPollDomain = func(tweetId int64) (*domain.Poll, error_utils.MessageErr) {
+					if tc.poll == nil {
+						return nil, error_utils.NotFoundError("no records found")
+					}
+					return tc.poll, nil
+				}
+				listDestinationsDomain = func(tweetId int64) ([]domain.Destination, error_utils.MessageErr) {
+					if tc.destinations == nil {
+						return nil, error_utils.NotFoundError("no records found")
+					}
+					return tc.destinations, nil
+				}
+
+				msg, err := TweetService.Update(&domain.Tweet{Id: recordId, PostTime: postTime, Status: domain.Pending, Kind: domain.Retweet, Target: "1436255364069150720"})
+
+				assert.Nil(t, msg)
+				assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+				assert.Equal(t, tc.message, err.Message())
+			})
+		}
+	})
+
+	t.Run("Keeps the poll when it is left out", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.PollRepo = &pollDbMock{}
+
+		var deleted bool
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Message: "Tabs or spaces?", PostTime: postTime}, nil
+		}
+		getPollDomain = func(tweetId int64) (*domain.Poll, error_utils.MessageErr) {
+			return &domain.Poll{Id: 1, TweetId: tweetId, Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+		deletePollDomain = func(tweetId int64) error_utils.MessageErr {
+			deleted = true
+			return nil
+		}
+
+		msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "Tabs or spaces, really?", PostTime: postTime})
+
+		assert.Nil(t, err)
+		assert.False(t, deleted)
+		assert.Equal(t, []string{"Tabs", "Spaces"}, msg.Poll.Options)
+	})
+
+	t.Run("Removes the poll", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.PollRepo = &pollDbMock{}
+
+		var deleted int64
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Message: "Tabs or spaces?", PostTime: postTime}, nil
+		}
+		getPollDomain = func(tweetId int64) (*domain.Poll, error_utils.MessageErr) {
+			return &domain.Poll{Id: 1, TweetId: tweetId, Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+		deletePollDomain = func(tweetId int64) error_utils.MessageErr {
+			deleted = tweetId
+			return nil
+		}
+
+		msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "Tabs or spaces?", PostTime: postTime, Poll: &domain.Poll{Options: []string{}}})
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, recordId, deleted)
+		assert.Nil(t, msg.Poll)
+	})
+
+	t.Run("Poll of a posted tweet", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		postedAt := postTime.Add(time.Minute)
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Message: "Tabs or spaces?", PostTime: postTime, Status: domain.Posted, RemoteId: "100", PostedAt: &postedAt}, nil
+		}
+		getPollDomain = func(tweetId int64) (*domain.Poll, error_utils.MessageErr) {
+			return &domain.Poll{Id: 1, TweetId: tweetId, Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		t.Run("Cannot be changed", func(t *testing.T) {
+			msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "Tabs or spaces?", PostTime: postTime, Status: domain.Posted, Poll: &domain.Poll{Options: []string{"Tabs", "Spaces", "Both"}, DurationMinutes: 60}})
+
+			assert.Nil(t, msg)
+			assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+			assert.Equal(t, "The poll of a posted tweet cannot be changed", err.Message())
+		})
+
+		t.Run("Cannot be removed", func(t *testing.T) {
+			msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "Tabs or spaces?", PostTime: postTime, Status: domain.Posted, Poll: &domain.Poll{}})
+
+			assert.Nil(t, msg)
+			assert.Equal(t, "The poll of a posted tweet cannot be changed", err.Message())
+		})
+
+		t.Run("Sent back unchanged", func(t *testing.T) {
+			msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "Tabs or spaces?", PostTime: postTime, Status: domain.Posted, Poll: &domain.Poll{Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}})
+
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"Tabs", "Spaces"}, msg.Poll.Options)
+		})
+	})
+
+	t.Run("Poll on a tweet with media", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Message: "Tabs or spaces?", PostTime: postTime}, nil
+		}
+		listMediaDomain = func(id int64) ([]domain.Media, error_utils.MessageErr) {
+			return []domain.Media{{Id: 1, TweetId: id}}, nil
+		}
+
+		msg, err := TweetService.Update(&domain.Tweet{Id: recordId, Message: "Tabs or spaces?", PostTime: postTime, Poll: &domain.Poll{Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}})
+
+		assert.Nil(t, msg)
+		assert.EqualValues(t, "A tweet with media cannot have a poll", err.Message())
+	})
+
 	t.Run("Validation failed", func(t *testing.T) {
 		domain.TweetRepo = &tweetDbMock{}
 
@@ -392,6 +1060,9 @@ func TestTweetService_Delete(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		domain.TweetRepo = &tweetDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+		changes := countChanges(t)
 
 		message = "the message"
 
@@ -411,6 +1082,7 @@ func TestTweetService_Delete(t *testing.T) {
 		err := TweetService.Delete(recordId)
 
 		assert.Nil(t, err)
+		assert.Equal(t, 1, *changes)
 	})
 
 	t.Run("Not found", func(t *testing.T) {
@@ -427,6 +1099,7 @@ func TestTweetService_Delete(t *testing.T) {
 
 	t.Run("Unable to delete", func(t *testing.T) {
 		domain.TweetRepo = &tweetDbMock{}
+		changes := countChanges(t)
 
 		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
 			return &domain.Tweet{
@@ -446,6 +1119,7 @@ func TestTweetService_Delete(t *testing.T) {
 		assert.EqualValues(t, "error deleting message", err.Message())
 		assert.EqualValues(t, http.StatusInternalServerError, err.Status())
 		assert.EqualValues(t, "server_error", err.Error())
+		assert.Equal(t, 0, *changes)
 	})
 }
 
@@ -456,10 +1130,12 @@ func TestTweetService_GetPending(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		domain.TweetRepo = &tweetDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
 
 		message = "the message"
 
-		getPendingTweetsDomain = func() ([]domain.Tweet, error_utils.MessageErr) {
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
 			return []domain.Tweet{
 				{
 					Id:        01,
@@ -478,7 +1154,7 @@ func TestTweetService_GetPending(t *testing.T) {
 			}, nil
 		}
 
-		tweets, err := TweetService.GetPending()
+		tweets, err := TweetService.GetPending(1)
 
 		assert.Nil(t, err)
 		assert.NotNil(t, tweets)
@@ -499,11 +1175,11 @@ func TestTweetService_GetPending(t *testing.T) {
 	t.Run("Not Found", func(t *testing.T) {
 		domain.TweetRepo = &tweetDbMock{}
 
-		getPendingTweetsDomain = func() ([]domain.Tweet, error_utils.MessageErr) {
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
 			return nil, error_utils.NotFoundError("error getting messages")
 		}
 
-		msg, err := TweetService.GetPending()
+		msg, err := TweetService.GetPending(1)
 
 		assert.Nil(t, msg)
 		assert.NotNil(t, err)
@@ -550,3 +1226,225 @@ func TestTweetService_GetLast(t *testing.T) {
 		assert.EqualValues(t, "not_found", err.Error())
 	})
 }
+
+func TestTweetService_CreateThread(t *testing.T) {
+	postTime, _ := time.Parse(layout, "2021-07-12 10:55:50 +0000")
+
+	t.Run("Success", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+
+		var id int64
+		createTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			id++
+			msg.Id = id
+			return msg, nil
+		}
+
+		thread := &domain.Thread{UserId: "001", PostTime: postTime, Messages: []string{"first", "second"}}
+		tweets, err := TweetService.CreateThread(thread)
+
+		assert.Nil(t, err)
+		assert.Len(t, tweets, 2)
+		assert.NotEmpty(t, tweets[0].ThreadId)
+		assert.Equal(t, tweets[0].ThreadId, tweets[1].ThreadId)
+		assert.Equal(t, 0, tweets[0].ThreadPosition)
+		assert.Equal(t, 1, tweets[1].ThreadPosition)
+		assert.EqualValues(t, domain.Pending, tweets[1].Status)
+		assert.Equal(t, "second", tweets[1].Message)
+	})
+
+	t.Run("Validation failed", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+
+		tweets, err := TweetService.CreateThread(&domain.Thread{Messages: []string{"first"}})
+
+		assert.Nil(t, tweets)
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+	})
+
+	t.Run("Create failed removes created parts", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+
+		deleted := make([]int64, 0)
+		createTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			if msg.ThreadPosition == 1 {
+				return nil, error_utils.InternalServerError("error creating message")
+			}
+			msg.Id = 7
+			return msg, nil
+		}
+		deleteTweetDomain = func(messageId int64) error_utils.MessageErr {
+			deleted = append(deleted, messageId)
+			return nil
+		}
+
+		thread := &domain.Thread{UserId: "001", PostTime: postTime, Messages: []string{"first", "second"}}
+		tweets, err := TweetService.CreateThread(thread)
+
+		assert.Nil(t, tweets)
+		assert.EqualValues(t, "error creating message", err.Message())
+		assert.Equal(t, []int64{7}, deleted)
+	})
+}
+
+func TestTweetService_CreateSplit(t *testing.T) {
+	postTime, _ := time.Parse(layout, "2021-07-12 10:55:50 +0000")
+
+	t.Run("Short message is a single tweet", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+
+		createTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			msg.Id = 1
+			return msg, nil
+		}
+
+		tweets, err := TweetService.CreateSplit(&domain.Tweet{UserId: "001", Message: "the message", PostTime: postTime, Status: domain.Pending})
+
+		assert.Nil(t, err)
+		assert.Len(t, tweets, 1)
+		assert.Equal(t, "the message", tweets[0].Message)
+		assert.Empty(t, tweets[0].ThreadId)
+	})
+
+	t.Run("Long message becomes a thread", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+
+		var id int64
+		createTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			id++
+			msg.Id = id
+			return msg, nil
+		}
+
+		message := strings.Repeat("All work and no play makes a dull tweet. ", 10)
+		tweets, err := TweetService.CreateSplit(&domain.Tweet{UserId: "001", Message: message, PostTime: postTime, Status: domain.Scheduled, Visibility: "unlisted", DeleteAfter: "24h"})
+
+		assert.Nil(t, err)
+		assert.Len(t, tweets, 2)
+		assert.Equal(t, tweets[0].ThreadId, tweets[1].ThreadId)
+		assert.NotEmpty(t, tweets[0].ThreadId)
+		assert.True(t, strings.HasSuffix(tweets[0].Message, "dull tweet. 1/2"))
+		assert.True(t, strings.HasSuffix(tweets[1].Message, "dull tweet. 2/2"))
+		assert.EqualValues(t, domain.Scheduled, tweets[1].Status)
+		assert.Equal(t, "unlisted", tweets[1].Visibility)
+		assert.Equal(t, "24h", tweets[1].DeleteAfter)
+		assert.Equal(t, postTime.Local(), tweets[1].PostTime)
+	})
+
+	t.Run("Quotes are not split", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+
+		message := strings.Repeat("All work and no play makes a dull tweet. ", 10)
+		tweets, err := TweetService.CreateSplit(&domain.Tweet{Message: message, Status: domain.Pending, Kind: domain.Quote, Target: "1436255364069150720"})
+
+		assert.Nil(t, tweets)
+		assert.EqualValues(t, "Message is 409 characters long, the limit is 280", err.Message())
+	})
+
+	t.Run("Polls are not split", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+
+		message := strings.Repeat("All work and no play makes a dull tweet. ", 10)
+		tweets, err := TweetService.CreateSplit(&domain.Tweet{Message: message, Status: domain.Pending, Poll: &domain.Poll{Options: []string{"Yes", "No"}, DurationMinutes: 60}})
+
+		assert.Nil(t, tweets)
+		assert.EqualValues(t, "Message is 409 characters long, the limit is 280", err.Message())
+	})
+
+	t.Run("Too many parts", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+
+		message := strings.Repeat("word ", 1500)
+		tweets, err := TweetService.CreateSplit(&domain.Tweet{Message: message, Status: domain.Pending})
+
+		assert.Nil(t, tweets)
+		assert.EqualValues(t, "A thread cannot have more than 25 messages", err.Message())
+	})
+}
+
+func TestTweetService_Requeue(t *testing.T) {
+	const recordId int64 = 1
+
+	t.Run("Success", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+
+		next := time.Now()
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Status: domain.Failed, Attempts: 5, LastError: "twitter: 130 Over capacity", NextAttemptAt: &next}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		tw, err := TweetService.Requeue(recordId)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, domain.Pending, tw.Status)
+		assert.Equal(t, 0, tw.Attempts)
+		assert.Empty(t, tw.LastError)
+		assert.Nil(t, tw.NextAttemptAt)
+	})
+
+	t.Run("Only failed destinations", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+
+		var requeued []int64
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Status: domain.Failed, LastError: "destination 2: gone"}, nil
+		}
+		listDestinationsDomain = func(tweetId int64) ([]domain.Destination, error_utils.MessageErr) {
+			return []domain.Destination{{Id: 1, Status: domain.Posted}, {Id: 2, Status: domain.Failed, Attempts: 5, LastError: "gone"}}, nil
+		}
+		updateDestinationDomain = func(destination *domain.Destination) (*domain.Destination, error_utils.MessageErr) {
+			requeued = append(requeued, destination.Id)
+			return destination, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		tw, err := TweetService.Requeue(recordId)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{2}, requeued)
+		assert.EqualValues(t, domain.Posted, tw.Destinations[0].Status)
+		assert.EqualValues(t, domain.Pending, tw.Destinations[1].Status)
+		assert.Equal(t, 0, tw.Destinations[1].Attempts)
+	})
+
+	t.Run("Not failed", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+
+		getTweetDomain = func(messageId int64) (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{Id: recordId, Status: domain.Pending}, nil
+		}
+
+		tw, err := TweetService.Requeue(recordId)
+
+		assert.Nil(t, tw)
+		assert.EqualValues(t, http.StatusUnprocessableEntity, err.Status())
+		assert.EqualValues(t, "Only failed tweets can be re-queued", err.Message())
+	})
+}
+
+func TestTweetService_RequeueFailed(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+
+		requeueFailedDestinationDomain = func(modified time.Time) (int64, error_utils.MessageErr) {
+			return 3, nil
+		}
+		requeueFailedDomain = func(modified time.Time) (int64, error_utils.MessageErr) {
+			return 2, nil
+		}
+
+		count, err := TweetService.RequeueFailed()
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 2, count)
+	})
+}
@@ -0,0 +1,15 @@
+CREATE TABLE accounts
+(
+    Id                SERIAL PRIMARY KEY,
+    Name              VARCHAR(50),
+    Network           VARCHAR(20) NOT NULL DEFAULT 'twitter',
+    InstanceUrl       VARCHAR(300) NOT NULL DEFAULT '',
+    Handle            VARCHAR(253) NOT NULL DEFAULT '',
+    ApiVersion        VARCHAR(5) NOT NULL DEFAULT '',
+    ConsumerKey       TEXT,
+    ConsumerSecret    TEXT,
+    AccessToken       TEXT,
+    AccessTokenSecret TEXT,
+    CreatedAt         TIMESTAMP,
+    Modified          TIMESTAMP
+);
@@ -0,0 +1,7 @@
+CREATE TABLE connections
+(
+    RequestToken  VARCHAR(100) PRIMARY KEY,
+    RequestSecret TEXT,
+    Name          VARCHAR(50),
+    CreatedAt     TIMESTAMP
+);
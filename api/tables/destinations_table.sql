@@ -0,0 +1,15 @@
+CREATE TABLE destinations
+(
+    Id            SERIAL PRIMARY KEY,
+    TweetId       INTEGER NOT NULL REFERENCES tweets (Id) ON DELETE CASCADE,
+    AccountId     INTEGER REFERENCES accounts (Id),
+    Status        VARCHAR(10) NOT NULL DEFAULT 'Pending',
+    RemoteId      VARCHAR(300) NOT NULL DEFAULT '',
+    RemoteUrl     VARCHAR(300) NOT NULL DEFAULT '',
+    PostedAt      TIMESTAMP,
+    Attempts      INTEGER NOT NULL DEFAULT 0,
+    LastError     TEXT NOT NULL DEFAULT '',
+    NextAttemptAt TIMESTAMP,
+    CreatedAt     TIMESTAMP,
+    Modified      TIMESTAMP
+);
@@ -0,0 +1,10 @@
+CREATE TABLE media
+(
+    Id        SERIAL PRIMARY KEY,
+    TweetId   INTEGER REFERENCES tweets (Id) ON DELETE CASCADE,
+    MimeType  VARCHAR(50),
+    AltText   VARCHAR(1000),
+    Data      BYTEA,
+    CreatedAt TIMESTAMP,
+    Modified  TIMESTAMP
+);
@@ -0,0 +1,16 @@
+CREATE TABLE outbox
+(
+    Id                  SERIAL PRIMARY KEY,
+    AccountId           INTEGER NOT NULL DEFAULT 0,
+    Network             VARCHAR(20) NOT NULL DEFAULT '',
+    Message             TEXT NOT NULL,
+    Kind                VARCHAR(10) NOT NULL DEFAULT 'original',
+    Target              VARCHAR(30) NOT NULL DEFAULT '',
+    ReplyTo             VARCHAR(300) NOT NULL DEFAULT '',
+    MediaCount          INTEGER NOT NULL DEFAULT 0,
+    Visibility          VARCHAR(10) NOT NULL DEFAULT '',
+    ContentWarning      VARCHAR(300) NOT NULL DEFAULT '',
+    PollOptions         TEXT[],
+    PollDurationMinutes INTEGER NOT NULL DEFAULT 0,
+    CreatedAt           TIMESTAMP
+);
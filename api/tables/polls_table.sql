@@ -0,0 +1,8 @@
+CREATE TABLE polls
+(
+    Id              SERIAL PRIMARY KEY,
+    TweetId         INTEGER NOT NULL UNIQUE REFERENCES tweets (Id) ON DELETE CASCADE,
+    Options         TEXT[] NOT NULL,
+    DurationMinutes INTEGER NOT NULL,
+    CreatedAt       TIMESTAMP
+);
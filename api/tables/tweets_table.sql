@@ -2,9 +2,26 @@ CREATE TABLE tweets
 (
     Id       	SERIAL PRIMARY KEY,
     UserId  	VARCHAR(300),
-    Message   VARCHAR(300),
+    Message   TEXT,
     PostTime  TIMESTAMP,
     Status    VARCHAR(10) ,
     CreatedAt TIMESTAMP,
-    Modified  TIMESTAMP
+    Modified  TIMESTAMP,
+    ThreadId  VARCHAR(36) NOT NULL DEFAULT '',
+    ThreadPosition INTEGER NOT NULL DEFAULT 0,
+    RemoteId  VARCHAR(300) NOT NULL DEFAULT '',
+    RemoteUrl VARCHAR(300) NOT NULL DEFAULT '',
+    PostedAt  TIMESTAMP,
+    Attempts  INTEGER NOT NULL DEFAULT 0,
+    LastError TEXT NOT NULL DEFAULT '',
+    NextAttemptAt TIMESTAMP,
+    AccountId INTEGER REFERENCES accounts (Id),
+    Visibility VARCHAR(10) NOT NULL DEFAULT '',
+    ContentWarning VARCHAR(300) NOT NULL DEFAULT '',
+    Kind VARCHAR(10) NOT NULL DEFAULT 'original',
+    Target VARCHAR(30) NOT NULL DEFAULT '',
+    DeleteAfter VARCHAR(20) NOT NULL DEFAULT '',
+    DeleteAt TIMESTAMP,
+    ClaimedBy VARCHAR(100) NOT NULL DEFAULT '',
+    LeaseExpiresAt TIMESTAMP
 );
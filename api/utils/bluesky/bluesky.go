@@ -0,0 +1,229 @@
+package bluesky
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+)
+
+const (
+	// DefaultServiceURL is the PDS accounts hosted by Bluesky itself log in to
+	DefaultServiceURL = "https://bsky.social"
+	// MaxGraphemes is the longest post Bluesky accepts
+	MaxGraphemes = 300
+	// maxImages is the most images a single post can embed
+	maxImages = 4
+
+	postCollection = "app.bsky.feed.post"
+)
+
+// Publisher creates app.bsky.feed.post records as the account the app password belongs to
+type Publisher struct {
+	serviceURL string
+	identifier string
+	password   string
+}
+
+// NewPublisher posts with the default handle and app password from the environment
+func NewPublisher() publisher.Publisher {
+	return NewAccountPublisher(os.Getenv("BLUESKY_URL"), os.Getenv("BLUESKY_HANDLE"), os.Getenv("BLUESKY_APP_PASSWORD"))
+}
+
+// NewAccountPublisher posts to the given PDS with the handle and app password of a single account,
+// an empty service URL uses DefaultServiceURL
+func NewAccountPublisher(serviceURL string, handle string, appPassword string) publisher.Publisher {
+	if serviceURL == "" {
+		serviceURL = DefaultServiceURL
+	}
+	return &Publisher{serviceURL: strings.TrimRight(serviceURL, "/"), identifier: handle, password: appPassword}
+}
+
+type strongRef struct {
+	Uri string `json:"uri"`
+	Cid string `json:"cid"`
+}
+
+type replyRef struct {
+	Root   strongRef `json:"root"`
+	Parent strongRef `json:"parent"`
+}
+
+type image struct {
+	Alt   string          `json:"alt"`
+	Image json.RawMessage `json:"image"`
+}
+
+type imagesEmbed struct {
+	Type   string  `json:"$type"`
+	Images []image `json:"images"`
+}
+
+type postRecord struct {
+	Type      string       `json:"$type"`
+	Text      string       `json:"text"`
+	CreatedAt string       `json:"createdAt"`
+	Facets    []facet      `json:"facets,omitempty"`
+	Reply     *replyRef    `json:"reply,omitempty"`
+	Embed     *imagesEmbed `json:"embed,omitempty"`
+}
+
+type createRecordInput struct {
+	Repo       string     `json:"repo"`
+	Collection string     `json:"collection"`
+	Record     postRecord `json:"record"`
+}
+
+type deleteRecordInput struct {
+	Repo       string `json:"repo"`
+	Collection string `json:"collection"`
+	Rkey       string `json:"rkey"`
+}
+
+// Publish creates a post record, the status ID is the record's at:// URI which is also what replies refer to
+// https://docs.bsky.app/docs/advanced-guides/posts
+func (p *Publisher) Publish(post *publisher.Post) (*publisher.Status, error) {
+	if post.Repost != "" || post.Quote != "" {
+		return nil, &publisher.Error{Category: publisher.Permanent, Err: errors.New("bluesky: retweets and quote tweets can only be posted to Twitter")}
+	}
+	if post.Poll != nil {
+		return nil, &publisher.Error{Category: publisher.Permanent, Err: errors.New("bluesky: polls are not supported")}
+	}
+	if length := Graphemes(post.Message); length > MaxGraphemes {
+		return nil, &publisher.Error{Category: publisher.Permanent, Err: fmt.Errorf("bluesky: post is %d characters, the limit is %d", length, MaxGraphemes)}
+	}
+	if len(post.Media) > maxImages {
+		return nil, &publisher.Error{Category: publisher.Permanent, Err: fmt.Errorf("bluesky: a post can have at most %d images", maxImages)}
+	}
+
+	sess, err := p.session()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	record := postRecord{
+		Type:      postCollection,
+		Text:      post.Message,
+		CreatedAt: now.Format("2006-01-02T15:04:05.000Z"),
+		Facets:    detectFacets(post.Message, p.resolveHandle),
+	}
+
+	if post.ReplyTo != "" {
+		if record.Reply, err = p.replyTo(post.ReplyTo); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(post.Media) > 0 {
+		record.Embed = &imagesEmbed{Type: "app.bsky.embed.images"}
+
+		for _, m := range post.Media {
+			blob, uploadErr := p.uploadBlob(m)
+			if uploadErr != nil {
+				return nil, uploadErr
+			}
+			record.Embed.Images = append(record.Embed.Images, image{Alt: m.AltText, Image: blob})
+		}
+	}
+
+	var created strongRef
+	if err = p.procedure("com.atproto.repo.createRecord", createRecordInput{Repo: sess.Did, Collection: postCollection, Record: record}, &created); err != nil {
+		return nil, err
+	}
+
+	return &publisher.Status{Id: created.Uri, Url: postURL(sess.Handle, created.Uri), PostedAt: now}, nil
+}
+
+func (p *Publisher) Delete(id string) error {
+	repo, collection, rkey, err := parseURI(id)
+	if err != nil {
+		return &publisher.Error{Category: publisher.Permanent, Err: err}
+	}
+
+	return p.procedure("com.atproto.repo.deleteRecord", deleteRecordInput{Repo: repo, Collection: collection, Rkey: rkey}, nil)
+}
+
+// Verify checks the session is still accepted, logging in again when it has expired
+func (p *Publisher) Verify() error {
+	return p.query("com.atproto.server.getSession", nil, nil)
+}
+
+type recordOutput struct {
+	Uri   string `json:"uri"`
+	Cid   string `json:"cid"`
+	Value struct {
+		Reply *replyRef `json:"reply"`
+	} `json:"value"`
+}
+
+// replyTo builds the reply reference for a post, which needs the thread root as well as the parent
+func (p *Publisher) replyTo(parentURI string) (*replyRef, error) {
+	repo, collection, rkey, err := parseURI(parentURI)
+	if err != nil {
+		return nil, &publisher.Error{Category: publisher.Permanent, Err: err}
+	}
+
+	var parent recordOutput
+	params := url.Values{"repo": {repo}, "collection": {collection}, "rkey": {rkey}}
+	if err = p.query("com.atproto.repo.getRecord", params, &parent); err != nil {
+		return nil, err
+	}
+
+	ref := &replyRef{Root: strongRef{Uri: parent.Uri, Cid: parent.Cid}, Parent: strongRef{Uri: parent.Uri, Cid: parent.Cid}}
+	if parent.Value.Reply != nil {
+		ref.Root = parent.Value.Reply.Root
+	}
+
+	return ref, nil
+}
+
+type resolveHandleOutput struct {
+	Did string `json:"did"`
+}
+
+func (p *Publisher) resolveHandle(handle string) (string, error) {
+	var resolved resolveHandleOutput
+	err := p.query("com.atproto.identity.resolveHandle", url.Values{"handle": {handle}}, &resolved)
+	return resolved.Did, err
+}
+
+type uploadBlobOutput struct {
+	Blob json.RawMessage `json:"blob"`
+}
+
+// uploadBlob uploads the image and returns the blob reference to embed, the blob is passed through as is
+// https://docs.bsky.app/docs/api/com-atproto-repo-upload-blob
+func (p *Publisher) uploadBlob(media publisher.Media) (json.RawMessage, error) {
+	var uploaded uploadBlobOutput
+	if err := p.call(http.MethodPost, "com.atproto.repo.uploadBlob", nil, media.MimeType, media.Data, &uploaded); err != nil {
+		return nil, err
+	}
+	return uploaded.Blob, nil
+}
+
+// parseURI splits an at://repo/collection/rkey record URI
+func parseURI(uri string) (repo string, collection string, rkey string, err error) {
+	parts := strings.Split(strings.TrimPrefix(uri, "at://"), "/")
+
+	if !strings.HasPrefix(uri, "at://") || len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", errors.New("bluesky: invalid record URI " + uri)
+	}
+
+	return parts[0], parts[1], parts[2], nil
+}
+
+// postURL is the bsky.app permalink of a post record
+func postURL(handle string, uri string) string {
+	_, _, rkey, err := parseURI(uri)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("https://bsky.app/profile/%s/post/%s", handle, rkey)
+}
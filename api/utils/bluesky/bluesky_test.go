@@ -0,0 +1,314 @@
+package bluesky
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testHandle   = "lattr.bsky.social"
+	testPassword = "app-password"
+	testDid      = "did:plc:lattr"
+)
+
+// fakePDS is a stand-in XRPC server that keeps the records it is sent
+type fakePDS struct {
+	mu        sync.Mutex
+	records   map[string]postRecord
+	deleted   []string
+	handles   map[string]string
+	blobs     [][]byte
+	logins    int
+	refreshes int
+	access    string
+	refresh   string
+	// expireAccess makes the current access token expire, expireRefresh does the same for the refresh token
+	expireAccess  bool
+	expireRefresh bool
+}
+
+func xrpcErr(w http.ResponseWriter, status int, name string, message string) {
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, `{"error":%q,"message":%q}`, name, message)
+}
+
+func (f *fakePDS) issue(w http.ResponseWriter) {
+	f.access = fmt.Sprintf("access-%d", f.logins+f.refreshes)
+	f.refresh = fmt.Sprintf("refresh-%d", f.logins+f.refreshes)
+	f.expireAccess = false
+	_ = json.NewEncoder(w).Encode(session{AccessJwt: f.access, RefreshJwt: f.refresh, Did: testDid, Handle: testHandle})
+}
+
+func newFakePDS(t *testing.T) (*fakePDS, *httptest.Server) {
+	sessionsMu.Lock()
+	sessions = map[string]*session{}
+	sessionsMu.Unlock()
+
+	f := &fakePDS{records: map[string]postRecord{}, handles: map[string]string{"alice.bsky.social": "did:plc:alice"}}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/xrpc/com.atproto.server.createSession", func(w http.ResponseWriter, r *http.Request) {
+		var creds credentials
+		_ = json.NewDecoder(r.Body).Decode(&creds)
+
+		if creds.Identifier != testHandle || creds.Password != testPassword {
+			xrpcErr(w, http.StatusUnauthorized, "AuthenticationRequired", "Invalid identifier or password")
+			return
+		}
+		f.logins++
+		f.issue(w)
+	})
+	mux.HandleFunc("/xrpc/com.atproto.server.refreshSession", func(w http.ResponseWriter, r *http.Request) {
+		if f.expireRefresh || r.Header.Get("Authorization") != "Bearer "+f.refresh {
+			xrpcErr(w, http.StatusBadRequest, "ExpiredToken", "Token has expired")
+			return
+		}
+		f.refreshes++
+		f.issue(w)
+	})
+	mux.HandleFunc("/xrpc/com.atproto.server.getSession", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"did":%q,"handle":%q}`, testDid, testHandle)
+	})
+	mux.HandleFunc("/xrpc/com.atproto.identity.resolveHandle", func(w http.ResponseWriter, r *http.Request) {
+		did, ok := f.handles[r.URL.Query().Get("handle")]
+		if !ok {
+			xrpcErr(w, http.StatusBadRequest, "InvalidRequest", "Unable to resolve handle")
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"did":%q}`, did)
+	})
+	mux.HandleFunc("/xrpc/com.atproto.repo.uploadBlob", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		f.blobs = append(f.blobs, data)
+		_, _ = fmt.Fprintf(w, `{"blob":{"$type":"blob","ref":{"$link":"bafkblob%d"},"mimeType":%q,"size":%d}}`, len(f.blobs), r.Header.Get("Content-Type"), len(data))
+	})
+	mux.HandleFunc("/xrpc/com.atproto.repo.createRecord", func(w http.ResponseWriter, r *http.Request) {
+		var input createRecordInput
+		_ = json.NewDecoder(r.Body).Decode(&input)
+
+		if Graphemes(input.Record.Text) > MaxGraphemes {
+			xrpcErr(w, http.StatusBadRequest, "InvalidRequest", "Record/text must not be longer than 300 graphemes")
+			return
+		}
+
+		rkey := fmt.Sprintf("3k%d", len(f.records)+1)
+		f.records[rkey] = input.Record
+		_, _ = fmt.Fprintf(w, `{"uri":"at://%s/%s/%s","cid":"bafy%s"}`, input.Repo, input.Collection, rkey, rkey)
+	})
+	mux.HandleFunc("/xrpc/com.atproto.repo.getRecord", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		record, ok := f.records[q.Get("rkey")]
+		if !ok {
+			xrpcErr(w, http.StatusBadRequest, "RecordNotFound", "Could not locate record")
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"uri":   fmt.Sprintf("at://%s/%s/%s", q.Get("repo"), q.Get("collection"), q.Get("rkey")),
+			"cid":   "bafy" + q.Get("rkey"),
+			"value": record,
+		})
+	})
+	mux.HandleFunc("/xrpc/com.atproto.repo.deleteRecord", func(w http.ResponseWriter, r *http.Request) {
+		var input deleteRecordInput
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		f.deleted = append(f.deleted, input.Rkey)
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if !strings.HasPrefix(r.URL.Path, "/xrpc/com.atproto.server.") || strings.HasSuffix(r.URL.Path, "getSession") {
+			if r.Header.Get("Authorization") != "Bearer "+f.access {
+				xrpcErr(w, http.StatusUnauthorized, "AuthenticationRequired", "Authentication Required")
+				return
+			}
+			if f.expireAccess {
+				xrpcErr(w, http.StatusBadRequest, "ExpiredToken", "Token has expired")
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return f, server
+}
+
+func TestPublish(t *testing.T) {
+	t.Run("Creates post record with facets", func(t *testing.T) {
+		pds, server := newFakePDS(t)
+		pub := NewAccountPublisher(server.URL+"/", testHandle, testPassword)
+
+		status, err := pub.Publish(&publisher.Post{Message: "Hi @alice.bsky.social and @nobody.example, see https://lattr.app/docs."})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "at://did:plc:lattr/app.bsky.feed.post/3k1", status.Id)
+		assert.Equal(t, "https://bsky.app/profile/lattr.bsky.social/post/3k1", status.Url)
+
+		record := pds.records["3k1"]
+		assert.Equal(t, "app.bsky.feed.post", record.Type)
+		assert.Nil(t, record.Reply)
+		assert.Equal(t, []facet{
+			{Index: byteSlice{ByteStart: 3, ByteEnd: 21}, Features: []feature{{Type: "app.bsky.richtext.facet#mention", Did: "did:plc:alice"}}},
+			{Index: byteSlice{ByteStart: 47, ByteEnd: 69}, Features: []feature{{Type: "app.bsky.richtext.facet#link", Uri: "https://lattr.app/docs"}}},
+		}, record.Facets)
+	})
+
+	t.Run("Reuses the session", func(t *testing.T) {
+		pds, server := newFakePDS(t)
+
+		_, _ = NewAccountPublisher(server.URL, testHandle, testPassword).Publish(&publisher.Post{Message: "first"})
+		_, err := NewAccountPublisher(server.URL, testHandle, testPassword).Publish(&publisher.Post{Message: "second"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, pds.logins)
+	})
+
+	t.Run("Refreshes an expired session", func(t *testing.T) {
+		pds, server := newFakePDS(t)
+		pub := NewAccountPublisher(server.URL, testHandle, testPassword)
+		_, _ = pub.Publish(&publisher.Post{Message: "first"})
+
+		pds.expireAccess = true
+		_, err := pub.Publish(&publisher.Post{Message: "second"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, pds.logins)
+		assert.Equal(t, 1, pds.refreshes)
+		assert.Len(t, pds.records, 2)
+	})
+
+	t.Run("Logs in again when the refresh token has expired", func(t *testing.T) {
+		pds, server := newFakePDS(t)
+		pub := NewAccountPublisher(server.URL, testHandle, testPassword)
+		_, _ = pub.Publish(&publisher.Post{Message: "first"})
+
+		pds.expireAccess = true
+		pds.expireRefresh = true
+		_, err := pub.Publish(&publisher.Post{Message: "second"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, pds.logins)
+		assert.Len(t, pds.records, 2)
+	})
+
+	t.Run("Replies to the thread root", func(t *testing.T) {
+		pds, server := newFakePDS(t)
+		pub := NewAccountPublisher(server.URL, testHandle, testPassword)
+
+		first, _ := pub.Publish(&publisher.Post{Message: "first"})
+		second, _ := pub.Publish(&publisher.Post{Message: "second", ReplyTo: first.Id})
+		_, err := pub.Publish(&publisher.Post{Message: "third", ReplyTo: second.Id})
+
+		assert.Nil(t, err)
+		assert.Equal(t, &replyRef{
+			Root:   strongRef{Uri: first.Id, Cid: "bafy3k1"},
+			Parent: strongRef{Uri: second.Id, Cid: "bafy3k2"},
+		}, pds.records["3k3"].Reply)
+	})
+
+	t.Run("Embeds images", func(t *testing.T) {
+		pds, server := newFakePDS(t)
+		pub := NewAccountPublisher(server.URL, testHandle, testPassword)
+
+		_, err := pub.Publish(&publisher.Post{Message: "look", Media: []publisher.Media{{MimeType: "image/png", AltText: "alt", Data: []byte("data")}}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, [][]byte{[]byte("data")}, pds.blobs)
+
+		embed := pds.records["3k1"].Embed
+		assert.Equal(t, "app.bsky.embed.images", embed.Type)
+		assert.Equal(t, "alt", embed.Images[0].Alt)
+		assert.JSONEq(t, `{"$type":"blob","ref":{"$link":"bafkblob1"},"mimeType":"image/png","size":4}`, string(embed.Images[0].Image))
+	})
+
+	t.Run("Too long is permanent", func(t *testing.T) {
+		pds, server := newFakePDS(t)
+		pub := NewAccountPublisher(server.URL, testHandle, testPassword)
+
+		status, err := pub.Publish(&publisher.Post{Message: strings.Repeat("é", MaxGraphemes+1)})
+
+		assert.Nil(t, status)
+		assert.Equal(t, publisher.Permanent, publisher.Classify(err))
+		assert.Equal(t, "bluesky: post is 301 characters, the limit is 300", err.Error())
+		assert.Equal(t, 0, pds.logins)
+	})
+
+	t.Run("Polls are permanent", func(t *testing.T) {
+		pds, server := newFakePDS(t)
+		pub := NewAccountPublisher(server.URL, testHandle, testPassword)
+
+		status, err := pub.Publish(&publisher.Post{Message: "Tabs or spaces?", Poll: &publisher.Poll{Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}})
+
+		assert.Nil(t, status)
+		assert.Equal(t, publisher.Permanent, publisher.Classify(err))
+		assert.Equal(t, "bluesky: polls are not supported", err.Error())
+		assert.Equal(t, 0, pds.logins)
+	})
+
+	t.Run("Retweets are permanent", func(t *testing.T) {
+		pds, server := newFakePDS(t)
+		pub := NewAccountPublisher(server.URL, testHandle, testPassword)
+
+		status, err := pub.Publish(&publisher.Post{Repost: "1436255364069150720"})
+
+		assert.Nil(t, status)
+		assert.Equal(t, publisher.Permanent, publisher.Classify(err))
+		assert.Equal(t, 0, pds.logins)
+	})
+
+	t.Run("Counts graphemes rather than bytes", func(t *testing.T) {
+		pds, server := newFakePDS(t)
+		pub := NewAccountPublisher(server.URL, testHandle, testPassword)
+
+		_, err := pub.Publish(&publisher.Post{Message: strings.Repeat("👍🏽", MaxGraphemes)})
+
+		assert.Nil(t, err)
+		assert.Len(t, pds.records, 1)
+	})
+
+	t.Run("Wrong app password is an auth error", func(t *testing.T) {
+		_, server := newFakePDS(t)
+		pub := NewAccountPublisher(server.URL, testHandle, "revoked")
+
+		_, err := pub.Publish(&publisher.Post{Message: "the message"})
+
+		assert.Equal(t, publisher.Auth, publisher.Classify(err))
+		assert.Equal(t, "bluesky: 401 AuthenticationRequired: Invalid identifier or password", err.Error())
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("Deletes record", func(t *testing.T) {
+		pds, server := newFakePDS(t)
+
+		err := NewAccountPublisher(server.URL, testHandle, testPassword).Delete("at://did:plc:lattr/app.bsky.feed.post/3k1")
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"3k1"}, pds.deleted)
+	})
+
+	t.Run("Invalid URI", func(t *testing.T) {
+		err := NewAccountPublisher("", testHandle, testPassword).Delete("1436255364069150720")
+
+		assert.Equal(t, publisher.Permanent, publisher.Classify(err))
+	})
+}
+
+func TestVerify(t *testing.T) {
+	_, server := newFakePDS(t)
+
+	assert.Nil(t, NewAccountPublisher(server.URL, testHandle, testPassword).Verify())
+	assert.Equal(t, publisher.Auth, publisher.Classify(NewAccountPublisher(server.URL, testHandle, "revoked").Verify()))
+}
@@ -0,0 +1,107 @@
+package bluesky
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+)
+
+// defaultRateLimitWindow is used when a 429 arrives without a reset header
+const defaultRateLimitWindow = 5 * time.Minute
+
+// authErrors are the XRPC error names that mean the session or app password can no longer be used
+var authErrors = map[string]bool{
+	"AuthenticationRequired": true,
+	"ExpiredToken":           true,
+	"InvalidToken":           true,
+	"AccountTakedown":        true,
+}
+
+// xrpcError is the error body returned by every XRPC endpoint
+type xrpcError struct {
+	StatusCode int    `json:"-"`
+	Name       string `json:"error"`
+	Message    string `json:"message"`
+}
+
+func (e *xrpcError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("bluesky: %d %s", e.StatusCode, e.Name)
+	}
+	return fmt.Sprintf("bluesky: %d %s: %s", e.StatusCode, e.Name, e.Message)
+}
+
+// classifyResponse maps a failed response to its publisher category, the error name takes
+// precedence over the HTTP status because expired tokens are reported as 400s
+func classifyResponse(resp *http.Response, body []byte) error {
+	xe := &xrpcError{StatusCode: resp.StatusCode}
+
+	if err := json.Unmarshal(body, xe); err != nil || xe.Name == "" {
+		xe.Name = http.StatusText(resp.StatusCode)
+	}
+
+	classified := &publisher.Error{
+		Category:   categoryForStatus(resp.StatusCode),
+		StatusCode: resp.StatusCode,
+		RateLimit:  rateLimit(resp),
+		Err:        xe,
+	}
+
+	if authErrors[xe.Name] {
+		classified.Category = publisher.Auth
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		classified.RateLimit = exhaustedRateLimit(resp)
+	}
+
+	return classified
+}
+
+func categoryForStatus(status int) publisher.Category {
+	switch {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return publisher.Auth
+	case status == http.StatusTooManyRequests, status >= http.StatusInternalServerError:
+		return publisher.Transient
+	case status >= http.StatusBadRequest:
+		return publisher.Permanent
+	default:
+		return publisher.Transient
+	}
+}
+
+// rateLimit reads the ratelimit headers of a response, returning nil when they are missing
+// https://docs.bsky.app/docs/advanced-guides/rate-limits
+func rateLimit(resp *http.Response) *publisher.RateLimit {
+	if resp == nil {
+		return nil
+	}
+
+	limit, limitErr := strconv.Atoi(resp.Header.Get("ratelimit-limit"))
+	remaining, remainingErr := strconv.Atoi(resp.Header.Get("ratelimit-remaining"))
+	reset, resetErr := strconv.ParseInt(resp.Header.Get("ratelimit-reset"), 10, 64)
+
+	if limitErr != nil || remainingErr != nil || resetErr != nil {
+		return nil
+	}
+
+	return &publisher.RateLimit{Limit: limit, Remaining: remaining, ResetAt: time.Unix(reset, 0)}
+}
+
+// exhaustedRateLimit returns the window for a rate limited response, falling back to
+// a full window from now when the headers are missing
+func exhaustedRateLimit(resp *http.Response) *publisher.RateLimit {
+	window := rateLimit(resp)
+
+	if window == nil {
+		window = &publisher.RateLimit{ResetAt: time.Now().Add(defaultRateLimitWindow)}
+	}
+
+	window.Remaining = 0
+	return window
+}
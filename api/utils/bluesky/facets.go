@@ -0,0 +1,87 @@
+package bluesky
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Facets annotate byte ranges of the post text, without them links and mentions are shown as plain text
+// https://docs.bsky.app/docs/advanced-guides/post-richtext
+type facet struct {
+	Index    byteSlice `json:"index"`
+	Features []feature `json:"features"`
+}
+
+type byteSlice struct {
+	ByteStart int `json:"byteStart"`
+	ByteEnd   int `json:"byteEnd"`
+}
+
+type feature struct {
+	Type string `json:"$type"`
+	Uri  string `json:"uri,omitempty"`
+	Did  string `json:"did,omitempty"`
+}
+
+var (
+	linkPattern    = regexp.MustCompile(`https?://[^\s<>"]+`)
+	mentionPattern = regexp.MustCompile(`(?:^|[^\w@])(@(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)`)
+)
+
+// linkTrailers are stripped from the end of a detected link, they are almost always punctuation around it
+const linkTrailers = ".,;:!?)]}'\""
+
+// detectFacets finds the links and mentions in text, mentions are resolved to DIDs with resolve
+// and are left as plain text when the handle does not exist
+func detectFacets(text string, resolve func(handle string) (string, error)) []facet {
+	var facets []facet
+
+	for _, match := range linkPattern.FindAllStringIndex(text, -1) {
+		link := strings.TrimRight(text[match[0]:match[1]], linkTrailers)
+		if _, err := url.ParseRequestURI(link); err != nil {
+			continue
+		}
+
+		facets = append(facets, facet{
+			Index:    byteSlice{ByteStart: match[0], ByteEnd: match[0] + len(link)},
+			Features: []feature{{Type: "app.bsky.richtext.facet#link", Uri: link}},
+		})
+	}
+
+	links := facets
+
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[2], match[3]
+		if overlaps(links, start, end) {
+			continue
+		}
+
+		did, err := resolve(text[start+1 : end])
+		if err != nil || did == "" {
+			continue
+		}
+
+		facets = append(facets, facet{
+			Index:    byteSlice{ByteStart: start, ByteEnd: end},
+			Features: []feature{{Type: "app.bsky.richtext.facet#mention", Did: did}},
+		})
+	}
+
+	sort.Slice(facets, func(i, j int) bool {
+		return facets[i].Index.ByteStart < facets[j].Index.ByteStart
+	})
+
+	return facets
+}
+
+// overlaps reports whether the byte range falls inside one of the facets, such as a handle in a link path
+func overlaps(facets []facet, start int, end int) bool {
+	for _, f := range facets {
+		if start < f.Index.ByteEnd && end > f.Index.ByteStart {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package bluesky
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func resolveKnown(handle string) (string, error) {
+	if handle == "alice.bsky.social" {
+		return "did:plc:alice", nil
+	}
+	return "", errors.New("unable to resolve handle")
+}
+
+func TestDetectFacets(t *testing.T) {
+	t.Run("Uses byte offsets", func(t *testing.T) {
+		facets := detectFacets("✨ https://lattr.app ✨", resolveKnown)
+
+		assert.Equal(t, []facet{{Index: byteSlice{ByteStart: 4, ByteEnd: 21}, Features: []feature{{Type: "app.bsky.richtext.facet#link", Uri: "https://lattr.app"}}}}, facets)
+	})
+
+	t.Run("Strips trailing punctuation from links", func(t *testing.T) {
+		facets := detectFacets("(see https://lattr.app/a?b=c).", resolveKnown)
+
+		assert.Equal(t, "https://lattr.app/a?b=c", facets[0].Features[0].Uri)
+	})
+
+	t.Run("Ignores handles inside links and emails", func(t *testing.T) {
+		facets := detectFacets("https://bsky.app/profile/@alice.bsky.social mail me@alice.bsky.social", resolveKnown)
+
+		assert.Len(t, facets, 1)
+		assert.Equal(t, "app.bsky.richtext.facet#link", facets[0].Features[0].Type)
+	})
+
+	t.Run("Mentions at the start and end", func(t *testing.T) {
+		facets := detectFacets("@alice.bsky.social hi @alice.bsky.social.", resolveKnown)
+
+		assert.Equal(t, []facet{
+			{Index: byteSlice{ByteStart: 0, ByteEnd: 18}, Features: []feature{{Type: "app.bsky.richtext.facet#mention", Did: "did:plc:alice"}}},
+			{Index: byteSlice{ByteStart: 22, ByteEnd: 40}, Features: []feature{{Type: "app.bsky.richtext.facet#mention", Did: "did:plc:alice"}}},
+		}, facets)
+	})
+
+	t.Run("Unresolved handles stay plain text", func(t *testing.T) {
+		assert.Empty(t, detectFacets("hi @bob.example", resolveKnown))
+	})
+}
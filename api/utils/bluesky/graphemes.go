@@ -0,0 +1,123 @@
+package bluesky
+
+import (
+	"unicode"
+
+	"github.com/RemeJuan/lattr/utils/twittertext"
+)
+
+type graphemeClass int
+
+const (
+	otherClass graphemeClass = iota
+	crClass
+	lfClass
+	controlClass
+	extendClass
+	zwjClass
+	spacingMarkClass
+	regionalClass
+	pictographicClass
+	hangulL
+	hangulV
+	hangulT
+	hangulLV
+	hangulLVT
+)
+
+// Graphemes counts the user-perceived characters in text, which is how Bluesky measures post length.
+// It follows the extended grapheme cluster rules of UAX #29 for the sequences that appear in posts:
+// combining marks, Hangul syllables, flags, skin tone modifiers and ZWJ emoji sequences
+// https://unicode.org/reports/tr29/#Grapheme_Cluster_Boundary_Rules
+func Graphemes(text string) int {
+	var count int
+	var prev graphemeClass
+	var regionalRun int
+	// emoji is set while the cluster is an Extended_Pictographic followed by Extend* and optionally a ZWJ
+	var emoji bool
+
+	for i, r := range text {
+		class := classify(r)
+
+		if i == 0 || isBoundary(prev, class, regionalRun, emoji) {
+			count++
+			regionalRun = 0
+			emoji = false
+		}
+
+		switch {
+		case class == regionalClass:
+			regionalRun++
+		case class == pictographicClass:
+			emoji = true
+		case class != extendClass && class != zwjClass:
+			emoji = false
+		}
+
+		prev = class
+	}
+
+	return count
+}
+
+func isBoundary(prev, next graphemeClass, regionalRun int, emoji bool) bool {
+	switch {
+	case prev == crClass && next == lfClass: // GB3
+		return false
+	case prev == crClass, prev == lfClass, prev == controlClass: // GB4
+		return true
+	case next == crClass, next == lfClass, next == controlClass: // GB5
+		return true
+	case prev == hangulL && (next == hangulL || next == hangulV || next == hangulLV || next == hangulLVT): // GB6
+		return false
+	case (prev == hangulLV || prev == hangulV) && (next == hangulV || next == hangulT): // GB7
+		return false
+	case (prev == hangulLVT || prev == hangulT) && next == hangulT: // GB8
+		return false
+	case next == extendClass, next == zwjClass, next == spacingMarkClass: // GB9, GB9a
+		return false
+	case prev == zwjClass && next == pictographicClass && emoji: // GB11
+		return false
+	case prev == regionalClass && next == regionalClass: // GB12, GB13
+		return regionalRun%2 == 0
+	default: // GB999
+		return true
+	}
+}
+
+func classify(r rune) graphemeClass {
+	switch {
+	case r == '\r':
+		return crClass
+	case r == '\n':
+		return lfClass
+	case r == 0x200D:
+		return zwjClass
+	case r == 0x200C, r >= 0xE0020 && r <= 0xE007F, r >= 0x1F3FB && r <= 0x1F3FF:
+		// zero width non-joiner, emoji tag characters and skin tone modifiers
+		return extendClass
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return extendClass
+	case unicode.Is(unicode.Mc, r):
+		return spacingMarkClass
+	case unicode.In(r, unicode.Cc, unicode.Cf, unicode.Zl, unicode.Zp):
+		return controlClass
+	case r >= 0x1F1E6 && r <= 0x1F1FF:
+		return regionalClass
+	case r >= 0x1100 && r <= 0x115F, r >= 0xA960 && r <= 0xA97C:
+		return hangulL
+	case r >= 0x1160 && r <= 0x11A7, r >= 0xD7B0 && r <= 0xD7C6:
+		return hangulV
+	case r >= 0x11A8 && r <= 0x11FF, r >= 0xD7CB && r <= 0xD7FB:
+		return hangulT
+	case r >= 0xAC00 && r <= 0xD7A3:
+		if (r-0xAC00)%28 == 0 {
+			return hangulLV
+		}
+		return hangulLVT
+	case r == 0x00A9, r == 0x00AE, twittertext.IsPictographic(r):
+		return pictographicClass
+	default:
+		return otherClass
+	}
+}
@@ -0,0 +1,39 @@
+package bluesky
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphemes(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected int
+	}{
+		{"Empty", "", 0},
+		{"ASCII", "hello", 5},
+		{"CRLF", "a\r\nb", 3},
+		{"Combining accent", "e\u0301te\u0301", 3},
+		{"Precomposed accent", "\u00e9t\u00e9", 3},
+		{"Devanagari virama and vowel signs", "नमस्ते", 4},
+		{"Hangul syllables", "한국어", 3},
+		{"Hangul jamo", "\u1100\u1161\u11a8", 1},
+		{"Skin tone modifier", "👍🏽", 1},
+		{"ZWJ family", "\U0001f468\u200d\U0001f469\u200d\U0001f467", 1},
+		{"Variation selector", "\u2764\ufe0f", 1},
+		{"Flags", "🇿🇦🇬🇧", 2},
+		{"Odd regional indicator", "🇿🇦🇬", 2},
+		{"Keycap", "1\ufe0f\u20e3", 1},
+		{"Tag sequence", "🏴\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F", 1},
+		{"ZWJ without emoji", "a\u200db", 2},
+		{"CJK", "你好", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Graphemes(tt.text))
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package bluesky
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+)
+
+// httpClient is shared by every publisher, requests to the service should never hang the scheduler
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// session is an authenticated XRPC session, sessions are kept between posts because
+// createSession is heavily rate limited
+type session struct {
+	AccessJwt  string `json:"accessJwt"`
+	RefreshJwt string `json:"refreshJwt"`
+	Did        string `json:"did"`
+	Handle     string `json:"handle"`
+}
+
+var (
+	sessionsMu sync.Mutex
+	sessions   = map[string]*session{}
+)
+
+type credentials struct {
+	Identifier string `json:"identifier"`
+	Password   string `json:"password"`
+}
+
+// session returns the cached session for the account, logging in when there is none
+func (p *Publisher) session() (*session, error) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	if sess, ok := sessions[p.sessionKey()]; ok {
+		return sess, nil
+	}
+
+	return p.createSession()
+}
+
+// createSession logs in with the app password, callers must hold sessionsMu
+// https://docs.bsky.app/docs/api/com-atproto-server-create-session
+func (p *Publisher) createSession() (*session, error) {
+	payload, err := json.Marshal(credentials{Identifier: p.identifier, Password: p.password})
+	if err != nil {
+		return nil, err
+	}
+
+	var sess session
+	if err = p.xrpc(http.MethodPost, "com.atproto.server.createSession", nil, "application/json", payload, "", &sess); err != nil {
+		return nil, err
+	}
+
+	sessions[p.sessionKey()] = &sess
+	return &sess, nil
+}
+
+// refresh exchanges the refresh token for a new session, logging in again when the refresh token has expired too
+// https://docs.bsky.app/docs/api/com-atproto-server-refresh-session
+func (p *Publisher) refresh(expired *session) (*session, error) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	// another post may already have refreshed it
+	if sess, ok := sessions[p.sessionKey()]; ok && sess != expired {
+		return sess, nil
+	}
+
+	var sess session
+	err := p.xrpc(http.MethodPost, "com.atproto.server.refreshSession", nil, "", nil, expired.RefreshJwt, &sess)
+	if err != nil {
+		if publisher.Classify(err) != publisher.Auth {
+			return nil, err
+		}
+		return p.createSession()
+	}
+
+	sessions[p.sessionKey()] = &sess
+	return &sess, nil
+}
+
+// sessionKey includes the app password so a replaced password logs in again rather than reusing the old session
+func (p *Publisher) sessionKey() string {
+	return p.serviceURL + " " + p.identifier + " " + p.password
+}
+
+// query calls an XRPC query (GET) with the session's access token
+func (p *Publisher) query(nsid string, params url.Values, out interface{}) error {
+	return p.call(http.MethodGet, nsid, params, "", nil, out)
+}
+
+// procedure calls an XRPC procedure (POST) with the input encoded as JSON
+func (p *Publisher) procedure(nsid string, in interface{}, out interface{}) error {
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	return p.call(http.MethodPost, nsid, nil, "application/json", payload, out)
+}
+
+// call sends an authenticated request, refreshing the session once when the access token has expired
+func (p *Publisher) call(method string, nsid string, params url.Values, contentType string, body []byte, out interface{}) error {
+	sess, err := p.session()
+	if err != nil {
+		return err
+	}
+
+	err = p.xrpc(method, nsid, params, contentType, body, sess.AccessJwt, out)
+	if !tokenExpired(err) {
+		return err
+	}
+
+	if sess, err = p.refresh(sess); err != nil {
+		return err
+	}
+
+	return p.xrpc(method, nsid, params, contentType, body, sess.AccessJwt, out)
+}
+
+// xrpc sends a single request and decodes a successful JSON response into out when provided
+func (p *Publisher) xrpc(method string, nsid string, params url.Values, contentType string, body []byte, token string, out interface{}) error {
+	endpoint := p.serviceURL + "/xrpc/" + nsid
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return &publisher.Error{Category: publisher.Permanent, Err: err}
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return &publisher.Error{Category: publisher.Transient, Err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return &publisher.Error{Category: publisher.Transient, StatusCode: resp.StatusCode, Err: err}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return classifyResponse(resp, data)
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+func tokenExpired(err error) bool {
+	var xe *xrpcError
+	return errors.As(err, &xe) && xe.Name == "ExpiredToken"
+}
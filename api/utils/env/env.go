@@ -0,0 +1,18 @@
+// Package env reads typed settings from the environment
+package env
+
+import (
+	"os"
+	"strconv"
+)
+
+// Int reads a positive number from the environment, falling back when it is unset or invalid
+func Int(key string, fallback int) int {
+	val, err := strconv.Atoi(os.Getenv(key))
+
+	if err != nil || val <= 0 {
+		return fallback
+	}
+
+	return val
+}
@@ -0,0 +1,34 @@
+package env
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInt(t *testing.T) {
+	defer os.Unsetenv("LATTR_TEST_INT")
+
+	t.Run("Unset", func(t *testing.T) {
+		assert.Equal(t, 10, Int("LATTR_TEST_INT", 10))
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		_ = os.Setenv("LATTR_TEST_INT", "4")
+
+		assert.Equal(t, 4, Int("LATTR_TEST_INT", 10))
+	})
+
+	t.Run("Not positive", func(t *testing.T) {
+		_ = os.Setenv("LATTR_TEST_INT", "-1")
+
+		assert.Equal(t, 10, Int("LATTR_TEST_INT", 10))
+	})
+
+	t.Run("Not a number", func(t *testing.T) {
+		_ = os.Setenv("LATTR_TEST_INT", "ten")
+
+		assert.Equal(t, 10, Int("LATTR_TEST_INT", 10))
+	})
+}
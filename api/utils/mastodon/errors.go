@@ -0,0 +1,90 @@
+package mastodon
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+)
+
+// defaultRateLimitWindow is used when a 429 arrives without a reset header,
+// Mastodon counts its general API limit in 5 minute windows
+const defaultRateLimitWindow = 5 * time.Minute
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// classifyResponse maps a failed response to its publisher category, Mastodon does not return error codes
+// so the HTTP status decides how the scheduler reacts
+// https://docs.joinmastodon.org/entities/Error/
+func classifyResponse(resp *http.Response, body []byte) error {
+	var decoded apiError
+	message := http.StatusText(resp.StatusCode)
+
+	if err := json.Unmarshal(body, &decoded); err == nil && decoded.Error != "" {
+		message = decoded.Error
+	}
+
+	classified := &publisher.Error{
+		Category:   categoryForStatus(resp.StatusCode),
+		StatusCode: resp.StatusCode,
+		RateLimit:  rateLimit(resp),
+		Err:        errors.New("mastodon: " + strconv.Itoa(resp.StatusCode) + " " + message),
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		classified.RateLimit = exhaustedRateLimit(resp)
+	}
+
+	return classified
+}
+
+func categoryForStatus(status int) publisher.Category {
+	switch {
+	// 401 is an invalid or revoked token, 403 a token without the write scope or a suspended account
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return publisher.Auth
+	case status == http.StatusTooManyRequests, status >= http.StatusInternalServerError:
+		return publisher.Transient
+	// 404 and 410 are a removed reply target, 422 is rejected content such as an overlong status
+	case status >= http.StatusBadRequest:
+		return publisher.Permanent
+	default:
+		return publisher.Transient
+	}
+}
+
+// rateLimit reads the X-RateLimit headers of a response, returning nil when they are missing
+// https://docs.joinmastodon.org/api/rate-limits/
+func rateLimit(resp *http.Response) *publisher.RateLimit {
+	if resp == nil {
+		return nil
+	}
+
+	limit, limitErr := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	remaining, remainingErr := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	reset, resetErr := time.Parse(time.RFC3339, resp.Header.Get("X-RateLimit-Reset"))
+
+	if limitErr != nil || remainingErr != nil || resetErr != nil {
+		return nil
+	}
+
+	return &publisher.RateLimit{Limit: limit, Remaining: remaining, ResetAt: reset}
+}
+
+// exhaustedRateLimit returns the window for a rate limited response, falling back to
+// a full window from now when the headers are missing
+func exhaustedRateLimit(resp *http.Response) *publisher.RateLimit {
+	window := rateLimit(resp)
+
+	if window == nil {
+		window = &publisher.RateLimit{ResetAt: time.Now().Add(defaultRateLimitWindow)}
+	}
+
+	window.Remaining = 0
+	return window
+}
@@ -0,0 +1,78 @@
+package mastodon
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected publisher.Category
+		message  string
+	}{
+		{"Invalid token", http.StatusUnauthorized, `{"error":"The access token is invalid"}`, publisher.Auth, "mastodon: 401 The access token is invalid"},
+		{"Missing scope", http.StatusForbidden, `{"error":"This action is outside the authorized scopes"}`, publisher.Auth, "mastodon: 403 This action is outside the authorized scopes"},
+		{"Reply target removed", http.StatusNotFound, `{"error":"Record not found"}`, publisher.Permanent, "mastodon: 404 Record not found"},
+		{"Validation failed", http.StatusUnprocessableEntity, `{"error":"Validation failed: Text can't be blank"}`, publisher.Permanent, "mastodon: 422 Validation failed: Text can't be blank"},
+		{"Rate limited", http.StatusTooManyRequests, `{"error":"Too many requests"}`, publisher.Transient, "mastodon: 429 Too many requests"},
+		{"Server error without body", http.StatusBadGateway, "<html></html>", publisher.Transient, "mastodon: 502 Bad Gateway"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyResponse(&http.Response{StatusCode: tt.status}, []byte(tt.body))
+
+			assert.Equal(t, tt.expected, publisher.Classify(err))
+			assert.Equal(t, tt.message, err.Error())
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	headers := func(limit, remaining, reset string) *http.Response {
+		resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+		resp.Header.Set("X-RateLimit-Limit", limit)
+		resp.Header.Set("X-RateLimit-Remaining", remaining)
+		resp.Header.Set("X-RateLimit-Reset", reset)
+		return resp
+	}
+
+	t.Run("Reads headers", func(t *testing.T) {
+		window := rateLimit(headers("300", "12", "2022-09-09T11:00:00.000Z"))
+
+		assert.Equal(t, 300, window.Limit)
+		assert.Equal(t, 12, window.Remaining)
+		assert.True(t, window.ResetAt.Equal(time.Date(2022, 9, 9, 11, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("Missing headers", func(t *testing.T) {
+		assert.Nil(t, rateLimit(&http.Response{Header: http.Header{}}))
+		assert.Nil(t, rateLimit(nil))
+	})
+
+	t.Run("Rate limited error carries the window", func(t *testing.T) {
+		reset := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+		err := classifyResponse(headers("300", "0", reset), nil)
+
+		window, limited := publisher.RateLimited(err)
+
+		assert.True(t, limited)
+		assert.Equal(t, reset, window.ResetAt.UTC().Format(time.RFC3339))
+	})
+
+	t.Run("Rate limited without headers waits a full window", func(t *testing.T) {
+		err := classifyResponse(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}, nil)
+
+		window, limited := publisher.RateLimited(err)
+
+		assert.True(t, limited)
+		assert.WithinDuration(t, time.Now().Add(defaultRateLimitWindow), window.ResetAt, time.Second)
+	})
+}
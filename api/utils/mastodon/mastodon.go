@@ -0,0 +1,144 @@
+package mastodon
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+)
+
+// httpClient is shared by every publisher, requests to an instance should never hang the scheduler
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Publisher posts statuses to a Mastodon instance as the owner of the access token
+type Publisher struct {
+	instanceURL string
+	accessToken string
+}
+
+// NewPublisher posts with the default instance and access token from the environment
+func NewPublisher() publisher.Publisher {
+	return NewAccountPublisher(os.Getenv("MASTODON_URL"), os.Getenv("MASTODON_ACCESS_TOKEN"))
+}
+
+// NewAccountPublisher posts to the given instance with the access token of a single account
+func NewAccountPublisher(instanceURL string, accessToken string) publisher.Publisher {
+	return &Publisher{instanceURL: strings.TrimRight(instanceURL, "/"), accessToken: accessToken}
+}
+
+type statusParams struct {
+	Status      string   `json:"status"`
+	MediaIds    []string `json:"media_ids,omitempty"`
+	InReplyToId string   `json:"in_reply_to_id,omitempty"`
+	SpoilerText string   `json:"spoiler_text,omitempty"`
+	Visibility  string   `json:"visibility,omitempty"`
+	Poll        *poll    `json:"poll,omitempty"`
+}
+
+// poll closes expires_in seconds after the status is created
+type poll struct {
+	Options   []string `json:"options"`
+	ExpiresIn int      `json:"expires_in"`
+}
+
+type status struct {
+	Id        string    `json:"id"`
+	Url       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Publish creates a status, the content warning is sent as the spoiler text which also marks any media as sensitive
+// https://docs.joinmastodon.org/methods/statuses/#create
+func (p *Publisher) Publish(post *publisher.Post) (*publisher.Status, error) {
+	if post.Repost != "" || post.Quote != "" {
+		return nil, &publisher.Error{Category: publisher.Permanent, Err: errors.New("mastodon: retweets and quote tweets can only be posted to Twitter")}
+	}
+
+	params := statusParams{
+		Status:      post.Message,
+		InReplyToId: post.ReplyTo,
+		SpoilerText: post.ContentWarning,
+		Visibility:  post.Visibility,
+	}
+
+	if post.Poll != nil {
+		params.Poll = &poll{Options: post.Poll.Options, ExpiresIn: post.Poll.DurationMinutes * 60}
+	}
+
+	for _, m := range post.Media {
+		id, err := p.uploadMedia(m)
+		if err != nil {
+			return nil, err
+		}
+		params.MediaIds = append(params.MediaIds, id)
+	}
+
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var created status
+	resp, err := p.do(http.MethodPost, "/api/v1/statuses", "application/json", bytes.NewReader(payload), &created)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &publisher.Status{Id: created.Id, Url: created.Url, PostedAt: created.CreatedAt, RateLimit: rateLimit(resp)}
+	if result.PostedAt.IsZero() {
+		result.PostedAt = time.Now()
+	}
+
+	return result, nil
+}
+
+func (p *Publisher) Delete(id string) error {
+	_, err := p.do(http.MethodDelete, "/api/v1/statuses/"+id, "", nil, nil)
+	return err
+}
+
+func (p *Publisher) Verify() error {
+	_, err := p.do(http.MethodGet, "/api/v1/accounts/verify_credentials", "", nil, nil)
+	return err
+}
+
+// do sends an authenticated request to the instance and decodes a successful JSON response into out when provided
+func (p *Publisher) do(method string, path string, contentType string, body io.Reader, out interface{}) (*http.Response, error) {
+	req, err := http.NewRequest(method, p.instanceURL+path, body)
+	if err != nil {
+		return nil, &publisher.Error{Category: publisher.Permanent, Err: err}
+	}
+
+	req.Header.Set("Authorization", "Bearer "+p.accessToken)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, &publisher.Error{Category: publisher.Transient, Err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &publisher.Error{Category: publisher.Transient, StatusCode: resp.StatusCode, Err: err}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, classifyResponse(resp, data)
+	}
+
+	if out == nil || len(data) == 0 {
+		return resp, nil
+	}
+
+	return resp, json.Unmarshal(data, out)
+}
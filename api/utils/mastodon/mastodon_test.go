@@ -0,0 +1,244 @@
+package mastodon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/stretchr/testify/assert"
+)
+
+const testToken = "token"
+
+// fakeInstance is a minimal Mastodon instance that keeps the statuses and media it is sent
+type fakeInstance struct {
+	mu       sync.Mutex
+	statuses []statusParams
+	media    map[string][]byte
+	alt      map[string]string
+	deleted  []string
+	// processing is the number of polls an upload stays in processing for
+	processing int
+	polls      int
+}
+
+func newFakeInstance(t *testing.T) (*fakeInstance, *httptest.Server) {
+	f := &fakeInstance{media: map[string][]byte{}, alt: map[string]string{}}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/v1/statuses", func(w http.ResponseWriter, r *http.Request) {
+		var params statusParams
+		_ = json.NewDecoder(r.Body).Decode(&params)
+
+		if len([]rune(params.Status)) > 500 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"error":"Validation failed: Text character limit of 500 exceeded"}`))
+			return
+		}
+
+		f.mu.Lock()
+		f.statuses = append(f.statuses, params)
+		id := len(f.statuses)
+		f.mu.Unlock()
+
+		w.Header().Set("X-RateLimit-Limit", "300")
+		w.Header().Set("X-RateLimit-Remaining", "299")
+		w.Header().Set("X-RateLimit-Reset", "2022-09-09T11:00:00.000Z")
+		_, _ = fmt.Fprintf(w, `{"id":"%d","url":"https://mastodon.example/@lattr/%d","created_at":"2022-09-09T10:30:01.000Z"}`, id, id)
+	})
+	mux.HandleFunc("/api/v1/statuses/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/api/v1/statuses/")
+		if r.Method != http.MethodDelete || id == "404" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"Record not found"}`))
+			return
+		}
+
+		f.mu.Lock()
+		f.deleted = append(f.deleted, id)
+		f.mu.Unlock()
+		_, _ = w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/api/v2/media", func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		assert.Nil(t, err)
+		data, _ := ioutil.ReadAll(file)
+
+		f.mu.Lock()
+		id := fmt.Sprintf("m%d", len(f.media)+1)
+		f.media[id] = data
+		f.alt[id] = r.FormValue("description")
+		f.mu.Unlock()
+
+		if f.processing > 0 {
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = fmt.Fprintf(w, `{"id":"%s","url":null}`, id)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"id":"%s","url":"https://files.example/%s"}`, id, id)
+	})
+	mux.HandleFunc("/api/v1/media/", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		f.polls++
+		if f.polls <= f.processing {
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/api/v1/accounts/verify_credentials", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"1","username":"lattr"}`))
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"The access token is invalid"}`))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return f, server
+}
+
+func TestPublish(t *testing.T) {
+	sleep = func(time.Duration) {}
+
+	t.Run("Posts status with visibility and content warning", func(t *testing.T) {
+		instance, server := newFakeInstance(t)
+		pub := NewAccountPublisher(server.URL+"/", testToken)
+
+		status, err := pub.Publish(&publisher.Post{Message: "the message", ReplyTo: "7", Visibility: "unlisted", ContentWarning: "spoilers"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "1", status.Id)
+		assert.Equal(t, "https://mastodon.example/@lattr/1", status.Url)
+		assert.True(t, status.PostedAt.Equal(time.Date(2022, 9, 9, 10, 30, 1, 0, time.UTC)))
+		assert.Equal(t, 299, status.RateLimit.Remaining)
+		assert.Equal(t, []statusParams{{Status: "the message", InReplyToId: "7", SpoilerText: "spoilers", Visibility: "unlisted"}}, instance.statuses)
+	})
+
+	t.Run("Uploads media with description", func(t *testing.T) {
+		instance, server := newFakeInstance(t)
+		pub := NewAccountPublisher(server.URL, testToken)
+
+		_, err := pub.Publish(&publisher.Post{Message: "the message", Media: []publisher.Media{{MimeType: "image/png", AltText: "alt", Data: []byte("data")}}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"m1"}, instance.statuses[0].MediaIds)
+		assert.Equal(t, []byte("data"), instance.media["m1"])
+		assert.Equal(t, "alt", instance.alt["m1"])
+	})
+
+	t.Run("Waits for media processing", func(t *testing.T) {
+		instance, server := newFakeInstance(t)
+		instance.processing = 2
+		pub := NewAccountPublisher(server.URL, testToken)
+
+		_, err := pub.Publish(&publisher.Post{Message: "the message", Media: []publisher.Media{{MimeType: "video/mp4", Data: []byte("data")}}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 3, instance.polls)
+		assert.Equal(t, []string{"m1"}, instance.statuses[0].MediaIds)
+	})
+
+	t.Run("Media still processing is retried", func(t *testing.T) {
+		instance, server := newFakeInstance(t)
+		instance.processing = mediaPolls + 1
+		pub := NewAccountPublisher(server.URL, testToken)
+
+		status, err := pub.Publish(&publisher.Post{Message: "the message", Media: []publisher.Media{{MimeType: "video/mp4", Data: []byte("data")}}})
+
+		assert.Nil(t, status)
+		assert.Equal(t, publisher.Transient, publisher.Classify(err))
+		assert.Empty(t, instance.statuses)
+	})
+
+	t.Run("Rejected content is permanent", func(t *testing.T) {
+		_, server := newFakeInstance(t)
+		pub := NewAccountPublisher(server.URL, testToken)
+
+		status, err := pub.Publish(&publisher.Post{Message: strings.Repeat("a", 501)})
+
+		assert.Nil(t, status)
+		assert.Equal(t, publisher.Permanent, publisher.Classify(err))
+		assert.Equal(t, "mastodon: 422 Validation failed: Text character limit of 500 exceeded", err.Error())
+	})
+
+	t.Run("Posts a poll", func(t *testing.T) {
+		instance, server := newFakeInstance(t)
+		pub := NewAccountPublisher(server.URL, testToken)
+
+		_, err := pub.Publish(&publisher.Post{Message: "Tabs or spaces?", Poll: &publisher.Poll{Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, &poll{Options: []string{"Tabs", "Spaces"}, ExpiresIn: 3600}, instance.statuses[0].Poll)
+	})
+
+	t.Run("Quotes are permanent", func(t *testing.T) {
+		instance, server := newFakeInstance(t)
+		pub := NewAccountPublisher(server.URL, testToken)
+
+		status, err := pub.Publish(&publisher.Post{Message: "so true", Quote: "1436255364069150720"})
+
+		assert.Nil(t, status)
+		assert.Equal(t, publisher.Permanent, publisher.Classify(err))
+		assert.Equal(t, "mastodon: retweets and quote tweets can only be posted to Twitter", err.Error())
+		assert.Empty(t, instance.statuses)
+	})
+
+	t.Run("Invalid token is an auth error", func(t *testing.T) {
+		_, server := newFakeInstance(t)
+		pub := NewAccountPublisher(server.URL, "revoked")
+
+		_, err := pub.Publish(&publisher.Post{Message: "the message"})
+
+		assert.Equal(t, publisher.Auth, publisher.Classify(err))
+	})
+
+	t.Run("Unreachable instance is retried", func(t *testing.T) {
+		_, server := newFakeInstance(t)
+		server.Close()
+		pub := NewAccountPublisher(server.URL, testToken)
+
+		_, err := pub.Publish(&publisher.Post{Message: "the message"})
+
+		assert.Equal(t, publisher.Transient, publisher.Classify(err))
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("Deletes status", func(t *testing.T) {
+		instance, server := newFakeInstance(t)
+
+		err := NewAccountPublisher(server.URL, testToken).Delete("12")
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"12"}, instance.deleted)
+	})
+
+	t.Run("Missing status", func(t *testing.T) {
+		_, server := newFakeInstance(t)
+
+		err := NewAccountPublisher(server.URL, testToken).Delete("404")
+
+		assert.Equal(t, publisher.Permanent, publisher.Classify(err))
+	})
+}
+
+func TestVerify(t *testing.T) {
+	_, server := newFakeInstance(t)
+
+	assert.Nil(t, NewAccountPublisher(server.URL, testToken).Verify())
+	assert.Equal(t, publisher.Auth, publisher.Classify(NewAccountPublisher(server.URL, "revoked").Verify()))
+}
@@ -0,0 +1,83 @@
+package mastodon
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+)
+
+// mediaPolls and mediaPollInterval bound how long an upload may stay in processing before the post is retried
+const mediaPolls = 10
+
+var (
+	mediaPollInterval = time.Second
+	sleep             = time.Sleep
+)
+
+type attachment struct {
+	Id  string  `json:"id"`
+	Url *string `json:"url"`
+}
+
+// uploadMedia uploads a single item with its description and returns the attachment ID,
+// large files are processed asynchronously so it waits until the attachment is ready
+// https://docs.joinmastodon.org/methods/media/#v2
+func (p *Publisher) uploadMedia(media publisher.Media) (string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="file"; filename="media"`)
+	header.Set("Content-Type", media.MimeType)
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return "", err
+	}
+	if _, err = part.Write(media.Data); err != nil {
+		return "", err
+	}
+	if media.AltText != "" {
+		if err = writer.WriteField("description", media.AltText); err != nil {
+			return "", err
+		}
+	}
+	if err = writer.Close(); err != nil {
+		return "", err
+	}
+
+	var uploaded attachment
+	resp, err := p.do(http.MethodPost, "/api/v2/media", writer.FormDataContentType(), body, &uploaded)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode == http.StatusAccepted {
+		return uploaded.Id, p.waitForMedia(uploaded.Id)
+	}
+
+	return uploaded.Id, nil
+}
+
+// waitForMedia polls the attachment until the instance has finished processing it
+func (p *Publisher) waitForMedia(id string) error {
+	for i := 0; i < mediaPolls; i++ {
+		sleep(mediaPollInterval)
+
+		resp, err := p.do(http.MethodGet, "/api/v1/media/"+id, "", nil, nil)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			return nil
+		}
+	}
+
+	return &publisher.Error{Category: publisher.Transient, Err: errors.New("mastodon: media " + id + " is still processing")}
+}
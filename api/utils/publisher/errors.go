@@ -0,0 +1,55 @@
+package publisher
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Category describes how the scheduler should react to a publishing error
+type Category string
+
+const (
+	// Transient errors, such as rate limits and server errors, are retried with backoff
+	Transient = Category("transient")
+	// Permanent errors, such as rejected content, will never succeed and fail the tweet
+	Permanent = Category("permanent")
+	// Duplicate errors mean the status already exists, so the tweet counts as posted
+	Duplicate = Category("duplicate")
+	// Auth errors mean the credentials are no longer valid and nothing can be posted
+	Auth = Category("auth")
+)
+
+// Error is a destination error annotated with its Category
+type Error struct {
+	Category Category
+	// Code is the destination specific error code, when one was returned
+	Code int
+	// StatusCode is the HTTP status of the failed response, when one was received
+	StatusCode int
+	// RateLimit is the request window reported with the failed response, when known
+	RateLimit *RateLimit
+	Err       error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s error (status %d, code %d)", e.Category, e.StatusCode, e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Classify returns the Category of err, errors that were not classified by the
+// publisher are treated as Transient so they are retried rather than dropped
+func Classify(err error) Category {
+	var pubErr *Error
+
+	if errors.As(err, &pubErr) {
+		return pubErr.Category
+	}
+
+	return Transient
+}
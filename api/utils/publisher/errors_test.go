@@ -0,0 +1,52 @@
+package publisher
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	t.Run("Classified error", func(t *testing.T) {
+		err := &Error{Category: Permanent, Code: 186, Err: errors.New("twitter: 186 Tweet needs to be a bit shorter.")}
+
+		assert.Equal(t, Permanent, Classify(err))
+		assert.Equal(t, "twitter: 186 Tweet needs to be a bit shorter.", err.Error())
+	})
+
+	t.Run("Wrapped error", func(t *testing.T) {
+		err := fmt.Errorf("posting: %w", &Error{Category: Auth})
+
+		assert.Equal(t, Auth, Classify(err))
+	})
+
+	t.Run("Unclassified error", func(t *testing.T) {
+		assert.Equal(t, Transient, Classify(errors.New("connection reset by peer")))
+	})
+}
+
+func TestRateLimited(t *testing.T) {
+	t.Run("Exhausted window", func(t *testing.T) {
+		window := &RateLimit{Limit: 300, ResetAt: time.Now().Add(time.Minute)}
+
+		limit, ok := RateLimited(&Error{Category: Transient, RateLimit: window})
+
+		assert.True(t, ok)
+		assert.Equal(t, window, limit)
+	})
+
+	t.Run("Window has already reset", func(t *testing.T) {
+		_, ok := RateLimited(&Error{Category: Transient, RateLimit: &RateLimit{ResetAt: time.Now().Add(-time.Minute)}})
+
+		assert.False(t, ok)
+	})
+
+	t.Run("Requests remaining", func(t *testing.T) {
+		_, ok := RateLimited(&Error{Category: Permanent, RateLimit: &RateLimit{Remaining: 5, ResetAt: time.Now().Add(time.Minute)}})
+
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,52 @@
+package publisher
+
+import "time"
+
+// Post is the destination agnostic representation of a scheduled tweet
+type Post struct {
+	Message string
+	Media   []Media
+	// ReplyTo is the remote ID of the status this post replies to, used to chain threads
+	ReplyTo string
+	// Visibility and ContentWarning are only honoured by destinations that support them, such as Mastodon
+	Visibility     string
+	ContentWarning string
+	// Repost is the remote ID of a status to share as is, the other fields are not used
+	Repost string
+	// Quote is the remote ID of the status quoted by the post
+	Quote string
+	// Poll is posted with the message, destinations without polls reject the post
+	Poll *Poll
+}
+
+// Poll is a set of options followers vote on until the poll closes
+type Poll struct {
+	Options         []string
+	DurationMinutes int
+}
+
+// Media is an image to be uploaded and attached to a Post
+type Media struct {
+	MimeType string
+	AltText  string
+	Data     []byte
+}
+
+// Status is the remote record created by a successful Publish
+type Status struct {
+	Id       string
+	Url      string
+	PostedAt time.Time
+	// RateLimit is the request window reported with the response, when known
+	RateLimit *RateLimit
+}
+
+// Publisher is implemented by every destination the scheduler is able to post to
+type Publisher interface {
+	// Publish sends the post to the destination and returns the created status
+	Publish(post *Post) (*Status, error)
+	// Delete removes a previously published status by its remote ID
+	Delete(id string) error
+	// Verify checks that the destination is reachable with the configured credentials
+	Verify() error
+}
@@ -0,0 +1,29 @@
+package publisher
+
+import (
+	"errors"
+	"time"
+)
+
+// RateLimit is the request window reported by the destination with its last response
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+// Exhausted reports whether the window has no requests left at the given time
+func (r *RateLimit) Exhausted(now time.Time) bool {
+	return r.Remaining <= 0 && now.Before(r.ResetAt)
+}
+
+// RateLimited returns the exhausted window carried by err, when the error was caused by a rate limit
+func RateLimited(err error) (*RateLimit, bool) {
+	var pubErr *Error
+
+	if errors.As(err, &pubErr) && pubErr.RateLimit != nil && pubErr.RateLimit.Exhausted(time.Now()) {
+		return pubErr.RateLimit, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,85 @@
+package publisher
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Recorder is an in-memory Publisher that keeps everything it is given,
+// it is used in tests and for local development where no real account is available
+type Recorder struct {
+	mu      sync.Mutex
+	nextId  int64
+	posts   []Post
+	deleted []string
+
+	// PublishErr, DeleteErr and VerifyErr are returned by their matching calls when set
+	PublishErr error
+	DeleteErr  error
+	VerifyErr  error
+
+	// OnPublish is called with every post before it is recorded, returning an error fails that publish
+	OnPublish func(post *Post) error
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Publish(post *Post) (*Status, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.PublishErr != nil {
+		return nil, r.PublishErr
+	}
+
+	if r.OnPublish != nil {
+		if err := r.OnPublish(post); err != nil {
+			return nil, err
+		}
+	}
+
+	r.nextId++
+	r.posts = append(r.posts, *post)
+
+	id := strconv.FormatInt(r.nextId, 10)
+	return &Status{Id: id, Url: "memory://status/" + id, PostedAt: time.Now()}, nil
+}
+
+func (r *Recorder) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.DeleteErr != nil {
+		return r.DeleteErr
+	}
+
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+func (r *Recorder) Verify() error {
+	return r.VerifyErr
+}
+
+// Published returns a copy of the posts recorded so far
+func (r *Recorder) Published() []Post {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	posts := make([]Post, len(r.posts))
+	copy(posts, r.posts)
+	return posts
+}
+
+// Deleted returns a copy of the remote IDs deleted so far
+func (r *Recorder) Deleted() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]string, len(r.deleted))
+	copy(ids, r.deleted)
+	return ids
+}
@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/env"
+)
+
+type OverduePolicy string
+
+const (
+	// PostAll posts every overdue tweet, spaced out by POST_SPACING_SECONDS
+	PostAll = OverduePolicy("ALL")
+	// PostNewest posts only the most recent overdue tweet and skips the rest
+	PostNewest = OverduePolicy("NEWEST")
+	// Reslot moves overdue tweets into new slots after the last scheduled tweet
+	Reslot = OverduePolicy("RESLOT")
+)
+
+const (
+	defaultBatchSize      = 10
+	defaultOverdueMinutes = 30
+	defaultReslotMinutes  = 60
+)
+
+var (
+	// sleep is swapped out in tests so spacing does not slow them down
+	sleep         = time.Sleep
+	lastPublished time.Time
+)
+
+// batchSize is the maximum number of due tweets posted per run, configured with BATCH_SIZE
+func batchSize() int {
+	return env.Int("BATCH_SIZE", defaultBatchSize)
+}
+
+// postSpacing is the minimum gap between two consecutive posts, configured with POST_SPACING_SECONDS
+func postSpacing() time.Duration {
+	val, err := time.ParseDuration(os.Getenv("POST_SPACING_SECONDS") + "s")
+
+	if err != nil || val < 0 {
+		return 0
+	}
+
+	return val
+}
+
+func overduePolicy() OverduePolicy {
+	switch policy := OverduePolicy(strings.ToUpper(os.Getenv("OVERDUE_POLICY"))); policy {
+	case PostNewest, Reslot:
+		return policy
+	default:
+		return PostAll
+	}
+}
+
+// overdueCutoff is the post time before which a tweet counts as overdue, configured with OVERDUE_MINUTES
+func overdueCutoff(now time.Time) time.Time {
+	return now.Add(-time.Duration(env.Int("OVERDUE_MINUTES", defaultOverdueMinutes)) * time.Minute)
+}
+
+// waitForSpacing blocks until the configured spacing since the previous post has passed
+func waitForSpacing() {
+	if lastPublished.IsZero() {
+		return
+	}
+
+	if wait := postSpacing() - time.Since(lastPublished); wait > 0 {
+		sleep(wait)
+	}
+}
+
+// handleOverdue applies the overdue policy to the backlog before the regular batch is posted.
+// Threads are always posted as a whole and are left out of the policy
+func handleOverdue(now time.Time) {
+	policy := overduePolicy()
+
+	if policy == PostAll {
+		return
+	}
+
+	overdue, err := domain.TweetRepo.GetOverdue(overdueCutoff(now))
+	if err != nil {
+		return
+	}
+
+	overdue = untried(overdue)
+	if len(overdue) == 0 {
+		return
+	}
+
+	switch policy {
+	case PostNewest:
+		// overdue is ordered by post time, keep the last one
+		for _, tw := range overdue[:len(overdue)-1] {
+			tw.Status = domain.Skipped
+			tw.Modified = now
+			updateTweet(tw, "skipped")
+		}
+	case Reslot:
+		slot := now
+		if last, lErr := domain.TweetRepo.GetLast(); lErr == nil && last.PostTime.After(slot) {
+			slot = last.PostTime
+		}
+
+		gap := time.Duration(env.Int("RESLOT_MINUTES", defaultReslotMinutes)) * time.Minute
+
+		for _, tw := range overdue {
+			slot = slot.Add(gap)
+			tw.PostTime = slot
+			tw.Modified = now
+			updateTweet(tw, "re-slotted")
+		}
+	}
+}
+
+// untried drops the tweets that were already attempted, their retries run as scheduled
+func untried(tweets []domain.Tweet) []domain.Tweet {
+	result := make([]domain.Tweet, 0, len(tweets))
+
+	for _, tw := range tweets {
+		if tw.Attempts == 0 && tw.NextAttemptAt == nil {
+			result = append(result, tw)
+		}
+	}
+
+	return result
+}
+
+func updateTweet(tw domain.Tweet, action string) {
+	if _, upErr := domain.TweetRepo.Update(&tw); upErr != nil {
+		fmt.Println("error updating", action, "entry", upErr.Error(), upErr.Message())
+		return
+	}
+
+	fmt.Printf("Overdue tweet %d %s\n", tw.Id, action)
+}
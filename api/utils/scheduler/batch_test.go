@@ -0,0 +1,257 @@
+package scheduler
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainBatch(t *testing.T) {
+	postTime := time.Now().Add(-time.Minute)
+
+	t.Run("Posts every due tweet in the batch", func(t *testing.T) {
+		var requested int
+		var updated []domain.Tweet
+		recorder := publisher.NewRecorder()
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+		_ = os.Setenv("BATCH_SIZE", "3")
+		defer os.Unsetenv("BATCH_SIZE")
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			requested = limit
+			return []domain.Tweet{
+				{Id: 1, Message: "one", PostTime: postTime, Status: domain.Pending},
+				{Id: 2, Message: "two", PostTime: postTime, Status: domain.Pending},
+				{Id: 3, Message: "three", PostTime: postTime, Status: domain.Pending},
+			}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = append(updated, *msg)
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.Equal(t, 3, requested)
+		assert.Len(t, recorder.Published(), 3)
+		assert.Len(t, updated, 3)
+	})
+
+	t.Run("Posts a thread once", func(t *testing.T) {
+		var threadCalls int
+		recorder := publisher.NewRecorder()
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+
+		parts := []domain.Tweet{
+			{Id: 1, Message: "first", PostTime: postTime, Status: domain.Pending, ThreadId: "t1", ThreadPosition: 1},
+			{Id: 2, Message: "second", PostTime: postTime, Status: domain.Pending, ThreadId: "t1", ThreadPosition: 2},
+		}
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return parts, nil
+		}
+		getThreadDomain = func(threadId string) ([]domain.Tweet, error_utils.MessageErr) {
+			threadCalls++
+			return parts, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.Equal(t, 1, threadCalls)
+		assert.Len(t, recorder.Published(), 2)
+	})
+
+	t.Run("Holds the account once its credentials are rejected", func(t *testing.T) {
+		recorder := publisher.NewRecorder()
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+		defer queue.reset()
+
+		var attempts int
+		recorder.OnPublish = func(post *publisher.Post) error {
+			attempts++
+			return &publisher.Error{Category: publisher.Auth, Code: 89}
+		}
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{
+				{Id: 1, Message: "one", PostTime: postTime, Status: domain.Pending},
+				{Id: 2, Message: "two", PostTime: postTime, Status: domain.Pending},
+			}, nil
+		}
+
+		getTweets()
+
+		assert.True(t, queue.isPaused(0))
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("Spaces consecutive posts", func(t *testing.T) {
+		var waited []time.Duration
+		sleep = func(d time.Duration) { waited = append(waited, d) }
+		defer func() { sleep = time.Sleep }()
+		_ = os.Setenv("POST_SPACING_SECONDS", "30")
+		defer os.Unsetenv("POST_SPACING_SECONDS")
+
+		Publisher = publisher.NewRecorder()
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{
+				{Id: 1, Message: "one", PostTime: postTime, Status: domain.Pending},
+				{Id: 2, Message: "two", PostTime: postTime, Status: domain.Pending},
+			}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		lastPublished = time.Time{}
+		getTweets()
+
+		assert.Len(t, waited, 1)
+		assert.True(t, waited[0] > 29*time.Second)
+	})
+}
+
+func TestHandleOverdue(t *testing.T) {
+	now := time.Now()
+	overdue := []domain.Tweet{
+		{Id: 1, Message: "oldest", PostTime: now.Add(-3 * time.Hour), Status: domain.Pending},
+		{Id: 2, Message: "older", PostTime: now.Add(-2 * time.Hour), Status: domain.Pending},
+		{Id: 3, Message: "newest", PostTime: now.Add(-time.Hour), Status: domain.Pending},
+	}
+
+	t.Run("All leaves the backlog alone", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		getOverdueDomain = func(cutoff time.Time) ([]domain.Tweet, error_utils.MessageErr) {
+			t.Fatal("overdue tweets should not be loaded")
+			return nil, nil
+		}
+
+		handleOverdue(now)
+	})
+
+	t.Run("Newest skips all but the latest", func(t *testing.T) {
+		var updated []domain.Tweet
+		domain.TweetRepo = &tweetDbMock{}
+		_ = os.Setenv("OVERDUE_POLICY", "newest")
+		defer os.Unsetenv("OVERDUE_POLICY")
+
+		getOverdueDomain = func(cutoff time.Time) ([]domain.Tweet, error_utils.MessageErr) {
+			assert.Equal(t, now.Add(-30*time.Minute), cutoff)
+			return overdue, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = append(updated, *msg)
+			return msg, nil
+		}
+
+		handleOverdue(now)
+
+		assert.Len(t, updated, 2)
+		assert.EqualValues(t, 1, updated[0].Id)
+		assert.EqualValues(t, 2, updated[1].Id)
+		assert.EqualValues(t, domain.Skipped, updated[0].Status)
+		assert.EqualValues(t, domain.Skipped, updated[1].Status)
+	})
+
+	t.Run("Reslot moves the backlog after the last tweet", func(t *testing.T) {
+		var updated []domain.Tweet
+		last := now.Add(2 * time.Hour)
+		domain.TweetRepo = &tweetDbMock{}
+		_ = os.Setenv("OVERDUE_POLICY", "RESLOT")
+		_ = os.Setenv("RESLOT_MINUTES", "15")
+		defer os.Unsetenv("OVERDUE_POLICY")
+		defer os.Unsetenv("RESLOT_MINUTES")
+
+		getOverdueDomain = func(cutoff time.Time) ([]domain.Tweet, error_utils.MessageErr) {
+			return overdue, nil
+		}
+		getLastTweetDomain = func() (*domain.Tweet, error_utils.MessageErr) {
+			return &domain.Tweet{PostTime: last}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = append(updated, *msg)
+			return msg, nil
+		}
+
+		handleOverdue(now)
+
+		assert.Len(t, updated, 3)
+		assert.Equal(t, last.Add(15*time.Minute), updated[0].PostTime)
+		assert.Equal(t, last.Add(30*time.Minute), updated[1].PostTime)
+		assert.Equal(t, last.Add(45*time.Minute), updated[2].PostTime)
+		assert.EqualValues(t, domain.Pending, updated[2].Status)
+	})
+
+	t.Run("Leaves tweets waiting on a retry", func(t *testing.T) {
+		var updated []domain.Tweet
+		retryAt := now.Add(time.Minute)
+		domain.TweetRepo = &tweetDbMock{}
+		_ = os.Setenv("OVERDUE_POLICY", "NEWEST")
+		defer os.Unsetenv("OVERDUE_POLICY")
+
+		getOverdueDomain = func(cutoff time.Time) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{
+				{Id: 1, PostTime: now.Add(-3 * time.Hour), Status: domain.Pending, Attempts: 2, NextAttemptAt: &retryAt},
+				{Id: 2, PostTime: now.Add(-2 * time.Hour), Status: domain.Pending},
+				{Id: 3, PostTime: now.Add(-time.Hour), Status: domain.Pending, Attempts: 1, NextAttemptAt: &retryAt},
+			}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = append(updated, *msg)
+			return msg, nil
+		}
+
+		handleOverdue(now)
+
+		assert.Empty(t, updated)
+	})
+
+	t.Run("Nothing overdue", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		_ = os.Setenv("OVERDUE_POLICY", "NEWEST")
+		defer os.Unsetenv("OVERDUE_POLICY")
+
+		getOverdueDomain = func(cutoff time.Time) ([]domain.Tweet, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no records found")
+		}
+
+		handleOverdue(now)
+	})
+}
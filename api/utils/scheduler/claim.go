@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/env"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/google/uuid"
+)
+
+const defaultClaimLeaseMinutes = 15
+
+// errClaimLost is returned for tweets whose claim expired and may have been taken over by another replica
+var errClaimLost = errors.New("claim lost, the lease expired before the tweet was posted")
+
+// replicaId identifies this process in the claims it holds, the dyno name on Heroku or otherwise
+// the host name, with a random suffix so a restarted process never picks up the claims of the old one
+var replicaId = newReplicaId()
+
+func newReplicaId() string {
+	name := os.Getenv("DYNO")
+	if name == "" {
+		name, _ = os.Hostname()
+	}
+
+	return name + "-" + uuid.New().String()[:8]
+}
+
+// claimLease is how long a claim holds a tweet, configured with CLAIM_LEASE_MINUTES. The lease is
+// renewed right before each post, so it only has to outlast a single post rather than a whole run.
+// A replica that stops mid-run leaves its tweets to the others once the lease expires
+func claimLease() time.Duration {
+	return time.Duration(env.Int("CLAIM_LEASE_MINUTES", defaultClaimLeaseMinutes)) * time.Minute
+}
+
+// claimPending claims the next batch of due tweets for this replica, so no other replica posts them.
+// The claimed tweets are posted as pending, saving a tweet ends its claim
+func claimPending() ([]domain.Tweet, error_utils.MessageErr) {
+	tweets, err := domain.TweetRepo.ClaimPending(batchSize(), replicaId, claimLease())
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range tweets {
+		tweets[i].Status = domain.Pending
+	}
+
+	return tweets, nil
+}
+
+// releaseClaims hands back the tweets a run claimed but did not post, such as tweets held back by
+// a rate limit or a paused account, so they are due again straight away
+func releaseClaims() {
+	if _, err := domain.TweetRepo.ReleaseClaims(replicaId); err != nil {
+		fmt.Println("error releasing claimed tweets", err.Error(), err.Message())
+	}
+}
+
+// renewClaim extends this replica's claim on the tweet right before it is published, so the spacing
+// between the posts of a long run never outlasts the lease. It reports false when the claim expired
+// and was taken over or released, the tweet is then left to the replica that holds it
+func renewClaim(tweetId int64) bool {
+	held, err := domain.TweetRepo.RenewClaim(tweetId, replicaId, claimLease())
+	if err != nil {
+		fmt.Println("error renewing tweet claim", err.Error(), err.Message())
+		return false
+	}
+
+	if !held {
+		fmt.Printf("Tweet %d is no longer claimed by %s, leaving it to the replica that holds it\n", tweetId, replicaId)
+	}
+
+	return held
+}
@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaims(t *testing.T) {
+	postTime := time.Now().Add(-time.Minute)
+	leaseExpiresAt := time.Now().Add(claimLease())
+
+	setup := func(recorder *publisher.Recorder) {
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+		releasedBy = nil
+		lostClaims = nil
+	}
+
+	t.Run("Releases the claims after the run", func(t *testing.T) {
+		setup(publisher.NewRecorder())
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: postTime, Status: domain.Posting, ClaimedBy: replicaId, LeaseExpiresAt: &leaseExpiresAt}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.Equal(t, []string{replicaId}, releasedBy)
+	})
+
+	t.Run("A failed post goes back to pending", func(t *testing.T) {
+		var updated *domain.Tweet
+		recorder := publisher.NewRecorder()
+		recorder.PublishErr = errors.New("twitter: 130 Over capacity")
+		setup(recorder)
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: postTime, Status: domain.Posting, ClaimedBy: replicaId, LeaseExpiresAt: &leaseExpiresAt}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = msg
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.Equal(t, domain.Pending, updated.Status)
+		assert.NotNil(t, updated.NextAttemptAt)
+	})
+
+	t.Run("Leaves tweets whose lease expired mid-run", func(t *testing.T) {
+		var updated []int64
+		recorder := publisher.NewRecorder()
+		setup(recorder)
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{
+				{Id: 1, Message: "first", PostTime: postTime, Status: domain.Posting, ClaimedBy: replicaId, LeaseExpiresAt: &leaseExpiresAt},
+				{Id: 2, Message: "second", PostTime: postTime, Status: domain.Posting, ClaimedBy: replicaId, LeaseExpiresAt: &leaseExpiresAt},
+			}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			// the lease of the second tweet runs out while the first is posted, another replica takes it over
+			lostClaims = map[int64]bool{2: true}
+			updated = append(updated, msg.Id)
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.Equal(t, []publisher.Post{{Message: "first"}}, recorder.Published())
+		assert.Equal(t, []int64{1}, updated)
+	})
+
+	t.Run("Leaves destinations whose lease expired mid-run", func(t *testing.T) {
+		var updated int
+		recorder := publisher.NewRecorder()
+		setup(recorder)
+		lostClaims = map[int64]bool{1: true}
+
+		listDestinationsDomain = func(tweetId int64) ([]domain.Destination, error_utils.MessageErr) {
+			return []domain.Destination{{Id: 1, TweetId: tweetId, Status: domain.Pending}}, nil
+		}
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: postTime, Status: domain.Posting, ClaimedBy: replicaId, LeaseExpiresAt: &leaseExpiresAt}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated++
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.Empty(t, recorder.Published())
+		assert.Equal(t, 0, updated, "the replica that took the tweet over settles it")
+	})
+
+	t.Run("Nothing claimed", func(t *testing.T) {
+		setup(publisher.NewRecorder())
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no records found")
+		}
+
+		getTweets()
+
+		assert.Empty(t, releasedBy)
+	})
+
+	thread := func(claimedBy string) []domain.Tweet {
+		return []domain.Tweet{
+			{Id: 1, Message: "first", PostTime: postTime, Status: domain.Posted, ThreadId: "thread", RemoteId: "100"},
+			{Id: 2, Message: "second", PostTime: postTime, Status: domain.Posting, ThreadId: "thread", ThreadPosition: 1, ClaimedBy: claimedBy, LeaseExpiresAt: &leaseExpiresAt},
+		}
+	}
+
+	t.Run("Leaves thread parts another replica holds", func(t *testing.T) {
+		recorder := publisher.NewRecorder()
+		setup(recorder)
+
+		getThreadDomain = func(id string) ([]domain.Tweet, error_utils.MessageErr) {
+			return thread("web.2-0a1b2c3d"), nil
+		}
+
+		postThread("thread")
+
+		assert.Empty(t, recorder.Published())
+	})
+
+	t.Run("Posts thread parts this replica holds", func(t *testing.T) {
+		var updated *domain.Tweet
+		recorder := publisher.NewRecorder()
+		setup(recorder)
+
+		getThreadDomain = func(id string) ([]domain.Tweet, error_utils.MessageErr) {
+			return thread(replicaId), nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = msg
+			return msg, nil
+		}
+
+		postThread("thread")
+
+		assert.Equal(t, []publisher.Post{{Message: "second", ReplyTo: "100"}}, recorder.Published())
+		assert.Equal(t, domain.Posted, updated.Status)
+	})
+}
@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/getsentry/sentry-go"
+)
+
+// crossPost publishes the tweet to every destination that is due. Each destination succeeds, retries
+// and fails on its own, the tweet is then settled from the destinations so it is only Posted
+// once all of them were posted or skipped
+func crossPost(tw domain.Tweet, replies map[int64]string) (*domain.Tweet, error) {
+	var lastErr error
+
+	post, buildErr := buildPost(tw)
+
+	if buildErr != nil {
+		fmt.Println("error loading tweet media", buildErr.Error(), buildErr.Message())
+		return nil, buildErr
+	}
+
+	for i := range tw.Destinations {
+		dest := &tw.Destinations[i]
+
+		// destinations whose credentials are rejected wait for them to be accepted again, those whose
+		// account is rate limited wait for its window to reset
+		if dest.Done() || (dest.NextAttemptAt != nil && dest.NextAttemptAt.After(time.Now())) || queue.isPaused(dest.AccountId) || rateLimit.limited(dest.AccountId, time.Now()) {
+			continue
+		}
+
+		destPost := *post
+		destPost.ReplyTo = replies[dest.AccountId]
+
+		if err := publishDestination(tw, dest, &destPost); err != nil {
+			// the tweet is settled by the replica that took it over
+			if err == errClaimLost {
+				return nil, err
+			}
+			lastErr = err
+		}
+	}
+
+	tw.Settle(tw.Destinations)
+	tw.Modified = time.Now().Local()
+
+	if _, upErr := domain.TweetRepo.Update(&tw); upErr != nil {
+		fmt.Println("error updating cross-posted entry", upErr.Error(), upErr.Message())
+	}
+
+	return &tw, lastErr
+}
+
+// publishDestination posts to a single destination, errors are handled the same way as for a
+// tweet that is not cross-posted but are recorded against the destination
+func publishDestination(tw domain.Tweet, dest *domain.Destination, post *publisher.Post) error {
+	pub, pubErr := publisherFor(dest.AccountId)
+
+	if pubErr != nil {
+		fmt.Println("error loading destination account", pubErr)
+		recordDestinationFailure(tw, dest, pubErr)
+		return pubErr
+	}
+
+	waitForSpacing()
+
+	if !renewClaim(tw.Id) {
+		return errClaimLost
+	}
+
+	fmt.Printf("Posting tweet to destination %d: %s\n", dest.Id, tw.Message)
+	status, postErr := pub.Publish(post)
+
+	if postErr != nil {
+		fmt.Println("Posting error: ", postErr)
+
+		if rateLimited(dest.AccountId, postErr) {
+			return postErr
+		}
+
+		switch publisher.Classify(postErr) {
+		case publisher.Duplicate:
+			fmt.Println("Marking duplicate as posted")
+		case publisher.Auth:
+			queue.pause(dest.AccountId, postErr.Error())
+			return postErr
+		default:
+			recordDestinationFailure(tw, dest, postErr)
+			return postErr
+		}
+	} else {
+		rateLimit.update(dest.AccountId, status.RateLimit)
+		postedAt := status.PostedAt.Local()
+		dest.RemoteId = status.Id
+		dest.RemoteUrl = status.Url
+		dest.PostedAt = &postedAt
+	}
+
+	lastPublished = time.Now()
+
+	dest.Status = domain.Posted
+	dest.NextAttemptAt = nil
+	dest.Modified = time.Now().Local()
+
+	if _, upErr := domain.DestinationRepo.Update(dest); upErr != nil {
+		fmt.Println("error updating posted destination", upErr.Error(), upErr.Message())
+	}
+
+	return nil
+}
+
+// recordDestinationFailure stores the publish error on the destination and either schedules its next
+// attempt or moves it to Failed, the other destinations of the tweet are not affected
+func recordDestinationFailure(tw domain.Tweet, dest *domain.Destination, postErr error) {
+	now := time.Now().Local()
+
+	dest.Attempts++
+	dest.LastError = postErr.Error()
+	dest.Modified = now
+	dest.NextAttemptAt = retryAt(dest.Attempts, postErr, now)
+
+	if dest.NextAttemptAt == nil {
+		dest.Status = domain.Failed
+
+		message := fmt.Sprintf("Tweet %d destination %d failed after %d attempts: %s", tw.Id, dest.Id, dest.Attempts, dest.LastError)
+		fmt.Println(message)
+		sentry.CaptureMessage(message)
+	}
+
+	if _, upErr := domain.DestinationRepo.Update(dest); upErr != nil {
+		fmt.Println("error updating failed destination", upErr.Error(), upErr.Message())
+	}
+}
+
+// loadDestinations attaches the tweet's destinations, tweets that are not cross-posted have none
+func loadDestinations(tw *domain.Tweet) error_utils.MessageErr {
+	destinations, err := domain.DestinationRepo.List(tw.Id)
+	if err != nil && err.Status() != http.StatusNotFound {
+		return err
+	}
+
+	tw.Destinations = destinations
+	return nil
+}
+
+// addReplies records the statuses a posted thread part was published as, keyed by account
+func addReplies(replies map[int64]string, tw domain.Tweet) {
+	if len(tw.Destinations) == 0 {
+		if tw.RemoteId != "" {
+			replies[tw.AccountId] = tw.RemoteId
+		}
+		return
+	}
+
+	for _, d := range tw.Destinations {
+		if d.Status == domain.Posted && d.RemoteId != "" {
+			replies[d.AccountId] = d.RemoteId
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrossPost(t *testing.T) {
+	postTime := time.Now().Add(-time.Minute)
+
+	setup := func(destinations func() []domain.Destination) (*publisher.Recorder, *publisher.Recorder, map[int64]domain.Destination, *[]domain.Tweet) {
+		fallback := publisher.NewRecorder()
+		mastodon := publisher.NewRecorder()
+		saved := make(map[int64]domain.Destination)
+		updated := make([]domain.Tweet, 0)
+
+		Publisher = fallback
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		domain.AccountRepo = &accountDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+		listMediaDomain = noMedia
+
+		accountPublisher = func(account *domain.Account) (publisher.Publisher, error) {
+			return mastodon, nil
+		}
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return &domain.Account{Id: id, Network: domain.MastodonNetwork}, nil
+		}
+		listDestinationsDomain = func(tweetId int64) ([]domain.Destination, error_utils.MessageErr) {
+			return destinations(), nil
+		}
+		updateDestinationDomain = func(destination *domain.Destination) (*domain.Destination, error_utils.MessageErr) {
+			saved[destination.Id] = *destination
+			return destination, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = append(updated, *msg)
+			return msg, nil
+		}
+
+		return fallback, mastodon, saved, &updated
+	}
+
+	t.Run("A failed destination does not fail the others", func(t *testing.T) {
+		fallback, mastodon, saved, updated := setup(func() []domain.Destination {
+			return []domain.Destination{{Id: 1, TweetId: 1, Status: domain.Pending}, {Id: 2, TweetId: 1, AccountId: 3, Status: domain.Pending}}
+		})
+		defer func() { accountPublisher = newAccountPublisher }()
+		mastodon.PublishErr = errors.New("mastodon: 503 Service Unavailable")
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: postTime, Status: domain.Pending}}, nil
+		}
+
+		getTweets()
+
+		assert.Len(t, fallback.Published(), 1)
+		assert.EqualValues(t, domain.Posted, saved[1].Status)
+		assert.Equal(t, "1", saved[1].RemoteId)
+		assert.EqualValues(t, domain.Pending, saved[2].Status)
+		assert.Equal(t, 1, saved[2].Attempts)
+		assert.Equal(t, "mastodon: 503 Service Unavailable", saved[2].LastError)
+
+		assert.Len(t, *updated, 1)
+		tweet := (*updated)[0]
+		assert.EqualValues(t, domain.Pending, tweet.Status)
+		assert.Equal(t, "1", tweet.RemoteId)
+		assert.Equal(t, saved[2].NextAttemptAt, tweet.NextAttemptAt)
+	})
+
+	t.Run("Posted once every destination is done", func(t *testing.T) {
+		fallback, mastodon, saved, updated := setup(func() []domain.Destination {
+			return []domain.Destination{
+				{Id: 1, TweetId: 1, Status: domain.Posted, RemoteId: "99"},
+				{Id: 2, TweetId: 1, AccountId: 3, Status: domain.Pending, Attempts: 1},
+				{Id: 3, TweetId: 1, AccountId: 4, Status: domain.Skipped},
+			}
+		})
+		defer func() { accountPublisher = newAccountPublisher }()
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: postTime, Status: domain.Pending, Visibility: "unlisted"}}, nil
+		}
+
+		getTweets()
+
+		assert.Empty(t, fallback.Published())
+		assert.Equal(t, []publisher.Post{{Message: "the message", Visibility: "unlisted"}}, mastodon.Published())
+		assert.Len(t, saved, 1)
+		assert.EqualValues(t, domain.Posted, saved[2].Status)
+		assert.EqualValues(t, domain.Posted, (*updated)[0].Status)
+		assert.Equal(t, "99", (*updated)[0].RemoteId)
+	})
+
+	t.Run("Failed once a destination can no longer be retried", func(t *testing.T) {
+		_, mastodon, saved, updated := setup(func() []domain.Destination {
+			return []domain.Destination{{Id: 1, TweetId: 1, Status: domain.Pending}, {Id: 2, TweetId: 1, AccountId: 3, Status: domain.Pending}}
+		})
+		defer func() { accountPublisher = newAccountPublisher }()
+		mastodon.PublishErr = &publisher.Error{Category: publisher.Permanent, StatusCode: 422, Err: errors.New("mastodon: 422 Validation failed")}
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: postTime, Status: domain.Pending}}, nil
+		}
+
+		getTweets()
+
+		assert.EqualValues(t, domain.Posted, saved[1].Status)
+		assert.EqualValues(t, domain.Failed, saved[2].Status)
+		assert.EqualValues(t, domain.Failed, (*updated)[0].Status)
+		assert.Equal(t, "destination 2: mastodon: 422 Validation failed", (*updated)[0].LastError)
+	})
+
+	t.Run("Waits for the backoff of a destination", func(t *testing.T) {
+		next := time.Now().Add(time.Hour)
+		fallback, mastodon, saved, updated := setup(func() []domain.Destination {
+			return []domain.Destination{{Id: 1, TweetId: 1, Status: domain.Posted}, {Id: 2, TweetId: 1, AccountId: 3, Status: domain.Pending, Attempts: 1, NextAttemptAt: &next}}
+		})
+		defer func() { accountPublisher = newAccountPublisher }()
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: postTime, Status: domain.Pending}}, nil
+		}
+
+		getTweets()
+
+		assert.Empty(t, fallback.Published())
+		assert.Empty(t, mastodon.Published())
+		assert.Empty(t, saved)
+		assert.Equal(t, &next, (*updated)[0].NextAttemptAt)
+	})
+
+	t.Run("Threads reply to the part posted by the same account", func(t *testing.T) {
+		const threadId = "thread"
+		fallback, mastodon, _, _ := setup(nil)
+		defer func() { accountPublisher = newAccountPublisher }()
+
+		listDestinationsDomain = func(tweetId int64) ([]domain.Destination, error_utils.MessageErr) {
+			if tweetId == 1 {
+				return []domain.Destination{{Id: 1, TweetId: 1, Status: domain.Posted, RemoteId: "100"}, {Id: 2, TweetId: 1, AccountId: 3, Status: domain.Posted, RemoteId: "m100"}}, nil
+			}
+			return []domain.Destination{{Id: 3, TweetId: 2, Status: domain.Pending}, {Id: 4, TweetId: 2, AccountId: 3, Status: domain.Pending}}, nil
+		}
+		getThreadDomain = func(id string) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{
+				{Id: 1, Message: "first", PostTime: postTime, Status: domain.Posted, ThreadId: threadId, ThreadPosition: 0, RemoteId: "100"},
+				{Id: 2, Message: "second", PostTime: postTime, Status: domain.Pending, ThreadId: threadId, ThreadPosition: 1},
+			}, nil
+		}
+
+		postThread(threadId)
+
+		assert.Equal(t, []publisher.Post{{Message: "second", ReplyTo: "100"}}, fallback.Published())
+		assert.Equal(t, []publisher.Post{{Message: "second", ReplyTo: "m100"}}, mastodon.Published())
+	})
+}
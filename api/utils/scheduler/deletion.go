@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/getsentry/sentry-go"
+)
+
+// deleteExpired removes every posted tweet whose DeleteAt has passed and marks it Deleted. Transient
+// errors are retried on the next run, auth errors pause the account and any other error stops
+// the deletion and is recorded against the tweet
+func deleteExpired() {
+	tweets, err := domain.TweetRepo.GetDueDeletions(time.Now())
+
+	if err != nil {
+		if err.Status() != http.StatusNotFound {
+			fmt.Println("Deletion:", err)
+		}
+		return
+	}
+
+	for _, tw := range tweets {
+		if loadErr := loadDestinations(&tw); loadErr != nil {
+			fmt.Println("error loading tweet destinations", loadErr.Error(), loadErr.Message())
+			continue
+		}
+
+		deleteErr := deleteTweet(tw)
+
+		if deleteErr == nil {
+			fmt.Printf("Deleted tweet %d\n", tw.Id)
+			tw.Status = domain.Deleted
+			saveDeletion(tw)
+			continue
+		}
+
+		fmt.Printf("Unable to delete tweet %d: %s\n", tw.Id, deleteErr)
+
+		// the deletion is retried on the next run, for rejected credentials once the account resumes
+		if category := publisher.Classify(deleteErr); deleteErr == errCredentialsRejected || category == publisher.Transient || category == publisher.Auth {
+			continue
+		}
+
+		message := fmt.Sprintf("Tweet %d could not be deleted: %s", tw.Id, deleteErr)
+		sentry.CaptureMessage(message)
+
+		tw.LastError = "delete: " + deleteErr.Error()
+		tw.DeleteAt = nil
+		saveDeletion(tw)
+	}
+}
+
+func saveDeletion(tw domain.Tweet) {
+	tw.Modified = time.Now().Local()
+
+	if _, upErr := domain.TweetRepo.Update(&tw); upErr != nil {
+		fmt.Println("error updating deleted entry", upErr.Error(), upErr.Message())
+	}
+}
+
+// deleteTweet removes the tweet from every destination it was posted to, or otherwise from its account
+func deleteTweet(tw domain.Tweet) error {
+	if len(tw.Destinations) == 0 {
+		return deleteStatus(tw.AccountId, tw.RemoteId)
+	}
+
+	for _, dest := range tw.Destinations {
+		if dest.Status != domain.Posted || dest.RemoteId == "" {
+			continue
+		}
+
+		if err := deleteStatus(dest.AccountId, dest.RemoteId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteStatus removes a single status, a status that no longer exists counts as deleted
+func deleteStatus(accountId int64, remoteId string) error {
+	if queue.isPaused(accountId) {
+		return errCredentialsRejected
+	}
+
+	pub, err := publisherFor(accountId)
+
+	if err == nil {
+		err = pub.Delete(remoteId)
+	}
+
+	var pubErr *publisher.Error
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &pubErr) && pubErr.StatusCode == http.StatusNotFound:
+		return nil
+	case publisher.Classify(err) == publisher.Auth:
+		queue.pause(accountId, err.Error())
+	}
+
+	return err
+}
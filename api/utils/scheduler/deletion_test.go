@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteExpired(t *testing.T) {
+	postedAt := time.Now().Add(-2 * time.Hour)
+	deleteAt := time.Now().Add(-time.Minute)
+
+	setup := func(tweets ...domain.Tweet) (*publisher.Recorder, *[]domain.Tweet) {
+		recorder := publisher.NewRecorder()
+		updated := make([]domain.Tweet, 0)
+
+		Publisher = recorder
+		queue.reset()
+		domain.TweetRepo = &tweetDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+
+		getDueDeletionsDomain = func(now time.Time) ([]domain.Tweet, error_utils.MessageErr) {
+			return tweets, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = append(updated, *msg)
+			return msg, nil
+		}
+
+		return recorder, &updated
+	}
+
+	t.Run("Deletes and marks the tweet deleted", func(t *testing.T) {
+		recorder, updated := setup(domain.Tweet{Id: 1, Status: domain.Posted, RemoteId: "100", PostedAt: &postedAt, DeleteAt: &deleteAt})
+
+		deleteExpired()
+
+		assert.Equal(t, []string{"100"}, recorder.Deleted())
+		assert.Len(t, *updated, 1)
+		assert.EqualValues(t, domain.Deleted, (*updated)[0].Status)
+	})
+
+	t.Run("Deletes every posted destination", func(t *testing.T) {
+		recorder, updated := setup(domain.Tweet{Id: 1, Status: domain.Posted, RemoteId: "100", PostedAt: &postedAt, DeleteAt: &deleteAt})
+		listDestinationsDomain = func(tweetId int64) ([]domain.Destination, error_utils.MessageErr) {
+			return []domain.Destination{
+				{Id: 1, TweetId: 1, Status: domain.Posted, RemoteId: "100"},
+				{Id: 2, TweetId: 1, Status: domain.Skipped},
+				{Id: 3, TweetId: 1, Status: domain.Posted, RemoteId: "101"},
+			}, nil
+		}
+
+		deleteExpired()
+
+		assert.Equal(t, []string{"100", "101"}, recorder.Deleted())
+		assert.EqualValues(t, domain.Deleted, (*updated)[0].Status)
+	})
+
+	t.Run("A status that no longer exists counts as deleted", func(t *testing.T) {
+		recorder, updated := setup(domain.Tweet{Id: 1, Status: domain.Posted, RemoteId: "100", DeleteAt: &deleteAt})
+		recorder.DeleteErr = &publisher.Error{Category: publisher.Permanent, StatusCode: 404, Code: 144, Err: errors.New("twitter: 144 No status found with that ID.")}
+
+		deleteExpired()
+
+		assert.EqualValues(t, domain.Deleted, (*updated)[0].Status)
+	})
+
+	t.Run("Transient errors are retried on the next run", func(t *testing.T) {
+		recorder, updated := setup(domain.Tweet{Id: 1, Status: domain.Posted, RemoteId: "100", DeleteAt: &deleteAt})
+		recorder.DeleteErr = &publisher.Error{Category: publisher.Transient, StatusCode: 503, Err: errors.New("twitter: 503 Service Unavailable")}
+
+		deleteExpired()
+
+		assert.Empty(t, *updated)
+	})
+
+	t.Run("Auth errors pause the account", func(t *testing.T) {
+		recorder, updated := setup(domain.Tweet{Id: 1, Status: domain.Posted, RemoteId: "100", DeleteAt: &deleteAt})
+		defer queue.reset()
+		recorder.DeleteErr = &publisher.Error{Category: publisher.Auth, StatusCode: 401, Err: errors.New("twitter: 89 Invalid or expired token.")}
+
+		deleteExpired()
+
+		assert.Empty(t, *updated)
+		assert.True(t, queue.isPaused(0))
+	})
+
+	t.Run("Permanent errors stop the deletion", func(t *testing.T) {
+		recorder, updated := setup(domain.Tweet{Id: 1, Status: domain.Posted, RemoteId: "100", DeleteAt: &deleteAt})
+		recorder.DeleteErr = &publisher.Error{Category: publisher.Permanent, StatusCode: 403, Err: errors.New("twitter: 63 User has been suspended.")}
+
+		deleteExpired()
+
+		assert.Len(t, *updated, 1)
+		assert.EqualValues(t, domain.Posted, (*updated)[0].Status)
+		assert.Equal(t, "delete: twitter: 63 User has been suspended.", (*updated)[0].LastError)
+		assert.Nil(t, (*updated)[0].DeleteAt)
+	})
+
+	t.Run("Schedules the deletion once posted", func(t *testing.T) {
+		_, updated := setup()
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+
+		posted, err := publishTweet(domain.Tweet{Id: 1, Message: "the message", Status: domain.Pending, DeleteAfter: "1h"}, "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, posted.PostedAt.Add(time.Hour), *(*updated)[0].DeleteAt)
+	})
+}
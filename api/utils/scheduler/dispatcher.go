@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/env"
+)
+
+const (
+	// defaultIdleMinutes is how long the dispatcher sleeps when nothing is scheduled, so tweets
+	// added straight to the database are still picked up
+	defaultIdleMinutes = 5
+	// retryInterval is the wait after a run that left due tweets behind, such as tweets of a paused
+	// account or a halted thread, and after the next due time could not be read
+	retryInterval = time.Minute
+)
+
+// dispatcher posts due tweets at their post time instead of polling on an interval. It sleeps until
+// the next due tweet and is re-armed whenever tweets change, so an earlier tweet is not missed
+type dispatcher struct {
+	mu      sync.Mutex
+	rearm   chan struct{}
+	next    *time.Time
+	lastRun time.Time
+}
+
+var dispatch = newDispatcher()
+
+func newDispatcher() *dispatcher {
+	return &dispatcher{rearm: make(chan struct{}, 1)}
+}
+
+// Rearm wakes the dispatcher to read the next due time again. Calls never block, several calls
+// before the dispatcher wakes are handled as one
+func (d *dispatcher) Rearm() {
+	select {
+	case d.rearm <- struct{}{}:
+	default:
+	}
+}
+
+// run calls post every time a tweet is due until done is closed
+func (d *dispatcher) run(post func(), done <-chan struct{}) {
+	for {
+		wake := d.nextWake(time.Now())
+		timer := time.NewTimer(time.Until(wake))
+
+		select {
+		case <-timer.C:
+			d.mu.Lock()
+			d.lastRun = time.Now()
+			d.mu.Unlock()
+			post()
+		case <-d.rearm:
+			timer.Stop()
+		case <-done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// nextWake returns when the next tweet is due. Tweets that were already due on the last run are held
+// back, such as those of a rate limited account, they are retried after retryInterval
+func (d *dispatcher) nextWake(now time.Time) time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	wake := now.Add(time.Duration(env.Int("DISPATCH_IDLE_MINUTES", defaultIdleMinutes)) * time.Minute)
+
+	due, err := domain.TweetRepo.GetNextDue()
+
+	switch {
+	case err == nil:
+		wake = *due
+	case err.Status() != http.StatusNotFound:
+		fmt.Println("Dispatcher:", err)
+		wake = now.Add(retryInterval)
+	}
+
+	if !d.lastRun.IsZero() && !wake.After(d.lastRun) {
+		wake = d.lastRun.Add(retryInterval)
+	}
+
+	d.next = &wake
+	return wake
+}
+
+// nextRun is when the dispatcher wakes next, nil until it has started
+func (d *dispatcher) nextRun() *time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.next == nil {
+		return nil
+	}
+
+	next := d.next.Local()
+	return &next
+}
@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/stretchr/testify/assert"
+)
+
+func dueAt(due time.Time) func() (*time.Time, error_utils.MessageErr) {
+	return func() (*time.Time, error_utils.MessageErr) {
+		return &due, nil
+	}
+}
+
+func nothingDue() (*time.Time, error_utils.MessageErr) {
+	return nil, error_utils.NotFoundError("no records found")
+}
+
+// runDispatcher runs the dispatcher until the test ends and waits for it to stop
+func runDispatcher(t *testing.T, d *dispatcher, post func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		d.run(post, done)
+		close(stopped)
+	}()
+
+	t.Cleanup(func() {
+		close(done)
+		<-stopped
+	})
+}
+
+func TestDispatcher_NextWake(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Wakes at the next post time", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		getNextDueDomain = dueAt(now.Add(61 * time.Second))
+
+		d := newDispatcher()
+
+		assert.Equal(t, now.Add(61*time.Second), d.nextWake(now))
+		assert.WithinDuration(t, now.Add(61*time.Second), *d.nextRun(), 0)
+	})
+
+	t.Run("Sleeps while nothing is scheduled", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		getNextDueDomain = nothingDue
+
+		assert.Equal(t, now.Add(defaultIdleMinutes*time.Minute), newDispatcher().nextWake(now))
+	})
+
+	t.Run("Retries when the due time cannot be read", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		getNextDueDomain = func() (*time.Time, error_utils.MessageErr) {
+			return nil, error_utils.InternalServerError("connection refused")
+		}
+
+		assert.Equal(t, now.Add(retryInterval), newDispatcher().nextWake(now))
+	})
+
+	t.Run("A rate limited account does not hold back the others", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		getNextDueDomain = dueAt(now.Add(time.Minute))
+		rateLimit.update(1, &publisher.RateLimit{Limit: 300, ResetAt: now.Add(10 * time.Minute)})
+		defer rateLimit.reset()
+
+		assert.Equal(t, now.Add(time.Minute), newDispatcher().nextWake(now))
+	})
+
+	t.Run("Holds back tweets left over from the last run", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		getNextDueDomain = dueAt(now.Add(-time.Hour))
+
+		d := newDispatcher()
+		d.lastRun = now.Add(-time.Second)
+
+		assert.Equal(t, d.lastRun.Add(retryInterval), d.nextWake(now))
+	})
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	t.Run("Posts when the next tweet is due", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		getNextDueDomain = dueAt(time.Now().Add(50 * time.Millisecond))
+
+		d := newDispatcher()
+		posted := make(chan struct{}, 1)
+
+		runDispatcher(t, d, func() {
+			getNextDueDomain = nothingDue
+			posted <- struct{}{}
+		})
+
+		select {
+		case <-posted:
+		case <-time.After(time.Second):
+			t.Fatal("the due tweet was not posted")
+		}
+	})
+
+	t.Run("Re-arms for an earlier tweet", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		getNextDueDomain = nothingDue
+
+		d := newDispatcher()
+		posted := make(chan struct{}, 1)
+
+		runDispatcher(t, d, func() { posted <- struct{}{} })
+
+		select {
+		case <-posted:
+			t.Fatal("nothing was due")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		d.mu.Lock()
+		getNextDueDomain = dueAt(time.Now())
+		d.mu.Unlock()
+		d.Rearm()
+
+		select {
+		case <-posted:
+		case <-time.After(time.Second):
+			t.Fatal("the dispatcher was not re-armed")
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/env"
+	"github.com/getsentry/sentry-go"
+)
+
+// leaderLockKey is the Postgres advisory lock the replicas compete for, the replica holding it is the leader
+const leaderLockKey int64 = 0x6c61747472
+
+const defaultLeaderCheckSeconds = 30
+
+// leaderCheckInterval is how often replicas try to take the lock, configured with LEADER_CHECK_SECONDS.
+// It bounds how long the singleton jobs go without a leader after the leader stops
+func leaderCheckInterval() int {
+	return env.Int("LEADER_CHECK_SECONDS", defaultLeaderCheckSeconds)
+}
+
+// Leadership reports whether this replica is the leader that runs the singleton jobs
+type Leadership struct {
+	Leader bool `json:"leader" example:"true"`
+	// Since is when this replica became the leader
+	Since     *time.Time `json:"since,omitempty" example:"2022-09-09T10:30:01.559636Z"`
+	CheckedAt time.Time  `json:"checkedAt" example:"2022-09-09T10:30:31.559636Z"`
+	Error     string     `json:"error,omitempty" example:"error when trying to save data: connection refused"`
+}
+
+type leaderState struct {
+	mu     sync.Mutex
+	status Leadership
+}
+
+var leadership = &leaderState{}
+
+// elect takes the leader lock when it is free and checks it is still held otherwise. A replica that
+// cannot reach the database steps down, its lock is released with its session
+func elect() {
+	held, err := domain.LeaderRepo.TryLock(leaderLockKey)
+
+	leadership.mu.Lock()
+	defer leadership.mu.Unlock()
+
+	now := time.Now().Local()
+	status := &leadership.status
+	status.CheckedAt = now
+	status.Error = ""
+
+	if err != nil {
+		status.Error = err.Message()
+		fmt.Println("Leader election:", err.Message())
+	}
+
+	switch {
+	case held && !status.Leader:
+		fmt.Printf("Replica %s is now the leader\n", replicaId)
+		status.Since = &now
+	case !held && status.Leader:
+		message := fmt.Sprintf("Replica %s lost the leader lock", replicaId)
+		fmt.Println(message)
+		sentry.CaptureMessage(message)
+		status.Since = nil
+	}
+
+	status.Leader = held
+}
+
+func (l *leaderState) isLeader() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.status.Leader
+}
+
+func (l *leaderState) current() Leadership {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.status
+}
+
+// singleton wraps a job that has to run on one replica only, it is skipped unless this replica leads
+func singleton(job func()) func() {
+	return func() {
+		if leadership.isLeader() {
+			job()
+		}
+	}
+}
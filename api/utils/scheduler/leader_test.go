@@ -0,0 +1,171 @@
+package scheduler
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/services"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/stretchr/testify/assert"
+)
+
+var tryLockDomain func(key int64) (bool, error_utils.MessageErr)
+
+type leaderDbMock struct {
+	domain.LeaderRepoInterface
+}
+
+func (m *leaderDbMock) TryLock(key int64) (bool, error_utils.MessageErr) {
+	return tryLockDomain(key)
+}
+
+// lockHeld makes this replica win or lose every election
+func lockHeld(held bool) func(key int64) (bool, error_utils.MessageErr) {
+	return func(key int64) (bool, error_utils.MessageErr) {
+		return held, nil
+	}
+}
+
+func TestElect(t *testing.T) {
+	domain.LeaderRepo = &leaderDbMock{}
+	defer func() { leadership = &leaderState{} }()
+
+	t.Run("Takes the lead", func(t *testing.T) {
+		tryLockDomain = func(key int64) (bool, error_utils.MessageErr) {
+			assert.Equal(t, leaderLockKey, key)
+			return true, nil
+		}
+
+		elect()
+
+		status := GetStatus().Leadership
+		assert.True(t, status.Leader)
+		assert.NotNil(t, status.Since)
+		assert.False(t, status.CheckedAt.IsZero())
+	})
+
+	t.Run("Keeps the lead", func(t *testing.T) {
+		since := leadership.current().Since
+		tryLockDomain = lockHeld(true)
+
+		elect()
+
+		assert.Equal(t, since, leadership.current().Since)
+	})
+
+	t.Run("Steps down when the database is unreachable", func(t *testing.T) {
+		tryLockDomain = func(key int64) (bool, error_utils.MessageErr) {
+			return false, error_utils.InternalServerError("error when trying to save data: connection refused")
+		}
+
+		elect()
+
+		status := leadership.current()
+		assert.False(t, status.Leader)
+		assert.Nil(t, status.Since)
+		assert.Equal(t, "error when trying to save data: connection refused", status.Error)
+	})
+
+	t.Run("Another replica leads", func(t *testing.T) {
+		tryLockDomain = lockHeld(false)
+
+		elect()
+
+		status := leadership.current()
+		assert.False(t, status.Leader)
+		assert.Empty(t, status.Error)
+	})
+}
+
+func TestSingleton(t *testing.T) {
+	domain.LeaderRepo = &leaderDbMock{}
+	defer func() { leadership = &leaderState{} }()
+
+	var runs int
+	job := singleton(func() { runs++ })
+
+	tryLockDomain = lockHeld(false)
+	elect()
+	job()
+
+	assert.Equal(t, 0, runs)
+
+	tryLockDomain = lockHeld(true)
+	elect()
+	job()
+
+	assert.Equal(t, 1, runs)
+}
+
+func TestOverdueOnLeaderOnly(t *testing.T) {
+	domain.LeaderRepo = &leaderDbMock{}
+	defer func() { leadership = &leaderState{} }()
+	_ = os.Setenv("OVERDUE_POLICY", "NEWEST")
+	defer os.Unsetenv("OVERDUE_POLICY")
+
+	var overdueChecks int
+	Publisher = publisher.NewRecorder()
+	domain.TweetRepo = &tweetDbMock{}
+	getOverdueDomain = func(cutoff time.Time) ([]domain.Tweet, error_utils.MessageErr) {
+		overdueChecks++
+		return nil, error_utils.NotFoundError("no records found")
+	}
+	getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+		return nil, error_utils.NotFoundError("no records found")
+	}
+
+	tryLockDomain = lockHeld(false)
+	elect()
+	getTweets()
+
+	assert.Equal(t, 0, overdueChecks)
+
+	tryLockDomain = lockHeld(true)
+	elect()
+	getTweets()
+
+	assert.Equal(t, 1, overdueChecks)
+}
+
+type authServiceMock struct {
+	lists int
+}
+
+func (m *authServiceMock) Create(token *domain.Token) (*domain.Token, error_utils.MessageErr) {
+	return token, nil
+}
+func (m *authServiceMock) Get(id int64) (*domain.Token, error_utils.MessageErr) {
+	return nil, error_utils.NotFoundError("no record matching given id")
+}
+func (m *authServiceMock) List() ([]domain.Token, error_utils.MessageErr) {
+	m.lists++
+	return nil, error_utils.NotFoundError("no records found")
+}
+func (m *authServiceMock) Reset(token *domain.Token) (*domain.Token, error_utils.MessageErr) {
+	return token, nil
+}
+func (m *authServiceMock) Delete(id int64) error_utils.MessageErr {
+	return nil
+}
+func (m *authServiceMock) ValidateToken(token *domain.Token, requiredScope string) bool {
+	return false
+}
+
+func TestRefreshCachesOnEveryReplica(t *testing.T) {
+	domain.LeaderRepo = &leaderDbMock{}
+	defer func() { leadership = &leaderState{} }()
+	original := services.AuthService
+	defer func() { services.AuthService = original }()
+
+	auth := &authServiceMock{}
+	services.AuthService = auth
+
+	tryLockDomain = lockHeld(false)
+	elect()
+	refreshCaches()
+
+	assert.Equal(t, 1, auth.lists, "replicas that do not lead still serve requests from their own tokens")
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/publisher"
+)
+
+// dryRun reports whether DRY_RUN is enabled, the scheduler then runs as usual but records every
+// post in the outbox instead of publishing it
+func dryRun() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("DRY_RUN"))
+	return enabled
+}
+
+// outbox is the publisher used in dry-run mode, it stores the posts of an account without
+// calling the network so a schedule can be validated end-to-end
+type outbox struct {
+	accountId int64
+	network   string
+}
+
+func newOutbox(accountId int64, network string) *outbox {
+	return &outbox{accountId: accountId, network: network}
+}
+
+func (o *outbox) Publish(post *publisher.Post) (*publisher.Status, error) {
+	kind, target := string(domain.Original), ""
+
+	switch {
+	case post.Repost != "":
+		kind, target = string(domain.Retweet), post.Repost
+	case post.Quote != "":
+		kind, target = string(domain.Quote), post.Quote
+	}
+
+	var pollOptions []string
+	var pollDuration int
+
+	if post.Poll != nil {
+		pollOptions, pollDuration = post.Poll.Options, post.Poll.DurationMinutes
+	}
+
+	entry, err := domain.OutboxRepo.Create(&domain.OutboxEntry{
+		AccountId:           o.accountId,
+		Network:             o.network,
+		Message:             post.Message,
+		Kind:                kind,
+		Target:              target,
+		ReplyTo:             post.ReplyTo,
+		MediaCount:          len(post.Media),
+		Visibility:          post.Visibility,
+		ContentWarning:      post.ContentWarning,
+		PollOptions:         pollOptions,
+		PollDurationMinutes: pollDuration,
+		CreatedAt:           time.Now().Local(),
+	})
+	if err != nil {
+		return nil, &publisher.Error{Category: publisher.Transient, StatusCode: err.Status(), Err: fmt.Errorf("outbox: %s", err.Message())}
+	}
+
+	id := strconv.FormatInt(entry.Id, 10)
+	return &publisher.Status{Id: id, Url: "outbox://" + id, PostedAt: entry.CreatedAt}, nil
+}
+
+// Delete leaves the outbox as is, it is a record of what would have been posted
+func (o *outbox) Delete(id string) error {
+	return nil
+}
+
+// Verify always succeeds as the outbox needs no credentials
+func (o *outbox) Verify() error {
+	return nil
+}
@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/stretchr/testify/assert"
+)
+
+var createOutboxDomain func(entry *domain.OutboxEntry) (*domain.OutboxEntry, error_utils.MessageErr)
+
+type outboxDbMock struct {
+	domain.OutboxRepoInterface
+}
+
+func (m *outboxDbMock) Create(entry *domain.OutboxEntry) (*domain.OutboxEntry, error_utils.MessageErr) {
+	return createOutboxDomain(entry)
+}
+
+func TestDryRun(t *testing.T) {
+	_ = os.Setenv("DRY_RUN", "true")
+	defer os.Unsetenv("DRY_RUN")
+
+	setup := func() *[]domain.OutboxEntry {
+		recorded := make([]domain.OutboxEntry, 0)
+
+		domain.OutboxRepo = &outboxDbMock{}
+		createOutboxDomain = func(entry *domain.OutboxEntry) (*domain.OutboxEntry, error_utils.MessageErr) {
+			entry.Id = int64(len(recorded) + 1)
+			recorded = append(recorded, *entry)
+			return entry, nil
+		}
+
+		return &recorded
+	}
+
+	t.Run("Records posts instead of publishing them", func(t *testing.T) {
+		recorded := setup()
+		Publisher = getPublisher()
+
+		var saved *domain.Tweet
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		domain.DestinationRepo = &destinationDbMock{}
+		listMediaDomain = noMedia
+		listDestinationsDomain = noDestinations
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: time.Now().Add(-time.Minute), Status: domain.Pending, Visibility: "unlisted"}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			saved = msg
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.Len(t, *recorded, 1)
+		entry := (*recorded)[0]
+		assert.EqualValues(t, 0, entry.AccountId)
+		assert.Equal(t, "twitter", entry.Network)
+		assert.Equal(t, "the message", entry.Message)
+		assert.Equal(t, "unlisted", entry.Visibility)
+
+		assert.EqualValues(t, domain.Posted, saved.Status)
+		assert.Equal(t, "1", saved.RemoteId)
+		assert.Equal(t, "outbox://1", saved.RemoteUrl)
+	})
+
+	t.Run("Accounts are recorded without opening their credentials", func(t *testing.T) {
+		recorded := setup()
+
+		pub, err := newAccountPublisher(&domain.Account{Id: 2, Network: domain.MastodonNetwork, AccessToken: "sealed"})
+		assert.Nil(t, err)
+		assert.Nil(t, pub.Verify())
+
+		status, err := pub.Publish(&publisher.Post{Message: "the reply", ReplyTo: "1", Media: []publisher.Media{{MimeType: "image/png"}}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "1", status.Id)
+		assert.Equal(t, domain.OutboxEntry{Id: 1, AccountId: 2, Network: "mastodon", Message: "the reply", Kind: "original", ReplyTo: "1", MediaCount: 1, CreatedAt: status.PostedAt}, (*recorded)[0])
+	})
+
+	t.Run("Records what a retweet shares", func(t *testing.T) {
+		recorded := setup()
+
+		_, err := newOutbox(0, "twitter").Publish(&publisher.Post{Repost: "1436255364069150720"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "retweet", (*recorded)[0].Kind)
+		assert.Equal(t, "1436255364069150720", (*recorded)[0].Target)
+	})
+
+	t.Run("Records the poll of a tweet", func(t *testing.T) {
+		recorded := setup()
+		Publisher = getPublisher()
+
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+		listMediaDomain = noMedia
+		listDestinationsDomain = noDestinations
+		getPollDomain = func(tweetId int64) (*domain.Poll, error_utils.MessageErr) {
+			return &domain.Poll{Id: 1, TweetId: tweetId, Options: []string{"Tabs", "Spaces"}, DurationMinutes: 1440}, nil
+		}
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "Tabs or spaces?", PostTime: time.Now().Add(-time.Minute), Status: domain.Pending}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.Len(t, *recorded, 1)
+		assert.Equal(t, []string{"Tabs", "Spaces"}, (*recorded)[0].PollOptions)
+		assert.Equal(t, 1440, (*recorded)[0].PollDurationMinutes)
+	})
+
+	t.Run("Outbox errors are retried", func(t *testing.T) {
+		domain.OutboxRepo = &outboxDbMock{}
+		createOutboxDomain = func(entry *domain.OutboxEntry) (*domain.OutboxEntry, error_utils.MessageErr) {
+			return nil, error_utils.InternalServerError("error when trying to save data")
+		}
+
+		_, err := newOutbox(0, "twitter").Publish(&publisher.Post{Message: "the message"})
+
+		assert.Equal(t, "outbox: error when trying to save data", err.Error())
+		assert.Equal(t, publisher.Transient, publisher.Classify(err))
+		assert.Equal(t, http.StatusInternalServerError, err.(*publisher.Error).StatusCode)
+	})
+
+	t.Run("Reported in the status", func(t *testing.T) {
+		assert.True(t, GetStatus().DryRun)
+	})
+}
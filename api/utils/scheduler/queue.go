@@ -0,0 +1,238 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/env"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/getsentry/sentry-go"
+)
+
+const defaultCredentialCheckMinutes = 60
+
+// errCredentialsRejected is returned for tweets that are held back because their account's
+// credentials are known to be rejected
+var errCredentialsRejected = errors.New("credentials rejected, waiting for them to be accepted again")
+
+// Credentials is the last known state of an account's credentials, account 0 is the default account
+type Credentials struct {
+	AccountId int64     `json:"accountId" example:"0"`
+	Valid     bool      `json:"valid" example:"false"`
+	Error     string    `json:"error,omitempty" example:"twitter: 89 Invalid or expired token."`
+	CheckedAt time.Time `json:"checkedAt" example:"2022-09-09T10:30:01.559636Z"`
+	// InvalidSince is when the credentials were first rejected
+	InvalidSince *time.Time `json:"invalidSince,omitempty" example:"2022-09-09T10:30:01.559636Z"`
+}
+
+// queueState tracks the credentials of each account, posting for an account is paused while its
+// credentials are rejected and the other accounts keep posting
+type queueState struct {
+	mu       sync.Mutex
+	accounts map[int64]*Credentials
+}
+
+var queue = &queueState{accounts: make(map[int64]*Credentials)}
+
+// pause holds the account's tweets and raises an alert, repeated calls while paused only update the reason
+func (q *queueState) pause(accountId int64, reason string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now().Local()
+	creds := q.credentials(accountId)
+	creds.Error = reason
+	creds.CheckedAt = now
+
+	if !creds.Valid && creds.InvalidSince != nil {
+		return
+	}
+
+	creds.Valid = false
+	creds.InvalidSince = &now
+
+	message := fmt.Sprintf("Account %d paused, credentials rejected: %s", accountId, reason)
+	fmt.Println(message)
+	sentry.CaptureMessage(message)
+}
+
+// resume records that the account's credentials were accepted
+func (q *queueState) resume(accountId int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	creds := q.credentials(accountId)
+
+	if creds.InvalidSince != nil {
+		fmt.Printf("Credentials of account %d verified, resuming\n", accountId)
+	}
+
+	creds.Valid = true
+	creds.Error = ""
+	creds.CheckedAt = time.Now().Local()
+	creds.InvalidSince = nil
+}
+
+func (q *queueState) isPaused(accountId int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	creds, ok := q.accounts[accountId]
+	return ok && !creds.Valid
+}
+
+// paused returns the accounts whose credentials are currently rejected
+func (q *queueState) paused() []int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ids := make([]int64, 0)
+	for id, creds := range q.accounts {
+		if !creds.Valid {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// forget drops the state of an account that no longer exists
+func (q *queueState) forget(accountId int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.accounts, accountId)
+}
+
+// list returns a copy of every account's credential state ordered by account
+func (q *queueState) list() []Credentials {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	list := make([]Credentials, 0, len(q.accounts))
+	for _, creds := range q.accounts {
+		list = append(list, *creds)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].AccountId < list[j].AccountId })
+	return list
+}
+
+func (q *queueState) reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.accounts = make(map[int64]*Credentials)
+}
+
+// credentials returns the state of the account, creating it when the account was not seen yet.
+// Callers must hold mu
+func (q *queueState) credentials(accountId int64) *Credentials {
+	creds, ok := q.accounts[accountId]
+	if !ok {
+		creds = &Credentials{AccountId: accountId, Valid: true}
+		q.accounts[accountId] = creds
+	}
+
+	return creds
+}
+
+// credentialCheckInterval is how often every account's credentials are verified, configured with
+// CREDENTIAL_CHECK_MINUTES
+func credentialCheckInterval() int {
+	return env.Int("CREDENTIAL_CHECK_MINUTES", defaultCredentialCheckMinutes)
+}
+
+// checkCredentials verifies the credentials of the default account and of every stored account
+func checkCredentials() {
+	checkDefaultAccount()
+
+	accounts, err := domain.AccountRepo.List()
+	if err != nil && err.Status() != http.StatusNotFound {
+		fmt.Println("Credential check:", err.Message())
+		return
+	}
+
+	known := make(map[int64]bool, len(accounts))
+	for _, account := range accounts {
+		known[account.Id] = true
+		verifyCredentials(account.Id)
+	}
+
+	for _, creds := range queue.list() {
+		if creds.AccountId != 0 && !known[creds.AccountId] {
+			queue.forget(creds.AccountId)
+		}
+	}
+}
+
+// checkDefaultAccount verifies the default account when it is configured or still has tweets to
+// post. A setup that only posts through stored accounts leaves it out of the health check
+func checkDefaultAccount() {
+	if !defaultAccountConfigured() {
+		outstanding, err := domain.TweetRepo.HasOutstanding(0)
+		if err != nil {
+			fmt.Println("Credential check:", err.Message())
+			return
+		}
+
+		if !outstanding {
+			queue.forget(0)
+			return
+		}
+	}
+
+	verifyCredentials(0)
+}
+
+// verifyCredentials checks the account's credentials, pausing the account when they are rejected
+// and resuming it once they are accepted. Other errors leave the known state unchanged
+func verifyCredentials(accountId int64) {
+	pub, err := publisherFor(accountId)
+
+	if err == nil {
+		err = pub.Verify()
+	}
+
+	switch {
+	case err == nil:
+		queue.resume(accountId)
+	case publisher.Classify(err) == publisher.Auth:
+		queue.pause(accountId, err.Error())
+	default:
+		fmt.Printf("Unable to verify credentials of account %d: %s\n", accountId, err)
+	}
+}
+
+// verifyPaused re-verifies the credentials of paused accounts on every run so they resume as
+// soon as the credentials are accepted again
+func verifyPaused() {
+	for _, accountId := range queue.paused() {
+		verifyCredentials(accountId)
+	}
+}
+
+// Health reports whether every account's credentials are accepted, exposed on the health endpoint
+type Health struct {
+	Healthy     bool          `json:"healthy" example:"false"`
+	Credentials []Credentials `json:"credentials"`
+}
+
+// GetHealth returns the credential health of every account that was checked
+func GetHealth() Health {
+	health := Health{Healthy: true, Credentials: queue.list()}
+
+	for _, creds := range health.Credentials {
+		if !creds.Valid {
+			health.Healthy = false
+		}
+	}
+
+	return health
+}
@@ -0,0 +1,200 @@
+package scheduler
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCredentials(t *testing.T) {
+	rejected := &publisher.Error{Category: publisher.Auth, StatusCode: 401, Err: errors.New("mastodon: 401 The access token is invalid")}
+	_ = os.Setenv("ACCESS_TOKEN", "at")
+	_ = os.Setenv("ACCESS_TOKEN_SECRET", "ats")
+	defer os.Unsetenv("ACCESS_TOKEN")
+	defer os.Unsetenv("ACCESS_TOKEN_SECRET")
+
+	setup := func() (*publisher.Recorder, map[int64]*publisher.Recorder) {
+		fallback := publisher.NewRecorder()
+		accounts := map[int64]*publisher.Recorder{2: publisher.NewRecorder(), 3: publisher.NewRecorder()}
+
+		Publisher = fallback
+		domain.AccountRepo = &accountDbMock{}
+
+		accountPublisher = func(account *domain.Account) (publisher.Publisher, error) {
+			return accounts[account.Id], nil
+		}
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return &domain.Account{Id: id}, nil
+		}
+		listAccountsDomain = func() ([]domain.Account, error_utils.MessageErr) {
+			return []domain.Account{{Id: 2}, {Id: 3}}, nil
+		}
+
+		return fallback, accounts
+	}
+
+	t.Run("Pauses only the rejected account", func(t *testing.T) {
+		_, accounts := setup()
+		defer func() { accountPublisher = newAccountPublisher }()
+		defer queue.reset()
+		accounts[3].VerifyErr = rejected
+
+		checkCredentials()
+
+		credentials := queue.list()
+		assert.Len(t, credentials, 3)
+		assert.True(t, credentials[0].Valid)
+		assert.True(t, credentials[1].Valid)
+		assert.False(t, credentials[2].Valid)
+		assert.Equal(t, "mastodon: 401 The access token is invalid", credentials[2].Error)
+		assert.Equal(t, []int64{3}, queue.paused())
+	})
+
+	t.Run("Resumes once the credentials are accepted", func(t *testing.T) {
+		_, accounts := setup()
+		defer func() { accountPublisher = newAccountPublisher }()
+		defer queue.reset()
+		accounts[2].VerifyErr = rejected
+
+		checkCredentials()
+		assert.True(t, queue.isPaused(2))
+
+		accounts[2].VerifyErr = nil
+		verifyPaused()
+
+		assert.False(t, queue.isPaused(2))
+	})
+
+	t.Run("Unreachable network keeps the known state", func(t *testing.T) {
+		fallback, _ := setup()
+		defer func() { accountPublisher = newAccountPublisher }()
+		defer queue.reset()
+		queue.pause(0, "twitter: 89 Invalid or expired token.")
+		fallback.VerifyErr = errors.New("dial tcp: i/o timeout")
+
+		checkCredentials()
+
+		assert.True(t, queue.isPaused(0))
+	})
+
+	t.Run("Forgets deleted accounts", func(t *testing.T) {
+		setup()
+		defer func() { accountPublisher = newAccountPublisher }()
+		defer queue.reset()
+		queue.pause(9, "gone")
+		listAccountsDomain = func() ([]domain.Account, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no records found")
+		}
+
+		checkCredentials()
+
+		assert.Len(t, queue.list(), 1)
+		assert.False(t, queue.isPaused(9))
+	})
+}
+
+func TestCheckDefaultAccount(t *testing.T) {
+	rejected := &publisher.Error{Category: publisher.Auth, StatusCode: 401, Err: errors.New("twitter: 89 Invalid or expired token.")}
+
+	setup := func(outstanding bool) *publisher.Recorder {
+		fallback := publisher.NewRecorder()
+		fallback.VerifyErr = rejected
+		Publisher = fallback
+
+		domain.TweetRepo = &tweetDbMock{}
+		domain.AccountRepo = &accountDbMock{}
+		hasOutstandingDomain = func(accountId int64) (bool, error_utils.MessageErr) {
+			assert.EqualValues(t, 0, accountId)
+			return outstanding, nil
+		}
+		listAccountsDomain = func() ([]domain.Account, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no records found")
+		}
+
+		return fallback
+	}
+
+	t.Run("Leaves out a default account without credentials", func(t *testing.T) {
+		setup(false)
+		defer queue.reset()
+		queue.pause(0, "twitter: 89 Invalid or expired token.")
+
+		checkCredentials()
+
+		assert.Empty(t, queue.list())
+		assert.True(t, GetHealth().Healthy)
+	})
+
+	t.Run("Checks the default account while it has tweets to post", func(t *testing.T) {
+		setup(true)
+		defer queue.reset()
+
+		checkCredentials()
+
+		assert.True(t, queue.isPaused(0))
+	})
+
+	t.Run("Checks the default account once its credentials are set", func(t *testing.T) {
+		setup(false)
+		defer queue.reset()
+		_ = os.Setenv("ACCESS_TOKEN", "at")
+		_ = os.Setenv("ACCESS_TOKEN_SECRET", "ats")
+		defer os.Unsetenv("ACCESS_TOKEN")
+		defer os.Unsetenv("ACCESS_TOKEN_SECRET")
+
+		checkCredentials()
+
+		assert.True(t, queue.isPaused(0))
+	})
+}
+
+func TestPausedAccount(t *testing.T) {
+	postTime := time.Now().Add(-time.Minute)
+
+	t.Run("Other accounts keep posting", func(t *testing.T) {
+		fallback := publisher.NewRecorder()
+		account := publisher.NewRecorder()
+		Publisher = fallback
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		domain.AccountRepo = &accountDbMock{}
+		domain.DestinationRepo = &destinationDbMock{}
+		listMediaDomain = noMedia
+		listDestinationsDomain = noDestinations
+		defer queue.reset()
+
+		fallback.VerifyErr = &publisher.Error{Category: publisher.Auth, Code: 89, Err: errors.New("twitter: 89 Invalid or expired token.")}
+		queue.pause(0, fallback.VerifyErr.Error())
+
+		accountPublisher = func(a *domain.Account) (publisher.Publisher, error) {
+			return account, nil
+		}
+		defer func() { accountPublisher = newAccountPublisher }()
+
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return &domain.Account{Id: id}, nil
+		}
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{
+				{Id: 1, Message: "default", PostTime: postTime, Status: domain.Pending},
+				{Id: 2, Message: "account", PostTime: postTime, Status: domain.Pending, AccountId: 2},
+			}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.Empty(t, fallback.Published())
+		assert.Equal(t, []publisher.Post{{Message: "account"}}, account.Published())
+	})
+}
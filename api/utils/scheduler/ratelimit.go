@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+)
+
+// errRateLimited is returned for tweets that are held back because their account's rate limit
+// window is exhausted
+var errRateLimited = errors.New("rate limited, waiting for the window to reset")
+
+// AccountRateLimit is the last rate limit window reported for an account, account 0 is the default account
+type AccountRateLimit struct {
+	AccountId int64               `json:"accountId" example:"0"`
+	Limited   bool                `json:"limited" example:"true"`
+	Window    publisher.RateLimit `json:"window"`
+}
+
+// rateLimitState holds the last rate limit window reported for each account. Every account posts to
+// a single network, so the account identifies the window. Posting for an account stops while its
+// window is exhausted and resumes once it resets, the other accounts keep posting
+type rateLimitState struct {
+	mu      sync.Mutex
+	windows map[int64]*publisher.RateLimit
+}
+
+var rateLimit = &rateLimitState{windows: make(map[int64]*publisher.RateLimit)}
+
+// update stores the account's latest window, responses without rate limit information are ignored
+func (r *rateLimitState) update(accountId int64, window *publisher.RateLimit) {
+	if window == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.windows[accountId] = window
+}
+
+// limited reports whether posting for the account has to wait for its window to reset
+func (r *rateLimitState) limited(accountId int64, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	window, ok := r.windows[accountId]
+	return ok && window.Exhausted(now)
+}
+
+// list returns a copy of every account's window ordered by account
+func (r *rateLimitState) list(now time.Time) []AccountRateLimit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := make([]AccountRateLimit, 0, len(r.windows))
+	for id, window := range r.windows {
+		list = append(list, AccountRateLimit{AccountId: id, Limited: window.Exhausted(now), Window: *window})
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].AccountId < list[j].AccountId })
+	return list
+}
+
+func (r *rateLimitState) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.windows = make(map[int64]*publisher.RateLimit)
+}
+
+// Status is a snapshot of the scheduler queue, exposed on the admin status endpoint
+type Status struct {
+	// RateLimited is set while any account waits for its rate limit window to reset
+	RateLimited bool `json:"rateLimited"`
+	// RateLimits lists the last rate limit window reported for each account
+	RateLimits []AccountRateLimit `json:"rateLimits"`
+	// Credentials lists the last known state of each account's credentials
+	Credentials []Credentials `json:"credentials"`
+	// DryRun is set when posts are recorded in the outbox instead of being published
+	DryRun bool `json:"dryRun"`
+	// NextRun is when the dispatcher next posts the tweets that are due
+	NextRun *time.Time `json:"nextRun,omitempty"`
+	// Replica identifies this process in the claims it holds on the tweets it posts
+	Replica string `json:"replica" example:"web.1-3f2a9c1e"`
+	// Leadership reports whether this replica holds the leader lock and runs the singleton jobs
+	Leadership Leadership `json:"leadership"`
+}
+
+// GetStatus returns the current rate limit and credential state of the queue, when it next posts
+// and whether this replica leads
+func GetStatus() Status {
+	status := Status{
+		RateLimits:  rateLimit.list(time.Now()),
+		Credentials: queue.list(),
+		DryRun:      dryRun(),
+		NextRun:     dispatch.nextRun(),
+		Replica:     replicaId,
+		Leadership:  leadership.current(),
+	}
+
+	for _, window := range status.RateLimits {
+		if window.Limited {
+			status.RateLimited = true
+		}
+	}
+
+	return status
+}
+
+// rateLimited records the exhausted window of a rate limit error against the account, the tweet stays
+// pending without using up an attempt and is picked up again once the window resets
+func rateLimited(accountId int64, err error) bool {
+	window, ok := publisher.RateLimited(err)
+
+	if !ok {
+		return false
+	}
+
+	rateLimit.update(accountId, window)
+	fmt.Printf("Account %d rate limited, posting resumes at %s\n", accountId, window.ResetAt.Local())
+	return true
+}
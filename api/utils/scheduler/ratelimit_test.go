@@ -0,0 +1,155 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	postTime := time.Now().Add(-time.Minute)
+	pending := func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+		return []domain.Tweet{
+			{Id: 1, Message: "one", PostTime: postTime, Status: domain.Pending},
+			{Id: 2, Message: "two", PostTime: postTime, Status: domain.Pending},
+		}, nil
+	}
+
+	t.Run("Stops posting until the window resets", func(t *testing.T) {
+		var updates int
+		resetAt := time.Now().Add(10 * time.Minute)
+		recorder := publisher.NewRecorder()
+		recorder.PublishErr = &publisher.Error{Category: publisher.Transient, Code: 88, RateLimit: &publisher.RateLimit{Limit: 300, ResetAt: resetAt}}
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+		getPendingTweetsDomain = pending
+		defer rateLimit.reset()
+
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updates++
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.Equal(t, 0, updates, "a rate limit should not use up an attempt")
+
+		status := GetStatus()
+		assert.True(t, status.RateLimited)
+		assert.Len(t, status.RateLimits, 1)
+		assert.EqualValues(t, 0, status.RateLimits[0].AccountId)
+		assert.Equal(t, resetAt, status.RateLimits[0].Window.ResetAt)
+		assert.Equal(t, 0, status.RateLimits[0].Window.Remaining)
+
+		recorder.PublishErr = nil
+		getTweets()
+
+		assert.Len(t, recorder.Published(), 0)
+	})
+
+	t.Run("Resumes once the window has reset", func(t *testing.T) {
+		Publisher = publisher.NewRecorder()
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+		getPendingTweetsDomain = pending
+		defer rateLimit.reset()
+
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		rateLimit.update(0, &publisher.RateLimit{Limit: 300, ResetAt: time.Now().Add(-time.Second)})
+		getTweets()
+
+		assert.Len(t, Publisher.(*publisher.Recorder).Published(), 2)
+		assert.False(t, GetStatus().RateLimited)
+	})
+
+	t.Run("Other accounts keep posting", func(t *testing.T) {
+		accounts := map[int64]*publisher.Recorder{2: publisher.NewRecorder(), 3: publisher.NewRecorder()}
+		accounts[2].PublishErr = &publisher.Error{Category: publisher.Transient, StatusCode: 429, RateLimit: &publisher.RateLimit{Limit: 300, ResetAt: time.Now().Add(10 * time.Minute)}}
+		Publisher = publisher.NewRecorder()
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+		domain.AccountRepo = &accountDbMock{}
+		defer rateLimit.reset()
+		defer func() { accountPublisher = newAccountPublisher }()
+
+		accountPublisher = func(account *domain.Account) (publisher.Publisher, error) {
+			return accounts[account.Id], nil
+		}
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return &domain.Account{Id: id}, nil
+		}
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{
+				{Id: 1, Message: "one", PostTime: postTime, Status: domain.Pending, AccountId: 2},
+				{Id: 2, Message: "two", PostTime: postTime, Status: domain.Pending, AccountId: 3},
+				{Id: 3, Message: "three", PostTime: postTime, Status: domain.Pending, AccountId: 2},
+			}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.Equal(t, []publisher.Post{{Message: "two"}}, accounts[3].Published())
+		assert.Empty(t, accounts[2].Published())
+
+		status := GetStatus()
+		assert.True(t, status.RateLimited)
+		assert.Len(t, status.RateLimits, 1)
+		assert.EqualValues(t, 2, status.RateLimits[0].AccountId)
+
+		accounts[2].PublishErr = nil
+		getTweets()
+
+		assert.Empty(t, accounts[2].Published(), "the limited account should wait for its window to reset")
+	})
+}
+
+func TestGetStatus(t *testing.T) {
+	t.Run("Idle queue", func(t *testing.T) {
+		status := GetStatus()
+
+		assert.False(t, status.RateLimited)
+		assert.Empty(t, status.RateLimits)
+		assert.Empty(t, status.Credentials)
+	})
+
+	t.Run("Paused account", func(t *testing.T) {
+		queue.pause(2, "invalid token")
+		queue.resume(0)
+		defer queue.reset()
+
+		status := GetStatus()
+
+		assert.Len(t, status.Credentials, 2)
+		assert.True(t, status.Credentials[0].Valid)
+		assert.EqualValues(t, 2, status.Credentials[1].AccountId)
+		assert.False(t, status.Credentials[1].Valid)
+		assert.Equal(t, "invalid token", status.Credentials[1].Error)
+		assert.NotNil(t, status.Credentials[1].InvalidSince)
+	})
+}
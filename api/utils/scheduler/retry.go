@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/env"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/getsentry/sentry-go"
+)
+
+const (
+	defaultMaxAttempts    = 5
+	defaultBackoffMinutes = 5
+	maxBackoff            = 24 * time.Hour
+)
+
+// maxAttempts is the number of failed publish attempts before a tweet is marked as failed,
+// configured with MAX_ATTEMPTS
+func maxAttempts() int {
+	return env.Int("MAX_ATTEMPTS", defaultMaxAttempts)
+}
+
+// backoff returns the delay before the next attempt, doubling the RETRY_BACKOFF_MINUTES
+// base delay for every attempt already made
+func backoff(attempts int) time.Duration {
+	base := time.Duration(env.Int("RETRY_BACKOFF_MINUTES", defaultBackoffMinutes)) * time.Minute
+	delay := base
+
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+
+	return delay
+}
+
+// recordFailure stores the publish error on the tweet and either schedules the next
+// attempt or, for permanent errors and once the retry limit is reached, moves the tweet to Failed
+func recordFailure(tw domain.Tweet, postErr error) domain.Tweet {
+	now := time.Now().Local()
+
+	tw.Attempts++
+	tw.LastError = postErr.Error()
+	tw.Modified = now
+
+	tw.NextAttemptAt = retryAt(tw.Attempts, postErr, now)
+
+	if tw.NextAttemptAt == nil {
+		tw.Status = domain.Failed
+
+		message := fmt.Sprintf("Tweet %d failed after %d attempts: %s", tw.Id, tw.Attempts, tw.LastError)
+		fmt.Println(message)
+		sentry.CaptureMessage(message)
+	}
+
+	if _, upErr := domain.TweetRepo.Update(&tw); upErr != nil {
+		fmt.Println("error updating failed entry", upErr.Error(), upErr.Message())
+	}
+
+	return tw
+}
+
+// retryAt returns when the next attempt is due, or nil when the error is permanent or the retry limit is reached
+func retryAt(attempts int, postErr error, now time.Time) *time.Time {
+	if publisher.Classify(postErr) == publisher.Permanent || attempts >= maxAttempts() {
+		return nil
+	}
+
+	next := now.Add(backoff(attempts))
+	return &next
+}
@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	_ = os.Setenv("RETRY_BACKOFF_MINUTES", "")
+
+	t.Run("Doubles per attempt", func(t *testing.T) {
+		assert.Equal(t, 5*time.Minute, backoff(1))
+		assert.Equal(t, 10*time.Minute, backoff(2))
+		assert.Equal(t, 40*time.Minute, backoff(4))
+	})
+
+	t.Run("Is capped", func(t *testing.T) {
+		assert.Equal(t, maxBackoff, backoff(20))
+	})
+
+	t.Run("Uses configured base", func(t *testing.T) {
+		_ = os.Setenv("RETRY_BACKOFF_MINUTES", "1")
+		defer os.Unsetenv("RETRY_BACKOFF_MINUTES")
+
+		assert.Equal(t, 2*time.Minute, backoff(2))
+	})
+}
+
+func TestRecordFailure(t *testing.T) {
+	updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+		return msg, nil
+	}
+
+	t.Run("Schedules next attempt", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+
+		tw := recordFailure(domain.Tweet{Id: 1, Status: domain.Pending, Attempts: 1}, errors.New("boom"))
+
+		assert.EqualValues(t, domain.Pending, tw.Status)
+		assert.Equal(t, 2, tw.Attempts)
+		assert.Equal(t, "boom", tw.LastError)
+		assert.NotNil(t, tw.NextAttemptAt)
+	})
+
+	t.Run("Fails after the retry limit", func(t *testing.T) {
+		domain.TweetRepo = &tweetDbMock{}
+		_ = os.Setenv("MAX_ATTEMPTS", "3")
+		defer os.Unsetenv("MAX_ATTEMPTS")
+
+		tw := recordFailure(domain.Tweet{Id: 1, Status: domain.Pending, Attempts: 2}, errors.New("boom"))
+
+		assert.EqualValues(t, domain.Failed, tw.Status)
+		assert.Equal(t, 3, tw.Attempts)
+		assert.Nil(t, tw.NextAttemptAt)
+	})
+}
@@ -2,31 +2,51 @@ package scheduler
 
 import (
 	"fmt"
+	"net/http"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/RemeJuan/lattr/domain"
 	"github.com/RemeJuan/lattr/services"
+	"github.com/RemeJuan/lattr/utils/bluesky"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/mastodon"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/RemeJuan/lattr/utils/secrets"
 	"github.com/RemeJuan/lattr/utils/twitter"
 	"github.com/RemeJuan/lattr/utils/webhook"
+	"github.com/getsentry/sentry-go"
 	"github.com/go-co-op/gocron"
 )
 
-func Scheduler() {
-	var schedule string
-	s := gocron.NewScheduler(time.Local)
-	cr := os.Getenv("CRON_SCHEDULE")
+// Publisher is the destination tweets without an account are posted to
+var Publisher publisher.Publisher
 
-	if len(cr) == 0 {
-		schedule = "*/5 6-18 * * *"
-	} else {
-		schedule = cr
+// accountPublisher creates the publisher that posts as an account, swapped out in tests
+var accountPublisher = newAccountPublisher
+
+// Scheduler starts the dispatcher that posts each tweet at its post time, re-armed whenever tweets
+// change through the tweet service, along with the jobs that run on an interval. Jobs that must only
+// run once across replicas are left to the leader
+func Scheduler() {
+	Publisher = getPublisher()
+	if dryRun() {
+		fmt.Println("Dry-run mode, posts are recorded in the outbox instead of being published")
 	}
 
-	_, err := s.Cron(schedule).Do(getTweets)
-	_, _ = s.Every(1).Day().Do(webhook.GetSchedules)
-	_, _ = s.Every(1).Day().Do(services.AuthService.List)
+	// the leader is known before the first run, so a single replica runs the singleton jobs from the start
+	elect()
+
+	services.TweetsChanged = dispatch.Rearm
+	go dispatch.run(getTweets, nil)
+
+	s := gocron.NewScheduler(time.Local)
+
+	_, err := s.Every(uint64(leaderCheckInterval())).Seconds().Do(elect)
+	_, _ = s.Every(1).Minutes().SingletonMode().Do(singleton(deleteExpired))
+	_, _ = s.Every(1).Day().Do(refreshCaches)
+	// runs at startup and then on the interval, posting stops for an account whose credentials are rejected
+	_, _ = s.Every(uint64(credentialCheckInterval())).Minutes().Do(checkCredentials)
 
 	if err != nil {
 		fmt.Println("Cron err", err)
@@ -36,42 +56,278 @@ func Scheduler() {
 	s.StartAsync()
 }
 
+// refreshCaches reloads the webhook time slots and the active API tokens this replica keeps in memory.
+// Every replica serves requests from its own copy, so every replica refreshes it. Neither refresh
+// writes anything the other replicas read, there is no part of them to leave to the leader
+func refreshCaches() {
+	webhook.GetSchedules()
+
+	if _, err := services.AuthService.List(); err != nil && err.Status() != http.StatusNotFound {
+		fmt.Println("Refreshing API tokens:", err.Message())
+	}
+}
+
 func getTweets() {
-	twts, err := domain.TweetRepo.GetPending()
+	verifyPaused()
+
+	// skipping or re-slotting the backlog on several replicas would apply the policy more than once
+	if leadership.isLeader() {
+		handleOverdue(time.Now().Local())
+	}
+
+	twts, err := claimPending()
 
 	if err != nil {
 		fmt.Println("Scheduler:", err)
 		return
 	}
+	defer releaseClaims()
 
-	if ShouldPost(twts[0]) {
-		var isDuplicate bool
+	threads := make(map[string]bool)
 
-		tw := twts[0]
-		fmt.Println("Posting tweet:", tw.Message)
-		postErr := twitter.CreateTweet(tw.Message)
+	for _, tw := range twts {
+		if !ShouldPost(tw) {
+			continue
+		}
 
-		if postErr != nil {
-			fmt.Println("Posting error: ", postErr)
-			isDuplicate = strings.Contains(postErr.Error(), "187")
+		if tw.IsThread() {
+			if !threads[tw.ThreadId] {
+				threads[tw.ThreadId] = true
+				postThread(tw.ThreadId)
+			}
+			continue
+		}
+
+		_, _ = postTweet(tw, nil)
+	}
+}
+
+// postThread posts every outstanding part of a thread as a reply to the part before it.
+// Posting stops at the first failure, the remaining parts stay pending so the next run
+// resumes from the failed part, once its backoff has passed, and continues the chain
+// from the last posted status. Cross-posted parts reply to the previous part posted by the same account.
+// The outstanding parts are claimed first and posting stops at a part another replica holds
+func postThread(threadId string) {
+	if _, err := domain.TweetRepo.ClaimThread(threadId, replicaId, claimLease()); err != nil {
+		fmt.Println("Scheduler:", err)
+		return
+	}
+
+	parts, err := domain.TweetRepo.GetThread(threadId)
+
+	if err != nil {
+		fmt.Println("Scheduler:", err)
+		return
+	}
+
+	replies := make(map[int64]string)
+
+	for i, part := range parts {
+		// deleted parts were posted, the chain continues from them
+		if part.Status == domain.Posted || part.Status == domain.Deleted {
+			if loadErr := loadDestinations(&part); loadErr != nil {
+				fmt.Println("Scheduler:", loadErr)
+				return
+			}
+			addReplies(replies, part)
+			continue
 		}
 
-		if postErr == nil || isDuplicate {
-			if isDuplicate {
-				fmt.Println("Marking duplicate as posted")
-			} else {
-				fmt.Println("Tweeted", isDuplicate, tw.Message)
+		if part.Status == domain.Failed {
+			fmt.Printf("Thread %s is halted at failed part %d of %d\n", threadId, i+1, len(parts))
+			return
+		}
+
+		if part.Status == domain.Posting {
+			if part.ClaimedBy != replicaId {
+				fmt.Printf("Thread %s part %d of %d is being posted by %s\n", threadId, i+1, len(parts), part.ClaimedBy)
+				return
 			}
+			part.Status = domain.Pending
+		}
+
+		if part.NextAttemptAt != nil && part.NextAttemptAt.After(time.Now()) {
+			return
+		}
 
-			tw.Status = domain.Posted
-			tw.Modified = time.Now().Local()
-			_, upErr := domain.TweetRepo.Update(&tw)
+		posted, postErr := postTweet(part, replies)
 
-			if upErr != nil {
-				fmt.Println("error updating tweeted entry", upErr.Error(), upErr.Message())
+		if postErr != nil {
+			if _, limited := publisher.RateLimited(postErr); limited || postErr == errRateLimited || postErr == errCredentialsRejected || postErr == errClaimLost {
+				return
 			}
+
+			message := fmt.Sprintf("Thread %s halted at part %d of %d: %s", threadId, i+1, len(parts), postErr.Error())
+			fmt.Println(message)
+			sentry.CaptureMessage(message)
+			return
+		}
+
+		// a destination that is still waiting for a retry holds back the rest of the thread
+		if posted.Status != domain.Posted {
+			return
+		}
+
+		addReplies(replies, *posted)
+	}
+}
+
+// postTweet publishes the tweet to each of its destinations when it is cross-posted, or otherwise
+// to its account. replies holds the status to reply to for each account
+func postTweet(tw domain.Tweet, replies map[int64]string) (*domain.Tweet, error) {
+	if err := loadDestinations(&tw); err != nil {
+		fmt.Println("error loading tweet destinations", err.Error(), err.Message())
+		return nil, err
+	}
+
+	if len(tw.Destinations) > 0 {
+		return crossPost(tw, replies)
+	}
+
+	return publishTweet(tw, replies[tw.AccountId])
+}
+
+// publishTweet posts a single tweet, as a reply to replyTo when set, and marks it as posted.
+// Duplicates count as posted, auth errors pause the account and rate limits hold back the account's
+// tweets without using up an attempt and any other failure is recorded against the tweet so it is
+// retried or failed
+func publishTweet(tw domain.Tweet, replyTo string) (*domain.Tweet, error) {
+	var isDuplicate bool
+
+	if queue.isPaused(tw.AccountId) {
+		return nil, errCredentialsRejected
+	}
+
+	if rateLimit.limited(tw.AccountId, time.Now()) {
+		return nil, errRateLimited
+	}
+
+	post, buildErr := buildPost(tw)
+
+	if buildErr != nil {
+		fmt.Println("error loading tweet media", buildErr.Error(), buildErr.Message())
+		return nil, buildErr
+	}
+
+	post.ReplyTo = replyTo
+
+	pub, pubErr := publisherFor(tw.AccountId)
+
+	if pubErr != nil {
+		fmt.Println("error loading tweet account", pubErr)
+		recordFailure(tw, pubErr)
+		return nil, pubErr
+	}
+
+	waitForSpacing()
+
+	if !renewClaim(tw.Id) {
+		return nil, errClaimLost
+	}
+
+	fmt.Println("Posting tweet:", tw.Message)
+	status, postErr := pub.Publish(post)
+
+	if postErr != nil {
+		fmt.Println("Posting error: ", postErr)
+
+		if rateLimited(tw.AccountId, postErr) {
+			return nil, postErr
+		}
+
+		switch publisher.Classify(postErr) {
+		case publisher.Duplicate:
+			isDuplicate = true
+		case publisher.Auth:
+			queue.pause(tw.AccountId, postErr.Error())
+			return nil, postErr
+		default:
+			recordFailure(tw, postErr)
+			return nil, postErr
+		}
+	}
+
+	if isDuplicate {
+		fmt.Println("Marking duplicate as posted")
+	} else {
+		fmt.Println("Tweeted", tw.Message)
+		rateLimit.update(tw.AccountId, status.RateLimit)
+		postedAt := status.PostedAt.Local()
+		tw.RemoteId = status.Id
+		tw.RemoteUrl = status.Url
+		tw.PostedAt = &postedAt
+		tw.ScheduleDeletion()
+	}
+
+	lastPublished = time.Now()
+
+	tw.Status = domain.Posted
+	tw.NextAttemptAt = nil
+	tw.Modified = time.Now().Local()
+	_, upErr := domain.TweetRepo.Update(&tw)
+
+	if upErr != nil {
+		fmt.Println("error updating tweeted entry", upErr.Error(), upErr.Message())
+	}
+
+	return &tw, nil
+}
+
+// buildPost converts the tweet and its attached media into a publishable post
+func buildPost(tweet domain.Tweet) (*publisher.Post, error_utils.MessageErr) {
+	post := &publisher.Post{Message: tweet.Message, Visibility: tweet.Visibility, ContentWarning: tweet.ContentWarning}
+
+	switch tweet.Kind {
+	case domain.Retweet:
+		return &publisher.Post{Repost: tweet.Target}, nil
+	case domain.Quote:
+		post.Quote = tweet.Target
+	}
+
+	media, err := domain.MediaRepo.List(tweet.Id)
+	if err != nil && err.Status() != http.StatusNotFound {
+		return nil, err
+	}
+
+	for _, m := range media {
+		post.Media = append(post.Media, publisher.Media{MimeType: m.MimeType, AltText: m.AltText, Data: m.Data})
+	}
+
+	poll, err := domain.PollRepo.Get(tweet.Id)
+	if err != nil && err.Status() != http.StatusNotFound {
+		return nil, err
+	}
+
+	if poll != nil {
+		post.Poll = &publisher.Poll{Options: poll.Options, DurationMinutes: poll.DurationMinutes}
+	}
+
+	return post, nil
+}
+
+// publisherFor returns the publisher for the account, or the default one when there is none.
+// Accounts in use cannot be deleted, an account that is not found is never going to be, so it
+// fails the tweet rather than retrying it
+func publisherFor(accountId int64) (publisher.Publisher, error) {
+	if accountId == 0 {
+		return Publisher, nil
+	}
+
+	account, err := domain.AccountRepo.Get(accountId)
+	if err != nil {
+		category := publisher.Transient
+		if err.Status() == http.StatusNotFound {
+			category = publisher.Permanent
 		}
+		return nil, &publisher.Error{Category: category, Err: fmt.Errorf("account %d: %s", accountId, err.Message())}
+	}
+
+	pub, pubErr := accountPublisher(account)
+	if pubErr != nil {
+		return nil, &publisher.Error{Category: publisher.Transient, Err: fmt.Errorf("account %d: %w", accountId, pubErr)}
 	}
+
+	return pub, nil
 }
 
 func ShouldPost(tweet domain.Tweet) bool {
@@ -79,3 +335,72 @@ func ShouldPost(tweet domain.Tweet) bool {
 
 	return now.After(tweet.PostTime.Local())
 }
+
+// getPublisher selects the publisher based on the PUBLISHER env, defaulting to Twitter. In dry-run
+// mode posts go to the outbox instead
+func getPublisher() publisher.Publisher {
+	if dryRun() {
+		return newOutbox(0, string(domain.DefaultNetwork()))
+	}
+
+	switch os.Getenv("PUBLISHER") {
+	case "memory":
+		return publisher.NewRecorder()
+	case "mastodon":
+		return mastodon.NewPublisher()
+	case "bluesky":
+		return bluesky.NewPublisher()
+	default:
+		return twitter.NewPublisher()
+	}
+}
+
+// defaultAccountConfigured reports whether the environment holds credentials for the default publisher
+func defaultAccountConfigured() bool {
+	switch os.Getenv("PUBLISHER") {
+	case "memory":
+		return true
+	case "mastodon":
+		return os.Getenv("MASTODON_ACCESS_TOKEN") != ""
+	case "bluesky":
+		return os.Getenv("BLUESKY_HANDLE") != "" && os.Getenv("BLUESKY_APP_PASSWORD") != ""
+	default:
+		return os.Getenv("ACCESS_TOKEN") != "" && os.Getenv("ACCESS_TOKEN_SECRET") != ""
+	}
+}
+
+// newAccountPublisher opens the account's sealed credentials, this is the only place they are decrypted
+func newAccountPublisher(account *domain.Account) (publisher.Publisher, error) {
+	if os.Getenv("PUBLISHER") == "memory" {
+		return Publisher, nil
+	}
+
+	if dryRun() {
+		return newOutbox(account.Id, string(account.Network)), nil
+	}
+
+	keyring, err := secrets.LoadKeyring()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range account.Credentials() {
+		if *field, err = keyring.Open(*field); err != nil {
+			return nil, err
+		}
+	}
+
+	switch account.Network {
+	case domain.MastodonNetwork:
+		return mastodon.NewAccountPublisher(account.InstanceUrl, account.AccessToken), nil
+	case domain.BlueskyNetwork:
+		return bluesky.NewAccountPublisher(account.InstanceUrl, account.Handle, account.AccessToken), nil
+	}
+
+	return twitter.NewAccountPublisher(account.Id, &twitter.Credentials{
+		ConsumerKey:       account.ConsumerKey,
+		ConsumerSecret:    account.ConsumerSecret,
+		AccessToken:       account.AccessToken,
+		AccessTokenSecret: account.AccessTokenSecret,
+	}, account.ApiVersion), nil
+}
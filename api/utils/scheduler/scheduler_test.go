@@ -1,15 +1,144 @@
 package scheduler
 
 import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/RemeJuan/lattr/domain"
+	"github.com/RemeJuan/lattr/utils/bluesky"
+	"github.com/RemeJuan/lattr/utils/error_utils"
+	"github.com/RemeJuan/lattr/utils/mastodon"
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/RemeJuan/lattr/utils/secrets"
+	"github.com/RemeJuan/lattr/utils/twitter"
 	"github.com/stretchr/testify/assert"
 )
 
 const layout = "2021-07-18 12:55:50 +0200 SAST"
 
+var (
+	getPendingTweetsDomain  func(limit int) ([]domain.Tweet, error_utils.MessageErr)
+	getOverdueDomain        func(cutoff time.Time) ([]domain.Tweet, error_utils.MessageErr)
+	getDueDeletionsDomain   func(now time.Time) ([]domain.Tweet, error_utils.MessageErr)
+	getLastTweetDomain      func() (*domain.Tweet, error_utils.MessageErr)
+	getNextDueDomain        func() (*time.Time, error_utils.MessageErr)
+	hasOutstandingDomain    func(accountId int64) (bool, error_utils.MessageErr)
+	updateTweetDomain       func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr)
+	listMediaDomain         func(tweetId int64) ([]domain.Media, error_utils.MessageErr)
+	getPollDomain           func(tweetId int64) (*domain.Poll, error_utils.MessageErr)
+	getThreadDomain         func(threadId string) ([]domain.Tweet, error_utils.MessageErr)
+	getAccountDomain        func(id int64) (*domain.Account, error_utils.MessageErr)
+	listAccountsDomain      func() ([]domain.Account, error_utils.MessageErr)
+	listDestinationsDomain  func(tweetId int64) ([]domain.Destination, error_utils.MessageErr)
+	updateDestinationDomain func(destination *domain.Destination) (*domain.Destination, error_utils.MessageErr)
+)
+
+// releasedBy lists the replicas that released their claims
+var releasedBy []string
+
+// lostClaims lists the tweets whose claim was taken over by another replica
+var lostClaims map[int64]bool
+
+type tweetDbMock struct {
+	domain.TweetRepoInterface
+}
+
+func (m *tweetDbMock) GetPending(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+	return getPendingTweetsDomain(limit)
+}
+func (m *tweetDbMock) GetOverdue(cutoff time.Time) ([]domain.Tweet, error_utils.MessageErr) {
+	return getOverdueDomain(cutoff)
+}
+func (m *tweetDbMock) GetDueDeletions(now time.Time) ([]domain.Tweet, error_utils.MessageErr) {
+	return getDueDeletionsDomain(now)
+}
+func (m *tweetDbMock) GetLast() (*domain.Tweet, error_utils.MessageErr) {
+	return getLastTweetDomain()
+}
+func (m *tweetDbMock) ClaimPending(limit int, owner string, lease time.Duration) ([]domain.Tweet, error_utils.MessageErr) {
+	return getPendingTweetsDomain(limit)
+}
+func (m *tweetDbMock) ClaimThread(threadId string, owner string, lease time.Duration) (int64, error_utils.MessageErr) {
+	return 1, nil
+}
+func (m *tweetDbMock) ReleaseClaims(owner string) (int64, error_utils.MessageErr) {
+	releasedBy = append(releasedBy, owner)
+	return 0, nil
+}
+func (m *tweetDbMock) RenewClaim(id int64, owner string, lease time.Duration) (bool, error_utils.MessageErr) {
+	return !lostClaims[id], nil
+}
+func (m *tweetDbMock) GetNextDue() (*time.Time, error_utils.MessageErr) {
+	return getNextDueDomain()
+}
+func (m *tweetDbMock) HasOutstanding(accountId int64) (bool, error_utils.MessageErr) {
+	return hasOutstandingDomain(accountId)
+}
+func (m *tweetDbMock) Update(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+	return updateTweetDomain(msg)
+}
+func (m *tweetDbMock) GetThread(threadId string) ([]domain.Tweet, error_utils.MessageErr) {
+	return getThreadDomain(threadId)
+}
+func (m *tweetDbMock) Initialize() *sql.DB {
+	return nil
+}
+
+type mediaDbMock struct {
+	domain.MediaRepoInterface
+}
+
+func (m *mediaDbMock) List(tweetId int64) ([]domain.Media, error_utils.MessageErr) {
+	return listMediaDomain(tweetId)
+}
+
+type pollDbMock struct {
+	domain.PollRepoInterface
+}
+
+func (m *pollDbMock) Get(tweetId int64) (*domain.Poll, error_utils.MessageErr) {
+	return getPollDomain(tweetId)
+}
+
+type accountDbMock struct {
+	domain.AccountRepoInterface
+}
+
+func (m *accountDbMock) Get(id int64) (*domain.Account, error_utils.MessageErr) {
+	return getAccountDomain(id)
+}
+func (m *accountDbMock) List() ([]domain.Account, error_utils.MessageErr) {
+	return listAccountsDomain()
+}
+
+type destinationDbMock struct {
+	domain.DestinationRepoInterface
+}
+
+func (m *destinationDbMock) List(tweetId int64) ([]domain.Destination, error_utils.MessageErr) {
+	return listDestinationsDomain(tweetId)
+}
+func (m *destinationDbMock) Update(destination *domain.Destination) (*domain.Destination, error_utils.MessageErr) {
+	return updateDestinationDomain(destination)
+}
+
+func noDestinations(tweetId int64) ([]domain.Destination, error_utils.MessageErr) {
+	return nil, error_utils.NotFoundError("no records found")
+}
+
+func noMedia(tweetId int64) ([]domain.Media, error_utils.MessageErr) {
+	return nil, error_utils.NotFoundError("no records found")
+}
+
+func noPoll(tweetId int64) (*domain.Poll, error_utils.MessageErr) {
+	return nil, error_utils.NotFoundError("no record matching given id")
+}
+
 func TestShouldPost(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		p, _ := time.Parse(layout, "2021-07-18 12:55:50 +0200 SAST")
@@ -21,3 +150,522 @@ func TestShouldPost(t *testing.T) {
 		assert.Equal(t, true, ShouldPost(*tweet))
 	})
 }
+
+func TestGetTweets(t *testing.T) {
+	postTime := time.Now().Add(-time.Minute)
+
+	t.Run("Publishes and marks as posted", func(t *testing.T) {
+		var updated *domain.Tweet
+		recorder := publisher.NewRecorder()
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: postTime, Status: domain.Pending, Visibility: "unlisted", ContentWarning: "spoilers"}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = msg
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.Equal(t, []publisher.Post{{Message: "the message", Visibility: "unlisted", ContentWarning: "spoilers"}}, recorder.Published())
+		assert.NotNil(t, updated)
+		assert.EqualValues(t, domain.Posted, updated.Status)
+		assert.EqualValues(t, "1", updated.RemoteId)
+		assert.EqualValues(t, "memory://status/1", updated.RemoteUrl)
+		assert.NotNil(t, updated.PostedAt)
+	})
+
+	t.Run("Duplicate is marked as posted", func(t *testing.T) {
+		var updated *domain.Tweet
+		recorder := publisher.NewRecorder()
+		recorder.PublishErr = &publisher.Error{Category: publisher.Duplicate, Code: 187, Err: errors.New("twitter: 187 Status is a duplicate.")}
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: postTime, Status: domain.Pending}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = msg
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.NotNil(t, updated)
+		assert.EqualValues(t, domain.Posted, updated.Status)
+	})
+
+	t.Run("Publish error schedules a retry", func(t *testing.T) {
+		var updated *domain.Tweet
+		recorder := publisher.NewRecorder()
+		recorder.PublishErr = errors.New("twitter: 130 Over capacity")
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: postTime, Status: domain.Pending}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = msg
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.Empty(t, recorder.Published())
+		assert.NotNil(t, updated)
+		assert.EqualValues(t, domain.Pending, updated.Status)
+		assert.Equal(t, 1, updated.Attempts)
+		assert.Equal(t, "twitter: 130 Over capacity", updated.LastError)
+		assert.NotNil(t, updated.NextAttemptAt)
+		assert.True(t, updated.NextAttemptAt.After(time.Now()))
+	})
+
+	t.Run("Permanent error fails the tweet", func(t *testing.T) {
+		var updated *domain.Tweet
+		recorder := publisher.NewRecorder()
+		recorder.PublishErr = &publisher.Error{Category: publisher.Permanent, Code: 186, Err: errors.New("twitter: 186 Tweet needs to be a bit shorter.")}
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: postTime, Status: domain.Pending}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = msg
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.NotNil(t, updated)
+		assert.EqualValues(t, domain.Failed, updated.Status)
+		assert.Equal(t, 1, updated.Attempts)
+		assert.Nil(t, updated.NextAttemptAt)
+	})
+
+	t.Run("Auth error pauses the account", func(t *testing.T) {
+		var updated *domain.Tweet
+		recorder := publisher.NewRecorder()
+		recorder.PublishErr = &publisher.Error{Category: publisher.Auth, Code: 89, Err: errors.New("twitter: 89 Invalid or expired token.")}
+		recorder.VerifyErr = recorder.PublishErr
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+		defer queue.reset()
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: postTime, Status: domain.Pending}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = msg
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.Nil(t, updated)
+		assert.True(t, queue.isPaused(0))
+
+		// known-bad credentials are not posted with until they verify again
+		recorder.PublishErr = nil
+		getTweets()
+
+		assert.Nil(t, updated)
+		assert.Empty(t, recorder.Published())
+
+		recorder.VerifyErr = nil
+		getTweets()
+
+		assert.False(t, queue.isPaused(0))
+		assert.EqualValues(t, domain.Posted, updated.Status)
+	})
+
+	t.Run("Attaches media", func(t *testing.T) {
+		recorder := publisher.NewRecorder()
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: postTime, Status: domain.Pending}}, nil
+		}
+		listMediaDomain = func(tweetId int64) ([]domain.Media, error_utils.MessageErr) {
+			return []domain.Media{{Id: 1, TweetId: tweetId, MimeType: "image/png", AltText: "alt", Data: []byte("data")}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		getTweets()
+
+		expected := []publisher.Post{{
+			Message: "the message",
+			Media:   []publisher.Media{{MimeType: "image/png", AltText: "alt", Data: []byte("data")}},
+		}}
+		assert.Equal(t, expected, recorder.Published())
+	})
+
+	t.Run("Retweets and quotes the target", func(t *testing.T) {
+		recorder := publisher.NewRecorder()
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{
+				{Id: 1, PostTime: postTime, Status: domain.Pending, Kind: domain.Retweet, Target: "1436255364069150720"},
+				{Id: 2, Message: "so true", PostTime: postTime, Status: domain.Pending, Kind: domain.Quote, Target: "1436255364069150721"},
+			}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		getTweets()
+
+		expected := []publisher.Post{{Repost: "1436255364069150720"}, {Message: "so true", Quote: "1436255364069150721"}}
+		assert.Equal(t, expected, recorder.Published())
+	})
+
+	t.Run("Posts the poll", func(t *testing.T) {
+		recorder := publisher.NewRecorder()
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		listMediaDomain = noMedia
+
+		getPollDomain = func(tweetId int64) (*domain.Poll, error_utils.MessageErr) {
+			return &domain.Poll{TweetId: tweetId, Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}, nil
+		}
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "Tabs or spaces?", PostTime: postTime, Status: domain.Pending}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		getTweets()
+
+		expected := []publisher.Post{{Message: "Tabs or spaces?", Poll: &publisher.Poll{Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}}}
+		assert.Equal(t, expected, recorder.Published())
+	})
+
+	t.Run("Posts as the tweet's account", func(t *testing.T) {
+		var postedAs *domain.Account
+		fallback := publisher.NewRecorder()
+		accountRecorder := publisher.NewRecorder()
+		Publisher = fallback
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		domain.AccountRepo = &accountDbMock{}
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+
+		accountPublisher = func(account *domain.Account) (publisher.Publisher, error) {
+			postedAs = account
+			return accountRecorder, nil
+		}
+		defer func() { accountPublisher = newAccountPublisher }()
+
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return &domain.Account{Id: id, Name: "brand", AccessToken: "at"}, nil
+		}
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: postTime, Status: domain.Pending, AccountId: 2}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.EqualValues(t, 2, postedAs.Id)
+		assert.Len(t, accountRecorder.Published(), 1)
+		assert.Len(t, fallback.Published(), 0)
+	})
+
+	t.Run("Deleted account fails the tweet", func(t *testing.T) {
+		var updated *domain.Tweet
+		Publisher = publisher.NewRecorder()
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		domain.AccountRepo = &accountDbMock{}
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no record matching given id")
+		}
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: postTime, Status: domain.Pending, AccountId: 2}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = msg
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.EqualValues(t, domain.Failed, updated.Status)
+		assert.Equal(t, "account 2: no record matching given id", updated.LastError)
+	})
+
+	t.Run("Nothing pending", func(t *testing.T) {
+		recorder := publisher.NewRecorder()
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return nil, error_utils.NotFoundError("no records found")
+		}
+
+		getTweets()
+
+		assert.Empty(t, recorder.Published())
+	})
+}
+
+func TestPostThread(t *testing.T) {
+	const threadId = "thread"
+	postTime := time.Now().Add(-time.Minute)
+
+	thread := func() []domain.Tweet {
+		return []domain.Tweet{
+			{Id: 1, Message: "first", PostTime: postTime, Status: domain.Posted, ThreadId: threadId, ThreadPosition: 0, RemoteId: "100"},
+			{Id: 2, Message: "second", PostTime: postTime, Status: domain.Pending, ThreadId: threadId, ThreadPosition: 1},
+			{Id: 3, Message: "third", PostTime: postTime, Status: domain.Pending, ThreadId: threadId, ThreadPosition: 2},
+		}
+	}
+
+	t.Run("Chains replies from the last posted part", func(t *testing.T) {
+		updated := make([]domain.Tweet, 0)
+		recorder := publisher.NewRecorder()
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return thread()[1:2], nil
+		}
+		getThreadDomain = func(id string) ([]domain.Tweet, error_utils.MessageErr) {
+			assert.Equal(t, threadId, id)
+			return thread(), nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = append(updated, *msg)
+			return msg, nil
+		}
+
+		getTweets()
+
+		expected := []publisher.Post{
+			{Message: "second", ReplyTo: "100"},
+			{Message: "third", ReplyTo: "1"},
+		}
+		assert.Equal(t, expected, recorder.Published())
+		assert.Len(t, updated, 2)
+		assert.EqualValues(t, "2", updated[1].RemoteId)
+	})
+
+	t.Run("Stops at the failed part", func(t *testing.T) {
+		updated := make([]domain.Tweet, 0)
+		recorder := publisher.NewRecorder()
+		recorder.OnPublish = func(post *publisher.Post) error {
+			if post.Message == "second" {
+				return errors.New("twitter: 130 Over capacity")
+			}
+			return nil
+		}
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+
+		getThreadDomain = func(id string) ([]domain.Tweet, error_utils.MessageErr) {
+			return thread(), nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = append(updated, *msg)
+			return msg, nil
+		}
+
+		postThread(threadId)
+
+		assert.Empty(t, recorder.Published())
+		assert.Len(t, updated, 1)
+		assert.EqualValues(t, 2, updated[0].Id)
+		assert.Equal(t, 1, updated[0].Attempts)
+	})
+
+	t.Run("Waits for the backoff of the failed part", func(t *testing.T) {
+		recorder := publisher.NewRecorder()
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+
+		getThreadDomain = func(id string) ([]domain.Tweet, error_utils.MessageErr) {
+			parts := thread()
+			next := time.Now().Add(time.Hour)
+			parts[1].NextAttemptAt = &next
+			return parts, nil
+		}
+
+		postThread(threadId)
+
+		assert.Empty(t, recorder.Published())
+	})
+
+	t.Run("Does not continue past a failed part", func(t *testing.T) {
+		recorder := publisher.NewRecorder()
+		Publisher = recorder
+		domain.TweetRepo = &tweetDbMock{}
+
+		getThreadDomain = func(id string) ([]domain.Tweet, error_utils.MessageErr) {
+			parts := thread()
+			parts[1].Status = domain.Failed
+			return parts, nil
+		}
+
+		postThread(threadId)
+
+		assert.Empty(t, recorder.Published())
+	})
+}
+
+func TestNewAccountPublisher(t *testing.T) {
+	masterKey := "1:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+	_ = os.Setenv("ENCRYPTION_KEYS", masterKey)
+	defer os.Unsetenv("ENCRYPTION_KEYS")
+
+	keyring, _ := secrets.LoadKeyring()
+	sealed := func(value string) string {
+		s, _ := keyring.Seal(value)
+		return s
+	}
+
+	t.Run("Opens the sealed credentials", func(t *testing.T) {
+		account := &domain.Account{Id: 1, ConsumerKey: sealed("ck"), ConsumerSecret: sealed("cs"), AccessToken: sealed("at"), AccessTokenSecret: sealed("ats")}
+
+		pub, err := newAccountPublisher(account)
+
+		assert.Nil(t, err)
+		assert.IsType(t, &twitter.Publisher{}, pub)
+	})
+
+	t.Run("Mastodon account", func(t *testing.T) {
+		account := &domain.Account{Id: 1, Network: domain.MastodonNetwork, InstanceUrl: "https://mastodon.social", AccessToken: sealed("at")}
+
+		pub, err := newAccountPublisher(account)
+
+		assert.Nil(t, err)
+		assert.IsType(t, &mastodon.Publisher{}, pub)
+	})
+
+	t.Run("Bluesky account", func(t *testing.T) {
+		account := &domain.Account{Id: 1, Network: domain.BlueskyNetwork, Handle: "lattr.bsky.social", AccessToken: sealed("app-password")}
+
+		pub, err := newAccountPublisher(account)
+
+		assert.Nil(t, err)
+		assert.IsType(t, &bluesky.Publisher{}, pub)
+	})
+
+	t.Run("Unsealed credentials are rejected", func(t *testing.T) {
+		account := &domain.Account{Id: 1, ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: "at", AccessTokenSecret: "ats"}
+
+		pub, err := newAccountPublisher(account)
+
+		assert.Nil(t, pub)
+		assert.Equal(t, secrets.ErrNotSealed, err)
+	})
+
+	t.Run("Decryption failure is retried", func(t *testing.T) {
+		var updated *domain.Tweet
+		Publisher = publisher.NewRecorder()
+		domain.TweetRepo = &tweetDbMock{}
+		domain.MediaRepo = &mediaDbMock{}
+		domain.PollRepo = &pollDbMock{}
+		getPollDomain = noPoll
+		domain.AccountRepo = &accountDbMock{}
+		listMediaDomain = noMedia
+		domain.DestinationRepo = &destinationDbMock{}
+		listDestinationsDomain = noDestinations
+
+		getAccountDomain = func(id int64) (*domain.Account, error_utils.MessageErr) {
+			return &domain.Account{Id: id, ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: "at", AccessTokenSecret: "ats"}, nil
+		}
+		getPendingTweetsDomain = func(limit int) ([]domain.Tweet, error_utils.MessageErr) {
+			return []domain.Tweet{{Id: 1, Message: "the message", PostTime: time.Now().Add(-time.Minute), Status: domain.Pending, AccountId: 2}}, nil
+		}
+		updateTweetDomain = func(msg *domain.Tweet) (*domain.Tweet, error_utils.MessageErr) {
+			updated = msg
+			return msg, nil
+		}
+
+		getTweets()
+
+		assert.EqualValues(t, domain.Pending, updated.Status)
+		assert.Equal(t, 1, updated.Attempts)
+		assert.NotNil(t, updated.NextAttemptAt)
+	})
+}
@@ -0,0 +1,212 @@
+// Package secrets seals credentials at rest with envelope encryption, every value is encrypted
+// with its own AES-GCM data key which is in turn encrypted under a master key from ENCRYPTION_KEYS
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// prefix marks a sealed value, followed by the master key ID, the wrapped data key and the ciphertext
+const prefix = "enc:v1"
+
+const dataKeySize = 32
+
+var (
+	ErrNoKeys     = errors.New("secrets: ENCRYPTION_KEYS is not configured")
+	ErrNotSealed  = errors.New("secrets: value is not sealed")
+	ErrUnknownKey = errors.New("secrets: value is sealed with an unknown master key")
+)
+
+// Keyring holds the master keys, new values are always sealed with the current key
+// while any of the keys can open existing values
+type Keyring struct {
+	current string
+	keys    map[string][]byte
+}
+
+// LoadKeyring reads the master keys from ENCRYPTION_KEYS, a comma separated list of id:base64 pairs
+// of 32 byte keys. The first key is the current one, older keys are kept until re-encrypted
+func LoadKeyring() (*Keyring, error) {
+	return ParseKeyring(os.Getenv("ENCRYPTION_KEYS"))
+}
+
+func ParseKeyring(config string) (*Keyring, error) {
+	if strings.TrimSpace(config) == "" {
+		return nil, ErrNoKeys
+	}
+
+	keyring := &Keyring{keys: make(map[string][]byte)}
+
+	for _, entry := range strings.Split(config, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("secrets: invalid key entry %q, expected id:base64", entry)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("secrets: key %s must be 32 base64 encoded bytes", parts[0])
+		}
+
+		if _, ok := keyring.keys[parts[0]]; ok {
+			return nil, fmt.Errorf("secrets: duplicate key %s", parts[0])
+		}
+
+		if keyring.current == "" {
+			keyring.current = parts[0]
+		}
+		keyring.keys[parts[0]] = key
+	}
+
+	return keyring, nil
+}
+
+// Seal encrypts the value under a new data key, wrapped with the current master key
+func (k *Keyring) Seal(plaintext string) (string, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+
+	wrapped, err := encrypt(k.keys[k.current], dataKey, []byte(k.current))
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := encrypt(dataKey, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		prefix,
+		k.current,
+		base64.StdEncoding.EncodeToString(wrapped),
+		base64.StdEncoding.EncodeToString(ciphertext),
+	}, ":"), nil
+}
+
+// Open decrypts a sealed value with whichever master key it was sealed under
+func (k *Keyring) Open(sealed string) (string, error) {
+	keyId, wrapped, ciphertext, err := split(sealed)
+	if err != nil {
+		return "", err
+	}
+
+	masterKey, ok := k.keys[keyId]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+
+	dataKey, err := decrypt(masterKey, wrapped, []byte(keyId))
+	if err != nil {
+		return "", fmt.Errorf("secrets: unwrapping data key: %w", err)
+	}
+
+	plaintext, err := decrypt(dataKey, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("secrets: decrypting value: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// Rotate re-seals a value under the current master key, values that are not sealed yet are
+// sealed for the first time. It reports whether the value changed
+func (k *Keyring) Rotate(value string) (string, bool, error) {
+	if !IsSealed(value) {
+		sealed, err := k.Seal(value)
+		return sealed, err == nil, err
+	}
+
+	keyId, _, _, err := split(value)
+	if err != nil {
+		return "", false, err
+	}
+
+	if keyId == k.current {
+		return value, false, nil
+	}
+
+	plaintext, err := k.Open(value)
+	if err != nil {
+		return "", false, err
+	}
+
+	sealed, err := k.Seal(plaintext)
+	return sealed, err == nil, err
+}
+
+// IsSealed reports whether the value was produced by Seal
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, prefix+":")
+}
+
+func split(sealed string) (string, []byte, []byte, error) {
+	if !IsSealed(sealed) {
+		return "", nil, nil, ErrNotSealed
+	}
+
+	parts := strings.Split(strings.TrimPrefix(sealed, prefix+":"), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrNotSealed
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, ErrNotSealed
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrNotSealed
+	}
+
+	return parts[0], wrapped, ciphertext, nil
+}
+
+// encrypt seals the plaintext with AES-GCM, prefixing the random nonce to the ciphertext
+func encrypt(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func decrypt(key []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,142 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	oldKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32)))
+	newKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("b", 32)))
+)
+
+func mustKeyring(t *testing.T, config string) *Keyring {
+	keyring, err := ParseKeyring(config)
+	if err != nil {
+		t.Fatalf("unexpected keyring error: %s", err)
+	}
+	return keyring
+}
+
+func TestParseKeyring(t *testing.T) {
+	t.Run("First key is current", func(t *testing.T) {
+		keyring := mustKeyring(t, "2:"+newKey+", 1:"+oldKey)
+
+		assert.Equal(t, "2", keyring.current)
+		assert.Len(t, keyring.keys, 2)
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		_, err := ParseKeyring("")
+
+		assert.Equal(t, ErrNoKeys, err)
+	})
+
+	t.Run("Invalid entries", func(t *testing.T) {
+		for _, config := range []string{"nokey", ":" + oldKey, "1:short", "1:" + oldKey + ",1:" + newKey} {
+			_, err := ParseKeyring(config)
+
+			assert.NotNil(t, err, config)
+		}
+	})
+
+	t.Run("Loads from the environment", func(t *testing.T) {
+		_ = os.Setenv("ENCRYPTION_KEYS", "1:"+oldKey)
+		defer os.Unsetenv("ENCRYPTION_KEYS")
+
+		keyring, err := LoadKeyring()
+
+		assert.Nil(t, err)
+		assert.Equal(t, "1", keyring.current)
+	})
+}
+
+func TestSealOpen(t *testing.T) {
+	keyring := mustKeyring(t, "1:"+oldKey)
+
+	t.Run("Round trip", func(t *testing.T) {
+		sealed, err := keyring.Seal("access-token-secret")
+
+		assert.Nil(t, err)
+		assert.True(t, IsSealed(sealed))
+		assert.NotContains(t, sealed, "access-token-secret")
+
+		plaintext, err := keyring.Open(sealed)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "access-token-secret", plaintext)
+	})
+
+	t.Run("Every seal uses a new data key", func(t *testing.T) {
+		first, _ := keyring.Seal("value")
+		second, _ := keyring.Seal("value")
+
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("Tampered value", func(t *testing.T) {
+		sealed, _ := keyring.Seal("value")
+		parts := strings.Split(sealed, ":")
+		ciphertext, _ := base64.StdEncoding.DecodeString(parts[4])
+		ciphertext[len(ciphertext)-1] ^= 1
+		parts[4] = base64.StdEncoding.EncodeToString(ciphertext)
+
+		_, err := keyring.Open(strings.Join(parts, ":"))
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Unknown master key", func(t *testing.T) {
+		sealed, _ := mustKeyring(t, "2:"+newKey).Seal("value")
+
+		_, err := keyring.Open(sealed)
+
+		assert.Equal(t, ErrUnknownKey, err)
+	})
+
+	t.Run("Plaintext value", func(t *testing.T) {
+		_, err := keyring.Open("value")
+
+		assert.Equal(t, ErrNotSealed, err)
+	})
+}
+
+func TestRotate(t *testing.T) {
+	old := mustKeyring(t, "1:"+oldKey)
+	rotated := mustKeyring(t, "2:"+newKey+",1:"+oldKey)
+
+	t.Run("Re-seals under the current key", func(t *testing.T) {
+		sealed, _ := old.Seal("value")
+
+		resealed, changed, err := rotated.Rotate(sealed)
+
+		assert.Nil(t, err)
+		assert.True(t, changed)
+		assert.True(t, strings.HasPrefix(resealed, "enc:v1:2:"))
+
+		plaintext, _ := mustKeyring(t, "2:"+newKey).Open(resealed)
+		assert.Equal(t, "value", plaintext)
+	})
+
+	t.Run("Leaves current values alone", func(t *testing.T) {
+		sealed, _ := rotated.Seal("value")
+
+		resealed, changed, err := rotated.Rotate(sealed)
+
+		assert.Nil(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, sealed, resealed)
+	})
+
+	t.Run("Seals plaintext values", func(t *testing.T) {
+		resealed, changed, err := rotated.Rotate("value")
+
+		assert.Nil(t, err)
+		assert.True(t, changed)
+		assert.True(t, IsSealed(resealed))
+	})
+}
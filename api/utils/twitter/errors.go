@@ -0,0 +1,98 @@
+package twitter
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/dghubble/go-twitter/twitter"
+)
+
+// errorCodes maps the Twitter API error codes the scheduler cares about to their category
+// https://developer.twitter.com/en/support/twitter-api/error-troubleshooting
+const rateLimitExceeded = 88
+
+var errorCodes = map[int]publisher.Category{
+	32:  publisher.Auth,      // Could not authenticate you
+	64:  publisher.Auth,      // Your account is suspended
+	89:  publisher.Auth,      // Invalid or expired token
+	135: publisher.Auth,      // Timestamp out of bounds
+	215: publisher.Auth,      // Bad authentication data
+	220: publisher.Auth,      // Your credentials do not allow access to this resource
+	261: publisher.Auth,      // Application cannot perform write actions
+	326: publisher.Auth,      // Account is temporarily locked
+	88:  publisher.Transient, // Rate limit exceeded
+	130: publisher.Transient, // Over capacity
+	131: publisher.Transient, // Internal error
+	185: publisher.Transient, // User is over daily status update limit
+	187: publisher.Duplicate, // Status is a duplicate
+	327: publisher.Duplicate, // You have already retweeted this Tweet
+	44:  publisher.Permanent, // attachment_url parameter is invalid
+	144: publisher.Permanent, // No status found with that ID
+	170: publisher.Permanent, // Missing required parameter
+	186: publisher.Permanent, // Tweet needs to be a bit shorter
+	226: publisher.Permanent, // Request looks like it might be automated
+	323: publisher.Permanent, // Only one animated GIF may be attached
+	324: publisher.Permanent, // The validation of media ids failed
+	325: publisher.Permanent, // A media id was not found
+	328: publisher.Permanent, // Retweet is not permissible for this status
+	385: publisher.Permanent, // Replied to a deleted or invisible tweet
+	386: publisher.Permanent, // Too many attachment types
+}
+
+// classifyError annotates an error returned by the Twitter API with its publisher category,
+// based on the API error code when present and the HTTP status otherwise
+func classifyError(err error, resp *http.Response) error {
+	if err == nil {
+		return nil
+	}
+
+	classified := &publisher.Error{Category: publisher.Transient, Err: err}
+
+	if resp != nil {
+		classified.StatusCode = resp.StatusCode
+		classified.Category = categoryForStatus(resp.StatusCode)
+		classified.RateLimit = rateLimit(resp)
+	}
+
+	var apiErr twitter.APIError
+	if errors.As(err, &apiErr) && !apiErr.Empty() {
+		classified.Code = apiErr.Errors[0].Code
+
+		if category, ok := errorCodes[classified.Code]; ok {
+			classified.Category = category
+		}
+	}
+
+	if classified.StatusCode == http.StatusTooManyRequests || classified.Code == rateLimitExceeded {
+		classified.RateLimit = exhaustedRateLimit(resp)
+	}
+
+	return classified
+}
+
+// classifyResponse decodes the error body of a raw API response, used for the
+// endpoints go-twitter does not cover
+func classifyResponse(resp *http.Response, body []byte) error {
+	var apiErr twitter.APIError
+
+	if jsonErr := json.Unmarshal(body, &apiErr); jsonErr != nil || apiErr.Empty() {
+		return classifyError(errors.New("twitter: "+http.StatusText(resp.StatusCode)+" "+string(body)), resp)
+	}
+
+	return classifyError(apiErr, resp)
+}
+
+func categoryForStatus(status int) publisher.Category {
+	switch {
+	case status == http.StatusUnauthorized:
+		return publisher.Auth
+	case status == http.StatusTooManyRequests, status >= http.StatusInternalServerError:
+		return publisher.Transient
+	case status >= http.StatusBadRequest:
+		return publisher.Permanent
+	default:
+		return publisher.Transient
+	}
+}
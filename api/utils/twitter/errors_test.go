@@ -0,0 +1,118 @@
+package twitter
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/dghubble/go-twitter/twitter"
+	"github.com/stretchr/testify/assert"
+)
+
+func apiError(code int) twitter.APIError {
+	return twitter.APIError{Errors: []twitter.ErrorDetail{{Code: code, Message: "message"}}}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		status   int
+		expected publisher.Category
+	}{
+		{"Duplicate", apiError(187), http.StatusForbidden, publisher.Duplicate},
+		{"Too long", apiError(186), http.StatusForbidden, publisher.Permanent},
+		{"Invalid token", apiError(89), http.StatusUnauthorized, publisher.Auth},
+		{"Write access revoked", apiError(261), http.StatusForbidden, publisher.Auth},
+		{"Rate limited", apiError(88), http.StatusTooManyRequests, publisher.Transient},
+		{"Over capacity", apiError(130), http.StatusServiceUnavailable, publisher.Transient},
+		{"Unknown code uses status", apiError(999), http.StatusBadGateway, publisher.Transient},
+		{"Unauthorized without body", errors.New("unauthorized"), http.StatusUnauthorized, publisher.Auth},
+		{"Bad request without body", errors.New("bad request"), http.StatusBadRequest, publisher.Permanent},
+		{"No response", errors.New("connection reset by peer"), 0, publisher.Transient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp *http.Response
+			if tt.status != 0 {
+				resp = &http.Response{StatusCode: tt.status}
+			}
+
+			err := classifyError(tt.err, resp)
+
+			assert.Equal(t, tt.expected, publisher.Classify(err))
+			assert.Equal(t, tt.err.Error(), err.Error())
+		})
+	}
+
+	t.Run("Nil error", func(t *testing.T) {
+		assert.Nil(t, classifyError(nil, &http.Response{StatusCode: http.StatusOK}))
+	})
+}
+
+func TestClassifyResponse(t *testing.T) {
+	t.Run("Decodes API error body", func(t *testing.T) {
+		resp := &http.Response{StatusCode: http.StatusBadRequest}
+
+		err := classifyResponse(resp, []byte(`{"errors":[{"code":324,"message":"Image file is invalid"}]}`))
+
+		var pubErr *publisher.Error
+		assert.True(t, errors.As(err, &pubErr))
+		assert.Equal(t, publisher.Permanent, pubErr.Category)
+		assert.Equal(t, 324, pubErr.Code)
+	})
+
+	t.Run("Falls back to the status", func(t *testing.T) {
+		resp := &http.Response{StatusCode: http.StatusServiceUnavailable}
+
+		err := classifyResponse(resp, []byte("<html>"))
+
+		assert.Equal(t, publisher.Transient, publisher.Classify(err))
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Run("Reads the rate limit headers", func(t *testing.T) {
+		resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+		resp.Header.Set("x-rate-limit-limit", "300")
+		resp.Header.Set("x-rate-limit-remaining", "12")
+		resp.Header.Set("x-rate-limit-reset", "1626605750")
+
+		assert.Equal(t, &publisher.RateLimit{Limit: 300, Remaining: 12, ResetAt: time.Unix(1626605750, 0)}, rateLimit(resp))
+	})
+
+	t.Run("Missing headers", func(t *testing.T) {
+		assert.Nil(t, rateLimit(&http.Response{StatusCode: http.StatusOK}))
+		assert.Nil(t, rateLimit(nil))
+	})
+
+	t.Run("Too many requests carries the exhausted window", func(t *testing.T) {
+		reset := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+		resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+		resp.Header.Set("x-rate-limit-limit", "300")
+		resp.Header.Set("x-rate-limit-remaining", "0")
+		resp.Header.Set("x-rate-limit-reset", strconv.FormatInt(reset.Unix(), 10))
+
+		window, ok := publisher.RateLimited(classifyError(apiError(88), resp))
+
+		assert.True(t, ok)
+		assert.Equal(t, reset, window.ResetAt)
+	})
+
+	t.Run("Rate limit code without headers waits a full window", func(t *testing.T) {
+		window, ok := publisher.RateLimited(classifyError(apiError(88), &http.Response{StatusCode: http.StatusForbidden}))
+
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(defaultRateLimitWindow), window.ResetAt, time.Minute)
+	})
+
+	t.Run("Other errors are not rate limited", func(t *testing.T) {
+		_, ok := publisher.RateLimited(classifyError(apiError(186), &http.Response{StatusCode: http.StatusForbidden}))
+
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,119 @@
+package twitter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+)
+
+// uploadBaseURL is the media upload host, which differs from the regular API host
+var uploadBaseURL = "https://upload.twitter.com/1.1/"
+
+type mediaUploadResponse struct {
+	MediaId    int64  `json:"media_id"`
+	MediaIdStr string `json:"media_id_string"`
+}
+
+type altText struct {
+	Text string `json:"text"`
+}
+
+type mediaMetadata struct {
+	MediaId string  `json:"media_id"`
+	AltText altText `json:"alt_text"`
+}
+
+// uploadMedia uploads every item using the simple upload endpoint and returns
+// the media IDs in the same order, ready to be attached to a status update
+func uploadMedia(httpClient *http.Client, media []publisher.Media) ([]int64, error) {
+	ids := make([]int64, 0, len(media))
+
+	for _, m := range media {
+		id, err := uploadSingle(httpClient, m)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func uploadSingle(httpClient *http.Client, media publisher.Media) (int64, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("media", "media")
+	if err != nil {
+		return 0, err
+	}
+	if _, err = part.Write(media.Data); err != nil {
+		return 0, err
+	}
+	if err = writer.Close(); err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, uploadBaseURL+"media/upload.json", body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	var uploaded mediaUploadResponse
+	if err = doJSON(httpClient, req, &uploaded); err != nil {
+		return 0, err
+	}
+
+	if media.AltText != "" {
+		if err = createMetadata(httpClient, strconv.FormatInt(uploaded.MediaId, 10), media.AltText); err != nil {
+			return 0, err
+		}
+	}
+
+	return uploaded.MediaId, nil
+}
+
+func createMetadata(httpClient *http.Client, mediaId string, text string) error {
+	payload, err := json.Marshal(mediaMetadata{MediaId: mediaId, AltText: altText{Text: text}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, uploadBaseURL+"media/metadata/create.json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return doJSON(httpClient, req, nil)
+}
+
+// doJSON executes the request and decodes a successful JSON response into out when provided
+func doJSON(httpClient *http.Client, req *http.Request, out interface{}) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return classifyError(err, nil)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return classifyResponse(resp, body)
+	}
+
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(body, out)
+}
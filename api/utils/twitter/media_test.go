@@ -0,0 +1,56 @@
+package twitter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadMedia(t *testing.T) {
+	t.Run("Uploads media and sets alt text", func(t *testing.T) {
+		var metadata mediaMetadata
+		var uploaded []byte
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/media/upload.json", func(w http.ResponseWriter, r *http.Request) {
+			file, _, err := r.FormFile("media")
+			assert.Nil(t, err)
+			uploaded, _ = ioutil.ReadAll(file)
+			_, _ = w.Write([]byte(`{"media_id": 710511363345354753, "media_id_string": "710511363345354753"}`))
+		})
+		mux.HandleFunc("/media/metadata/create.json", func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&metadata)
+			w.WriteHeader(http.StatusOK)
+		})
+		server := httptest.NewServer(mux)
+		defer server.Close()
+		uploadBaseURL = server.URL + "/"
+
+		ids, err := uploadMedia(server.Client(), []publisher.Media{{MimeType: "image/png", AltText: "alt", Data: []byte("data")}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{710511363345354753}, ids)
+		assert.Equal(t, []byte("data"), uploaded)
+		assert.Equal(t, "710511363345354753", metadata.MediaId)
+		assert.Equal(t, "alt", metadata.AltText.Text)
+	})
+
+	t.Run("Upload failure", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":[{"code":324,"message":"Image file is invalid"}]}`))
+		}))
+		defer server.Close()
+		uploadBaseURL = server.URL + "/"
+
+		ids, err := uploadMedia(server.Client(), []publisher.Media{{MimeType: "image/png", Data: []byte("data")}})
+
+		assert.Nil(t, ids)
+		assert.Contains(t, err.Error(), "324")
+	})
+}
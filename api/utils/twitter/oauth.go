@@ -0,0 +1,80 @@
+package twitter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dghubble/oauth1"
+)
+
+// defaultOAuthURL is the base of Twitter's OAuth 1.0a endpoints,
+// OAUTH_BASE_URL points the connect flow at a different server such as a local stub
+const defaultOAuthURL = "https://api.twitter.com/oauth"
+
+// oauthConfig builds the three-legged OAuth config for the app's consumer credentials
+func oauthConfig() (*oauth1.Config, error) {
+	callback := os.Getenv("OAUTH_CALLBACK_URL")
+	if callback == "" {
+		return nil, errors.New("OAUTH_CALLBACK_URL is not configured")
+	}
+
+	base := strings.TrimSuffix(os.Getenv("OAUTH_BASE_URL"), "/")
+	if base == "" {
+		base = defaultOAuthURL
+	}
+
+	return &oauth1.Config{
+		ConsumerKey:    os.Getenv("CONSUMER_KEY"),
+		ConsumerSecret: os.Getenv("CONSUMER_SECRET"),
+		CallbackURL:    callback,
+		Endpoint: oauth1.Endpoint{
+			RequestTokenURL: base + "/request_token",
+			AuthorizeURL:    base + "/authorize",
+			AccessTokenURL:  base + "/access_token",
+		},
+	}, nil
+}
+
+// RequestToken starts the connect flow, returning the temporary request token and secret
+// along with the URL the user has to visit to authorize the app
+func RequestToken() (token string, secret string, authorizationURL string, err error) {
+	config, err := oauthConfig()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	token, secret, err = config.RequestToken()
+	if err != nil {
+		return "", "", "", fmt.Errorf("requesting token: %w", err)
+	}
+
+	authURL, err := config.AuthorizationURL(token)
+	if err != nil {
+		return "", "", "", fmt.Errorf("building authorization url: %w", err)
+	}
+
+	return token, secret, authURL.String(), nil
+}
+
+// AccessToken completes the connect flow, exchanging the authorized request token
+// for the credentials of the user that authorized it
+func AccessToken(requestToken string, requestSecret string, verifier string) (*Credentials, error) {
+	config, err := oauthConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, accessSecret, err := config.AccessToken(requestToken, requestSecret, verifier)
+	if err != nil {
+		return nil, fmt.Errorf("exchanging token: %w", err)
+	}
+
+	return &Credentials{
+		ConsumerKey:       config.ConsumerKey,
+		ConsumerSecret:    config.ConsumerSecret,
+		AccessToken:       accessToken,
+		AccessTokenSecret: accessSecret,
+	}, nil
+}
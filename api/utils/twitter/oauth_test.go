@@ -0,0 +1,104 @@
+package twitter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubOAuthServer is a minimal OAuth 1.0a provider that issues a fixed request token
+// and exchanges it for access tokens when given the expected verifier
+func stubOAuthServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/request_token", func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		assert.Contains(t, auth, `oauth_consumer_key="consumer-key"`)
+		assert.Contains(t, auth, "oauth_callback=")
+
+		_, _ = w.Write([]byte("oauth_token=request-token&oauth_token_secret=request-secret&oauth_callback_confirmed=true"))
+	})
+
+	mux.HandleFunc("/access_token", func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+
+		if !strings.Contains(auth, `oauth_token="request-token"`) || !strings.Contains(auth, `oauth_verifier="the-verifier"`) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		_, _ = w.Write([]byte("oauth_token=access-token&oauth_token_secret=access-secret&user_id=1&screen_name=lattr"))
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func setOAuthEnv(t *testing.T, baseURL string) {
+	for key, val := range map[string]string{
+		"OAUTH_BASE_URL":     baseURL,
+		"OAUTH_CALLBACK_URL": "https://api.lattr.app/accounts/connect/callback",
+		"CONSUMER_KEY":       "consumer-key",
+		"CONSUMER_SECRET":    "consumer-secret",
+	} {
+		_ = os.Setenv(key, val)
+		key := key
+		t.Cleanup(func() { _ = os.Unsetenv(key) })
+	}
+}
+
+func TestRequestToken(t *testing.T) {
+	server := stubOAuthServer(t)
+	defer server.Close()
+
+	t.Run("Success", func(t *testing.T) {
+		setOAuthEnv(t, server.URL)
+
+		token, secret, authURL, err := RequestToken()
+
+		assert.Nil(t, err)
+		assert.Equal(t, "request-token", token)
+		assert.Equal(t, "request-secret", secret)
+		assert.Equal(t, server.URL+"/authorize?oauth_token=request-token", authURL)
+	})
+
+	t.Run("Missing callback", func(t *testing.T) {
+		setOAuthEnv(t, server.URL)
+		_ = os.Unsetenv("OAUTH_CALLBACK_URL")
+
+		_, _, _, err := RequestToken()
+
+		assert.EqualError(t, err, "OAUTH_CALLBACK_URL is not configured")
+	})
+}
+
+func TestAccessToken(t *testing.T) {
+	server := stubOAuthServer(t)
+	defer server.Close()
+
+	t.Run("Success", func(t *testing.T) {
+		setOAuthEnv(t, server.URL)
+
+		creds, err := AccessToken("request-token", "request-secret", "the-verifier")
+
+		assert.Nil(t, err)
+		assert.Equal(t, &Credentials{
+			ConsumerKey:       "consumer-key",
+			ConsumerSecret:    "consumer-secret",
+			AccessToken:       "access-token",
+			AccessTokenSecret: "access-secret",
+		}, creds)
+	})
+
+	t.Run("Invalid verifier", func(t *testing.T) {
+		setOAuthEnv(t, server.URL)
+
+		creds, err := AccessToken("request-token", "request-secret", "wrong")
+
+		assert.Nil(t, creds)
+		assert.EqualError(t, err, "exchanging token: oauth1: Server returned status 401")
+	})
+}
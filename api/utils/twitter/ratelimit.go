@@ -0,0 +1,44 @@
+package twitter
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+)
+
+// defaultRateLimitWindow is used when a 429 arrives without a reset header,
+// Twitter rate limits are counted in 15 minute windows
+const defaultRateLimitWindow = 15 * time.Minute
+
+// rateLimit reads the x-rate-limit headers of a response, returning nil when they are missing
+// https://developer.twitter.com/en/docs/twitter-api/rate-limits
+func rateLimit(resp *http.Response) *publisher.RateLimit {
+	if resp == nil {
+		return nil
+	}
+
+	limit, limitErr := strconv.Atoi(resp.Header.Get("x-rate-limit-limit"))
+	remaining, remainingErr := strconv.Atoi(resp.Header.Get("x-rate-limit-remaining"))
+	reset, resetErr := strconv.ParseInt(resp.Header.Get("x-rate-limit-reset"), 10, 64)
+
+	if limitErr != nil || remainingErr != nil || resetErr != nil {
+		return nil
+	}
+
+	return &publisher.RateLimit{Limit: limit, Remaining: remaining, ResetAt: time.Unix(reset, 0)}
+}
+
+// exhaustedRateLimit returns the window for a rate limited response, falling back to
+// a full window from now when the headers are missing
+func exhaustedRateLimit(resp *http.Response) *publisher.RateLimit {
+	window := rateLimit(resp)
+
+	if window == nil {
+		window = &publisher.RateLimit{ResetAt: time.Now().Add(defaultRateLimitWindow)}
+	}
+
+	window.Remaining = 0
+	return window
+}
@@ -0,0 +1,83 @@
+package twitter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/dghubble/go-twitter/twitter"
+)
+
+// apiBaseURL is the REST API host, used for the status parameters go-twitter does not cover
+const apiBaseURL = "https://api.twitter.com/1.1/"
+
+// retweet shares an existing status, the returned status is the retweet itself so deleting it undoes the retweet
+func retweet(c *client, statusId string) (*publisher.Status, error) {
+	id, err := strconv.ParseInt(statusId, 10, 64)
+	if err != nil {
+		return nil, &publisher.Error{Category: publisher.Permanent, Err: fmt.Errorf("twitter: invalid status ID %q", statusId)}
+	}
+
+	tweet, resp, err := c.api.Statuses.Retweet(id, nil)
+	if err != nil {
+		return nil, classifyError(err, resp)
+	}
+
+	status := toStatus(tweet)
+	status.RateLimit = rateLimit(resp)
+
+	return status, nil
+}
+
+// quote posts a status update with the quoted status attached through attachment_url, which keeps
+// the link out of the message so it does not count towards its length. Errors are already classified
+func quote(httpClient *http.Client, message string, params *twitter.StatusUpdateParams, statusId string) (*twitter.Tweet, *http.Response, error) {
+	form := url.Values{}
+	form.Set("status", message)
+	form.Set("attachment_url", "https://twitter.com/i/status/"+statusId)
+
+	if params.InReplyToStatusID != 0 {
+		form.Set("in_reply_to_status_id", strconv.FormatInt(params.InReplyToStatusID, 10))
+	}
+
+	if len(params.MediaIds) > 0 {
+		ids := make([]string, 0, len(params.MediaIds))
+		for _, id := range params.MediaIds {
+			ids = append(ids, strconv.FormatInt(id, 10))
+		}
+		form.Set("media_ids", strings.Join(ids, ","))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, apiBaseURL+"statuses/update.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, classifyError(err, nil)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp, classifyError(err, resp)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, resp, classifyResponse(resp, body)
+	}
+
+	var tweet twitter.Tweet
+	if err = json.Unmarshal(body, &tweet); err != nil {
+		return nil, resp, err
+	}
+
+	return &tweet, resp, nil
+}
@@ -0,0 +1,115 @@
+package twitter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/dghubble/go-twitter/twitter"
+	"github.com/stretchr/testify/assert"
+)
+
+// rewriteTransport sends every request to the test server, whatever host it was made for
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (rt rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// testPublisher returns a publisher whose cached client talks to the test server
+func testPublisher(t *testing.T, handler http.Handler) *Publisher {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	target, _ := url.Parse(server.URL)
+	httpClient := &http.Client{Transport: rewriteTransport{target: target}}
+	credentials := &Credentials{ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: t.Name(), AccessTokenSecret: "ats"}
+
+	accountId := nextTestAccount()
+
+	clientsMu.Lock()
+	clients[accountId] = cachedClient{credentials: *credentials, client: &client{api: twitter.NewClient(httpClient), http: httpClient}}
+	clientsMu.Unlock()
+
+	return &Publisher{accountId: accountId, credentials: credentials}
+}
+
+func TestRetweet(t *testing.T) {
+	t.Run("Retweets the status", func(t *testing.T) {
+		var path string
+		pub := testPublisher(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			_, _ = w.Write([]byte(`{"id_str":"1500000000000000000","created_at":"Fri Sep 10 09:00:00 +0000 2021","user":{"screen_name":"lattr"}}`))
+		}))
+
+		status, err := pub.Publish(&publisher.Post{Repost: "1436255364069150720"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "/1.1/statuses/retweet/1436255364069150720.json", path)
+		assert.Equal(t, "1500000000000000000", status.Id)
+		assert.Equal(t, "https://twitter.com/lattr/status/1500000000000000000", status.Url)
+	})
+
+	t.Run("Already retweeted is a duplicate", func(t *testing.T) {
+		pub := testPublisher(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":[{"code":327,"message":"You have already retweeted this Tweet."}]}`))
+		}))
+
+		_, err := pub.Publish(&publisher.Post{Repost: "1436255364069150720"})
+
+		assert.Equal(t, publisher.Duplicate, publisher.Classify(err))
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		pub := testPublisher(t, http.NotFoundHandler())
+
+		_, err := pub.Publish(&publisher.Post{Repost: "abc"})
+
+		assert.Equal(t, publisher.Permanent, publisher.Classify(err))
+	})
+}
+
+func TestQuote(t *testing.T) {
+	t.Run("Attaches the quoted status", func(t *testing.T) {
+		var form url.Values
+		pub := testPublisher(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/1.1/statuses/update.json", r.URL.Path)
+			_ = r.ParseForm()
+			form = r.PostForm
+			w.Header().Set("x-rate-limit-limit", "300")
+			w.Header().Set("x-rate-limit-remaining", "299")
+			w.Header().Set("x-rate-limit-reset", "1631264400")
+			_, _ = w.Write([]byte(`{"id_str":"1500000000000000001","user":{"screen_name":"lattr"}}`))
+		}))
+
+		status, err := pub.Publish(&publisher.Post{Message: "so true", Quote: "1436255364069150720", ReplyTo: "1400000000000000000"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "so true", form.Get("status"))
+		assert.Equal(t, "https://twitter.com/i/status/1436255364069150720", form.Get("attachment_url"))
+		assert.Equal(t, "1400000000000000000", form.Get("in_reply_to_status_id"))
+		assert.Equal(t, "1500000000000000001", status.Id)
+		assert.Equal(t, 299, status.RateLimit.Remaining)
+	})
+
+	t.Run("Deleted status is permanent", func(t *testing.T) {
+		pub := testPublisher(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":[{"code":44,"message":"attachment_url parameter is invalid."}]}`))
+		}))
+
+		_, err := pub.Publish(&publisher.Post{Message: "so true", Quote: "1436255364069150720"})
+
+		var pubErr *publisher.Error
+		assert.ErrorAs(t, err, &pubErr)
+		assert.Equal(t, publisher.Permanent, pubErr.Category)
+		assert.Equal(t, 44, pubErr.Code)
+	})
+}
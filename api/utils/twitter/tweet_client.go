@@ -1,10 +1,15 @@
 package twitter
 
 import (
-	"log"
+	"fmt"
+	"net/http"
 	"os"
+	"strconv"
+	"sync"
+	"time"
 
 	// other imports
+	"github.com/RemeJuan/lattr/utils/publisher"
 	"github.com/dghubble/go-twitter/twitter"
 	"github.com/dghubble/oauth1"
 )
@@ -12,65 +17,178 @@ import (
 // Credentials stores all of our access/consumer tokens
 // and secret keys needed for authentication against
 // the twitter REST API.
-type credentials struct {
+type Credentials struct {
 	ConsumerKey       string
 	ConsumerSecret    string
 	AccessToken       string
 	AccessTokenSecret string
 }
 
-// getClient is a helper function that will return a twitter client
-// that we can subsequently use to send tweets, or to stream new tweets
-// this will take in a pointer to a Credential struct which will contain
-// everything needed to authenticate and return a pointer to a twitter Client
-// or an error
-func getClient(credentials *credentials) (*twitter.Client, error) {
+// client is a twitter client along with the signed http client used for requests
+// go-twitter does not cover
+type client struct {
+	api  *twitter.Client
+	http *http.Client
+}
+
+// cachedClient is the client of an account along with the credentials it was built from
+type cachedClient struct {
+	credentials Credentials
+	client      *client
+}
+
+var (
+	clientsMu sync.Mutex
+	clients   = map[int64]cachedClient{}
+)
+
+// getClient returns the client of the account, clients are built once and reused for every post
+// until the account's credentials change, the old client is then replaced. The credentials are not
+// verified here, the scheduler checks them on a schedule and stops posting for an account while
+// they are rejected
+func getClient(accountId int64, credentials *Credentials) *client {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	if cached, ok := clients[accountId]; ok && cached.credentials == *credentials {
+		return cached.client
+	}
+
 	// Pass in your consumer key (API Key) and your Consumer Secret (API Secret)
 	config := oauth1.NewConfig(credentials.ConsumerKey, credentials.ConsumerSecret)
 	// Pass in your Access Token and your Access Token Secret
 	token := oauth1.NewToken(credentials.AccessToken, credentials.AccessTokenSecret)
 
 	httpClient := config.Client(oauth1.NoContext, token)
-	client := twitter.NewClient(httpClient)
+	c := &client{api: twitter.NewClient(httpClient), http: httpClient}
+	clients[accountId] = cachedClient{credentials: *credentials, client: c}
 
-	// Verify Credentials
-	verifyParams := &twitter.AccountVerifyParams{
-		SkipStatus:   twitter.Bool(true),
-		IncludeEmail: twitter.Bool(true),
+	return c
+}
+
+func getCredentials() *Credentials {
+	return &Credentials{
+		AccessToken:       os.Getenv("ACCESS_TOKEN"),
+		AccessTokenSecret: os.Getenv("ACCESS_TOKEN_SECRET"),
+		ConsumerKey:       os.Getenv("CONSUMER_KEY"),
+		ConsumerSecret:    os.Getenv("CONSUMER_SECRET"),
+	}
+}
+
+// APIV2 is the API version that posts through POST /2/tweets, any other version posts through v1.1
+const APIV2 = "2"
+
+// Publisher posts to Twitter as the account the credentials belong to, through the v1.1 API
+// unless the publisher is set to v2
+type Publisher struct {
+	accountId   int64
+	credentials *Credentials
+	apiVersion  string
+}
+
+// NewPublisher posts with the default credentials and API version from the environment, they
+// belong to account 0
+func NewPublisher() publisher.Publisher {
+	return &Publisher{credentials: getCredentials(), apiVersion: os.Getenv("TWITTER_API_VERSION")}
+}
+
+// NewAccountPublisher posts with the credentials of a single account through the given API version
+func NewAccountPublisher(accountId int64, credentials *Credentials, apiVersion string) publisher.Publisher {
+	return &Publisher{accountId: accountId, credentials: credentials, apiVersion: apiVersion}
+}
+
+func (p *Publisher) Publish(post *publisher.Post) (*publisher.Status, error) {
+	var err error
+	client := getClient(p.accountId, p.credentials)
+
+	// retweets stay on v1.1 for every account, v2 needs the id of the user retweeting
+	if post.Repost != "" {
+		return retweet(client, post.Repost)
+	}
+
+	// polls cannot be created through v1.1
+	if p.apiVersion == APIV2 || post.Poll != nil {
+		return publishV2(client, post)
+	}
+
+	params := &twitter.StatusUpdateParams{}
+
+	if post.ReplyTo != "" {
+		params.InReplyToStatusID, err = strconv.ParseInt(post.ReplyTo, 10, 64)
+		if err != nil {
+			return nil, &publisher.Error{Category: publisher.Permanent, Err: err}
+		}
+	}
+
+	if len(post.Media) > 0 {
+		params.MediaIds, err = uploadMedia(client.http, post.Media)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var tweet *twitter.Tweet
+	var resp *http.Response
+
+	if post.Quote != "" {
+		tweet, resp, err = quote(client.http, post.Message, params, post.Quote)
+	} else {
+		tweet, resp, err = client.api.Statuses.Update(post.Message, params)
+		err = classifyError(err, resp)
 	}
 
-	// we can retrieve the user and verify if the credentials
-	// we have used successfully allow us to log in!
-	_, _, err := client.Accounts.VerifyCredentials(verifyParams)
 	if err != nil {
 		return nil, err
 	}
 
-	return client, nil
+	status := toStatus(tweet)
+	status.RateLimit = rateLimit(resp)
+
+	return status, nil
 }
 
-func getCredentials() *credentials {
-	return &credentials{
-		AccessToken:       os.Getenv("ACCESS_TOKEN"),
-		AccessTokenSecret: os.Getenv("ACCESS_TOKEN_SECRET"),
-		ConsumerKey:       os.Getenv("CONSUMER_KEY"),
-		ConsumerSecret:    os.Getenv("CONSUMER_SECRET"),
+// toStatus maps the created tweet to a publisher status with its permalink and publish time
+func toStatus(tweet *twitter.Tweet) *publisher.Status {
+	status := &publisher.Status{Id: tweet.IDStr, PostedAt: time.Now()}
+
+	if createdAt, err := tweet.CreatedAtTime(); err == nil {
+		status.PostedAt = createdAt
+	}
+
+	if tweet.User != nil {
+		status.Url = fmt.Sprintf("https://twitter.com/%s/status/%s", tweet.User.ScreenName, tweet.IDStr)
+	} else {
+		status.Url = fmt.Sprintf("https://twitter.com/i/web/status/%s", tweet.IDStr)
 	}
+
+	return status
 }
 
-func CreateTweet(tweet string) error {
-	client, err := getClient(getCredentials())
+func (p *Publisher) Delete(id string) error {
+	if p.apiVersion == APIV2 {
+		return deleteTweetV2(getClient(p.accountId, p.credentials).http, id)
+	}
 
+	statusId, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		log.Println("Error getting Twitter Client")
-		log.Println(err)
 		return err
 	}
 
-	_, _, err = client.Statuses.Update(tweet, nil)
-	if err != nil {
-		return err
+	_, resp, err := getClient(p.accountId, p.credentials).api.Statuses.Destroy(statusId, nil)
+	return classifyError(err, resp)
+}
+
+// Verify checks that Twitter still accepts the credentials
+func (p *Publisher) Verify() error {
+	if p.apiVersion == APIV2 {
+		return verifyV2(getClient(p.accountId, p.credentials).http)
+	}
+
+	verifyParams := &twitter.AccountVerifyParams{
+		SkipStatus:   twitter.Bool(true),
+		IncludeEmail: twitter.Bool(true),
 	}
 
-	return nil
+	_, resp, err := getClient(p.accountId, p.credentials).api.Accounts.VerifyCredentials(verifyParams)
+	return classifyError(err, resp)
 }
@@ -0,0 +1,77 @@
+package twitter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dghubble/go-twitter/twitter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToStatus(t *testing.T) {
+	t.Run("Uses screen name and created time", func(t *testing.T) {
+		tweet := &twitter.Tweet{
+			IDStr:     "1436255364069150720",
+			CreatedAt: "Fri Sep 10 09:00:00 +0000 2021",
+			User:      &twitter.User{ScreenName: "lattr"},
+		}
+
+		status := toStatus(tweet)
+
+		assert.Equal(t, "1436255364069150720", status.Id)
+		assert.Equal(t, "https://twitter.com/lattr/status/1436255364069150720", status.Url)
+		assert.True(t, status.PostedAt.Equal(time.Date(2021, 9, 10, 9, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("Falls back without user", func(t *testing.T) {
+		status := toStatus(&twitter.Tweet{IDStr: "1"})
+
+		assert.Equal(t, "https://twitter.com/i/web/status/1", status.Url)
+		assert.False(t, status.PostedAt.IsZero())
+	})
+}
+
+// testAccounts hands out account IDs so every test gets its own cached client
+var testAccounts int64
+
+func nextTestAccount() int64 {
+	testAccounts++
+	return testAccounts
+}
+
+func TestGetClient(t *testing.T) {
+	credentials := Credentials{ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: "at", AccessTokenSecret: "ats"}
+
+	t.Run("Reuses the client of the account", func(t *testing.T) {
+		accountId := nextTestAccount()
+		same := credentials
+
+		first := getClient(accountId, &credentials)
+		second := getClient(accountId, &same)
+
+		assert.Same(t, first, second)
+	})
+
+	t.Run("Builds a client per account", func(t *testing.T) {
+		first := getClient(nextTestAccount(), &credentials)
+		second := getClient(nextTestAccount(), &credentials)
+
+		assert.NotSame(t, first, second)
+	})
+
+	t.Run("Replaces the client when the credentials change", func(t *testing.T) {
+		accountId := nextTestAccount()
+		rotated := credentials
+		rotated.AccessToken = "rotated"
+
+		first := getClient(accountId, &credentials)
+		second := getClient(accountId, &rotated)
+
+		assert.NotSame(t, first, second)
+		assert.Same(t, second, getClient(accountId, &rotated))
+
+		clientsMu.Lock()
+		defer clientsMu.Unlock()
+		assert.Equal(t, rotated, clients[accountId].credentials)
+	})
+}
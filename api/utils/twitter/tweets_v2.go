@@ -0,0 +1,190 @@
+package twitter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+)
+
+// apiV2BaseURL is the v2 API host, accounts set to v2 post every tweet through it and polls can only
+// be created through v2
+// https://developer.twitter.com/en/docs/twitter-api/tweets/manage-tweets/api-reference/post-tweets
+const apiV2BaseURL = "https://api.twitter.com/2/"
+
+type v2TweetRequest struct {
+	Text         string   `json:"text,omitempty"`
+	Reply        *v2Reply `json:"reply,omitempty"`
+	QuoteTweetId string   `json:"quote_tweet_id,omitempty"`
+	Media        *v2Media `json:"media,omitempty"`
+	Poll         *v2Poll  `json:"poll,omitempty"`
+}
+
+type v2Reply struct {
+	InReplyToTweetId string `json:"in_reply_to_tweet_id"`
+}
+
+type v2Media struct {
+	MediaIds []string `json:"media_ids"`
+}
+
+type v2Poll struct {
+	Options         []string `json:"options"`
+	DurationMinutes int      `json:"duration_minutes"`
+}
+
+type v2TweetResponse struct {
+	Data struct {
+		Id   string `json:"id"`
+		Text string `json:"text"`
+	} `json:"data"`
+}
+
+// v2ErrorResponse is either a problem with a title and detail or a list of request errors
+type v2ErrorResponse struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// publishV2 posts through POST /2/tweets, media is still uploaded through the v1.1 upload endpoint
+func publishV2(c *client, post *publisher.Post) (*publisher.Status, error) {
+	request := v2TweetRequest{Text: post.Message, QuoteTweetId: post.Quote}
+
+	if post.ReplyTo != "" {
+		request.Reply = &v2Reply{InReplyToTweetId: post.ReplyTo}
+	}
+
+	if len(post.Media) > 0 {
+		ids, err := uploadMedia(c.http, post.Media)
+		if err != nil {
+			return nil, err
+		}
+
+		request.Media = &v2Media{}
+		for _, id := range ids {
+			request.Media.MediaIds = append(request.Media.MediaIds, strconv.FormatInt(id, 10))
+		}
+	}
+
+	if post.Poll != nil {
+		request.Poll = &v2Poll{Options: post.Poll.Options, DurationMinutes: post.Poll.DurationMinutes}
+	}
+
+	return createTweet(c.http, request)
+}
+
+// createTweet sends the request to the v2 API, errors are already classified
+func createTweet(httpClient *http.Client, request v2TweetRequest) (*publisher.Status, error) {
+	var created v2TweetResponse
+
+	resp, err := callV2(httpClient, http.MethodPost, "tweets", request, &created)
+	if err != nil {
+		return nil, err
+	}
+
+	if created.Data.Id == "" {
+		return nil, classifyError(errors.New("twitter: the created tweet has no id"), resp)
+	}
+
+	return &publisher.Status{
+		Id:        created.Data.Id,
+		Url:       fmt.Sprintf("https://twitter.com/i/web/status/%s", created.Data.Id),
+		PostedAt:  time.Now(),
+		RateLimit: rateLimit(resp),
+	}, nil
+}
+
+// deleteTweetV2 removes a tweet through DELETE /2/tweets/:id
+func deleteTweetV2(httpClient *http.Client, id string) error {
+	_, err := callV2(httpClient, http.MethodDelete, "tweets/"+url.PathEscape(id), nil, nil)
+	return err
+}
+
+// verifyV2 checks the credentials against GET /2/users/me, which only answers for a user context
+func verifyV2(httpClient *http.Client) error {
+	_, err := callV2(httpClient, http.MethodGet, "users/me", nil, nil)
+	return err
+}
+
+// callV2 sends a JSON request to the v2 API and decodes the response into out when it is set,
+// the request is signed by the OAuth 1.0a client so it runs in the account's user context
+func callV2(httpClient *http.Client, method string, path string, in interface{}, out interface{}) (*http.Response, error) {
+	var payload io.Reader
+
+	if in != nil {
+		body, err := json.Marshal(in)
+		if err != nil {
+			return nil, &publisher.Error{Category: publisher.Permanent, Err: err}
+		}
+		payload = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, apiV2BaseURL+path, payload)
+	if err != nil {
+		return nil, &publisher.Error{Category: publisher.Permanent, Err: err}
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, classifyError(err, nil)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp, classifyError(err, resp)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, classifyV2Response(resp, body)
+	}
+
+	if out != nil {
+		if err = json.Unmarshal(body, out); err != nil {
+			return resp, classifyError(fmt.Errorf("twitter: unexpected response %s", string(body)), resp)
+		}
+	}
+
+	return resp, nil
+}
+
+// classifyV2Response decodes a v2 error body, the category follows the HTTP status as v2 has no
+// error codes. Duplicate content is only reported in the detail of a 403
+func classifyV2Response(resp *http.Response, body []byte) error {
+	var problem v2ErrorResponse
+	message := http.StatusText(resp.StatusCode) + " " + string(body)
+
+	if json.Unmarshal(body, &problem) == nil {
+		switch {
+		case problem.Detail != "":
+			message = problem.Detail
+		case len(problem.Errors) > 0:
+			message = problem.Errors[0].Message
+		case problem.Title != "":
+			message = problem.Title
+		}
+	}
+
+	err := classifyError(errors.New("twitter: "+message), resp)
+
+	if resp.StatusCode == http.StatusForbidden && strings.Contains(strings.ToLower(message), "duplicate content") {
+		err.(*publisher.Error).Category = publisher.Duplicate
+	}
+
+	return err
+}
@@ -0,0 +1,340 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/RemeJuan/lattr/utils/publisher"
+	"github.com/dghubble/go-twitter/twitter"
+	"github.com/dghubble/oauth1"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeV2Server is a minimal v2 API that keeps the tweets, media and deletions it is sent. It only
+// accepts requests signed with OAuth 1.0a for its access token, like the user context endpoints
+type fakeV2Server struct {
+	mu      sync.Mutex
+	token   string
+	tweets  []v2TweetRequest
+	media   [][]byte
+	deleted []string
+	// retweeted is the status id sent to the v1.1 retweet endpoint
+	retweeted string
+}
+
+func newFakeV2Server(t *testing.T, token string) (*fakeV2Server, *httptest.Server) {
+	f := &fakeV2Server{token: token}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/2/tweets", func(w http.ResponseWriter, r *http.Request) {
+		var request v2TweetRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+
+		if request.Text == "" && request.Media == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":[{"message":"$.text: is missing but it is required"}],"title":"Invalid Request"}`))
+			return
+		}
+
+		f.mu.Lock()
+		f.tweets = append(f.tweets, request)
+		id := 1500000000000000000 + len(f.tweets)
+		f.mu.Unlock()
+
+		w.Header().Set("x-rate-limit-limit", "200")
+		w.Header().Set("x-rate-limit-remaining", "199")
+		w.Header().Set("x-rate-limit-reset", "1631264400")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, `{"data":{"id":"%d","text":%q}}`, id, request.Text)
+	})
+	mux.HandleFunc("/2/tweets/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/2/tweets/")
+		if r.Method != http.MethodDelete || id == "404" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"title":"Not Found Error","detail":"Could not find tweet with id: [404]."}`))
+			return
+		}
+
+		f.mu.Lock()
+		f.deleted = append(f.deleted, id)
+		f.mu.Unlock()
+		_, _ = w.Write([]byte(`{"data":{"deleted":true}}`))
+	})
+	mux.HandleFunc("/2/users/me", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"id":"370773112","name":"lattr","username":"lattr"}}`))
+	})
+	mux.HandleFunc("/1.1/statuses/retweet/", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.retweeted = strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/1.1/statuses/retweet/"), ".json")
+		f.mu.Unlock()
+		_, _ = w.Write([]byte(`{"id_str":"1500000000000000099","user":{"screen_name":"lattr"}}`))
+	})
+
+	// media is still uploaded through v1.1, whatever host the upload base URL points at
+	upload := func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("media")
+		assert.Nil(t, err)
+		data, _ := ioutil.ReadAll(file)
+
+		f.mu.Lock()
+		f.media = append(f.media, data)
+		id := 710511363345354752 + len(f.media)
+		f.mu.Unlock()
+
+		_, _ = fmt.Fprintf(w, `{"media_id": %d, "media_id_string": "%d"}`, id, id)
+	}
+	mux.HandleFunc("/1.1/media/upload.json", upload)
+	mux.HandleFunc("/media/upload.json", upload)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "OAuth ") || !strings.Contains(auth, fmt.Sprintf(`oauth_token="%s"`, url.QueryEscape(f.token))) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"title":"Unauthorized","type":"about:blank","status":401,"detail":"Unauthorized"}`))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return f, server
+}
+
+// v2Publisher returns a v2 publisher whose cached client signs its requests with OAuth 1.0a and
+// sends them to the server
+func v2Publisher(t *testing.T, server *httptest.Server, accessToken string) *Publisher {
+	target, _ := url.Parse(server.URL)
+	base := &http.Client{Transport: rewriteTransport{target: target}}
+	credentials := &Credentials{ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: accessToken, AccessTokenSecret: "ats"}
+
+	ctx := context.WithValue(oauth1.NoContext, oauth1.HTTPClient, base)
+	httpClient := oauth1.NewConfig(credentials.ConsumerKey, credentials.ConsumerSecret).
+		Client(ctx, oauth1.NewToken(credentials.AccessToken, credentials.AccessTokenSecret))
+
+	accountId := nextTestAccount()
+
+	clientsMu.Lock()
+	clients[accountId] = cachedClient{credentials: *credentials, client: &client{api: twitter.NewClient(httpClient), http: httpClient}}
+	clientsMu.Unlock()
+
+	return NewAccountPublisher(accountId, credentials, APIV2).(*Publisher)
+}
+
+func TestPublishV2(t *testing.T) {
+	t.Run("Posts the tweet", func(t *testing.T) {
+		fake, server := newFakeV2Server(t, t.Name())
+		pub := v2Publisher(t, server, t.Name())
+
+		status, err := pub.Publish(&publisher.Post{Message: "Hello from v2"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []v2TweetRequest{{Text: "Hello from v2"}}, fake.tweets)
+		assert.Equal(t, "1500000000000000001", status.Id)
+		assert.Equal(t, "https://twitter.com/i/web/status/1500000000000000001", status.Url)
+		assert.Equal(t, 199, status.RateLimit.Remaining)
+	})
+
+	t.Run("Replies", func(t *testing.T) {
+		fake, server := newFakeV2Server(t, t.Name())
+		pub := v2Publisher(t, server, t.Name())
+
+		_, err := pub.Publish(&publisher.Post{Message: "2/2", ReplyTo: "1400000000000000000"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, &v2Reply{InReplyToTweetId: "1400000000000000000"}, fake.tweets[0].Reply)
+	})
+
+	t.Run("Quotes", func(t *testing.T) {
+		fake, server := newFakeV2Server(t, t.Name())
+		pub := v2Publisher(t, server, t.Name())
+
+		_, err := pub.Publish(&publisher.Post{Message: "Worth a read", Quote: "1400000000000000000"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "1400000000000000000", fake.tweets[0].QuoteTweetId)
+	})
+
+	t.Run("Attaches media", func(t *testing.T) {
+		fake, server := newFakeV2Server(t, t.Name())
+		pub := v2Publisher(t, server, t.Name())
+
+		_, err := pub.Publish(&publisher.Post{Message: "Two pictures", Media: []publisher.Media{
+			{MimeType: "image/png", Data: []byte("first")},
+			{MimeType: "image/png", Data: []byte("second")},
+		}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, [][]byte{[]byte("first"), []byte("second")}, fake.media)
+		assert.Equal(t, &v2Media{MediaIds: []string{"710511363345354753", "710511363345354754"}}, fake.tweets[0].Media)
+	})
+
+	t.Run("Posts a poll", func(t *testing.T) {
+		fake, server := newFakeV2Server(t, t.Name())
+		pub := v2Publisher(t, server, t.Name())
+
+		_, err := pub.Publish(&publisher.Post{Message: "Tabs or spaces?", Poll: &publisher.Poll{Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, &v2Poll{Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}, fake.tweets[0].Poll)
+	})
+
+	t.Run("Retweets through v1.1", func(t *testing.T) {
+		fake, server := newFakeV2Server(t, t.Name())
+		pub := v2Publisher(t, server, t.Name())
+
+		status, err := pub.Publish(&publisher.Post{Repost: "1400000000000000000"})
+
+		assert.Nil(t, err)
+		assert.Empty(t, fake.tweets)
+		assert.Equal(t, "1400000000000000000", fake.retweeted)
+		assert.Equal(t, "1500000000000000099", status.Id)
+	})
+
+	t.Run("Invalid request is permanent", func(t *testing.T) {
+		_, server := newFakeV2Server(t, t.Name())
+		pub := v2Publisher(t, server, t.Name())
+
+		_, err := pub.Publish(&publisher.Post{})
+
+		assert.Equal(t, publisher.Permanent, publisher.Classify(err))
+		assert.EqualError(t, err, "twitter: $.text: is missing but it is required")
+	})
+
+	t.Run("Rejected credentials", func(t *testing.T) {
+		fake, server := newFakeV2Server(t, "other")
+		pub := v2Publisher(t, server, t.Name())
+
+		_, err := pub.Publish(&publisher.Post{Message: "Hello from v2"})
+
+		assert.Equal(t, publisher.Auth, publisher.Classify(err))
+		assert.Empty(t, fake.tweets)
+	})
+}
+
+func TestDeleteV2(t *testing.T) {
+	t.Run("Deletes the tweet", func(t *testing.T) {
+		fake, server := newFakeV2Server(t, t.Name())
+		pub := v2Publisher(t, server, t.Name())
+
+		assert.Nil(t, pub.Delete("1500000000000000001"))
+		assert.Equal(t, []string{"1500000000000000001"}, fake.deleted)
+	})
+
+	t.Run("Missing tweet", func(t *testing.T) {
+		_, server := newFakeV2Server(t, t.Name())
+		pub := v2Publisher(t, server, t.Name())
+
+		err := pub.Delete("404")
+
+		var pubErr *publisher.Error
+		assert.ErrorAs(t, err, &pubErr)
+		assert.Equal(t, http.StatusNotFound, pubErr.StatusCode)
+	})
+}
+
+func TestVerifyV2(t *testing.T) {
+	t.Run("Accepted credentials", func(t *testing.T) {
+		_, server := newFakeV2Server(t, t.Name())
+
+		assert.Nil(t, v2Publisher(t, server, t.Name()).Verify())
+	})
+
+	t.Run("Rejected credentials", func(t *testing.T) {
+		_, server := newFakeV2Server(t, "other")
+
+		assert.Equal(t, publisher.Auth, publisher.Classify(v2Publisher(t, server, t.Name()).Verify()))
+	})
+}
+
+func TestPublishPoll(t *testing.T) {
+	poll := &publisher.Poll{Options: []string{"Tabs", "Spaces"}, DurationMinutes: 1440}
+
+	t.Run("Posts the poll through v2", func(t *testing.T) {
+		var path string
+		var body map[string]interface{}
+		pub := testPublisher(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			w.Header().Set("x-rate-limit-limit", "200")
+			w.Header().Set("x-rate-limit-remaining", "199")
+			w.Header().Set("x-rate-limit-reset", "1631264400")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"data":{"id":"1500000000000000002","text":"Tabs or spaces?"}}`))
+		}))
+
+		status, err := pub.Publish(&publisher.Post{Message: "Tabs or spaces?", ReplyTo: "1400000000000000000", Poll: poll})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "/2/tweets", path)
+		assert.Equal(t, "Tabs or spaces?", body["text"])
+		assert.Equal(t, map[string]interface{}{"in_reply_to_tweet_id": "1400000000000000000"}, body["reply"])
+		assert.Equal(t, map[string]interface{}{"options": []interface{}{"Tabs", "Spaces"}, "duration_minutes": float64(1440)}, body["poll"])
+		assert.Equal(t, "1500000000000000002", status.Id)
+		assert.Equal(t, "https://twitter.com/i/web/status/1500000000000000002", status.Url)
+		assert.Equal(t, 199, status.RateLimit.Remaining)
+	})
+
+	t.Run("Duplicate content", func(t *testing.T) {
+		pub := testPublisher(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"title":"Forbidden","status":403,"detail":"You are not allowed to create a Tweet with duplicate content."}`))
+		}))
+
+		_, err := pub.Publish(&publisher.Post{Message: "Tabs or spaces?", Poll: poll})
+
+		assert.Equal(t, publisher.Duplicate, publisher.Classify(err))
+		assert.EqualError(t, err, "twitter: You are not allowed to create a Tweet with duplicate content.")
+	})
+
+	t.Run("Invalid request is permanent", func(t *testing.T) {
+		pub := testPublisher(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":[{"message":"$.poll.duration_minutes: must be at least 5"}],"title":"Invalid Request"}`))
+		}))
+
+		_, err := pub.Publish(&publisher.Post{Message: "Tabs or spaces?", Poll: poll})
+
+		var pubErr *publisher.Error
+		assert.ErrorAs(t, err, &pubErr)
+		assert.Equal(t, publisher.Permanent, pubErr.Category)
+		assert.Equal(t, http.StatusBadRequest, pubErr.StatusCode)
+		assert.EqualError(t, err, "twitter: $.poll.duration_minutes: must be at least 5")
+	})
+
+	t.Run("Rejected credentials", func(t *testing.T) {
+		pub := testPublisher(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"title":"Unauthorized","type":"about:blank","status":401,"detail":"Unauthorized"}`))
+		}))
+
+		_, err := pub.Publish(&publisher.Post{Message: "Tabs or spaces?", Poll: poll})
+
+		assert.Equal(t, publisher.Auth, publisher.Classify(err))
+	})
+
+	t.Run("Rate limited", func(t *testing.T) {
+		pub := testPublisher(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("x-rate-limit-limit", "200")
+			w.Header().Set("x-rate-limit-remaining", "0")
+			w.Header().Set("x-rate-limit-reset", "1631264400")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"title":"Too Many Requests","detail":"Too Many Requests","type":"about:blank","status":429}`))
+		}))
+
+		_, err := pub.Publish(&publisher.Post{Message: "Tabs or spaces?", Poll: poll})
+
+		var pubErr *publisher.Error
+		assert.ErrorAs(t, err, &pubErr)
+		assert.Equal(t, publisher.Transient, pubErr.Category)
+		assert.Equal(t, 0, pubErr.RateLimit.Remaining)
+	})
+}
@@ -0,0 +1,138 @@
+package twittertext
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Split breaks text that is longer than limit into parts that each fit, numbered with a " 1/n" suffix.
+// Parts end on a sentence where possible, otherwise on a word, and links and emoji are never split.
+// Text that already fits is returned as is
+func Split(text string, limit int) []string {
+	text = Normalize(text)
+
+	if WeightedLength(text) <= limit {
+		return []string{text}
+	}
+
+	var parts []string
+
+	// the suffix grows with the number of parts, so pack again until its width is known
+	for digits := 1; ; digits++ {
+		budget := limit - suffixLength(digits)
+		if budget < 1 {
+			budget = 1
+		}
+
+		parts = pack(text, Segments(text), budget*scale)
+
+		if len(strconv.Itoa(len(parts))) <= digits {
+			break
+		}
+	}
+
+	for i := range parts {
+		parts[i] = fmt.Sprintf("%s %d/%d", parts[i], i+1, len(parts))
+	}
+
+	return parts
+}
+
+// suffixLength is the widest " i/n" suffix when n has the given number of digits
+func suffixLength(digits int) int {
+	return 2 + 2*digits
+}
+
+// pack fills parts up to budget, breaking at the best boundary before the first segment that does not fit
+func pack(text string, segments []Segment, budget int) []string {
+	var parts []string
+
+	for start := 0; start < len(segments); {
+		for start < len(segments) && isSpace(text, segments[start]) {
+			start++
+		}
+
+		if start == len(segments) {
+			break
+		}
+
+		var weight int
+		end := start
+
+		for end < len(segments) && (end == start || weight+segments[end].Weight <= budget) {
+			weight += segments[end].Weight
+			end++
+		}
+
+		if end < len(segments) {
+			end = breakBefore(text, segments, start, end)
+		}
+
+		parts = append(parts, strings.TrimSpace(text[segments[start].Start:segments[end-1].End]))
+		start = end
+	}
+
+	return parts
+}
+
+// breakBefore picks where a part that cannot reach limit ends. A sentence end is preferred when it
+// keeps at least half of the part, then the last space and, for a single word that does not fit,
+// the segment boundary at the limit
+func breakBefore(text string, segments []Segment, start, limit int) int {
+	word := -1
+
+	for i := limit; i > start; i-- {
+		if !isSpace(text, segments[i]) {
+			continue
+		}
+
+		if word < 0 {
+			word = i
+		}
+
+		if i-start < (limit-start)/2 {
+			break
+		}
+
+		if endsSentence(text, segments, start, i) {
+			return i
+		}
+	}
+
+	if word > 0 {
+		return word
+	}
+
+	return limit
+}
+
+// endsSentence reports whether the space at segment i ends a sentence or paragraph
+func endsSentence(text string, segments []Segment, start, i int) bool {
+	if r, _ := utf8.DecodeRuneInString(text[segments[i].Start:]); r == '\n' {
+		return true
+	}
+
+	for j := i - 1; j >= start; j-- {
+		r, _ := utf8.DecodeLastRuneInString(text[segments[j].Start:segments[j].End])
+
+		switch r {
+		case '"', '\'', ')', '”', '’':
+			// closing quotes and brackets after the full stop
+			continue
+		case '.', '!', '?', '…', '。', '！', '？':
+			return true
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+func isSpace(text string, segment Segment) bool {
+	r, size := utf8.DecodeRuneInString(text[segment.Start:])
+	return size == segment.End-segment.Start && unicode.IsSpace(r)
+}
@@ -0,0 +1,87 @@
+package twittertext
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	sentence := "The quick brown fox jumps over the lazy dog."
+
+	t.Run("Short text is not split", func(t *testing.T) {
+		assert.Equal(t, []string{"short"}, Split("short", MaxWeightedLength))
+	})
+
+	t.Run("Numbers every part", func(t *testing.T) {
+		text := strings.TrimSpace(strings.Repeat(sentence+" ", 10))
+
+		parts := Split(text, MaxWeightedLength)
+
+		assert.Len(t, parts, 2)
+		assert.True(t, strings.HasSuffix(parts[0], " 1/2"))
+		assert.True(t, strings.HasSuffix(parts[1], " 2/2"))
+		for _, part := range parts {
+			assert.LessOrEqual(t, WeightedLength(part), MaxWeightedLength)
+		}
+	})
+
+	t.Run("Ends parts on a sentence", func(t *testing.T) {
+		text := strings.TrimSpace(strings.Repeat(sentence+" ", 10))
+
+		parts := Split(text, MaxWeightedLength)
+
+		assert.True(t, strings.HasSuffix(parts[0], "dog. 1/2"))
+		assert.True(t, strings.HasPrefix(parts[1], "The quick"))
+	})
+
+	t.Run("Falls back to words", func(t *testing.T) {
+		text := strings.Repeat("word ", 100)
+
+		parts := Split(text, 100)
+
+		for _, part := range parts {
+			assert.NotContains(t, part, "wor ")
+			assert.LessOrEqual(t, WeightedLength(part), 100)
+		}
+		assert.Equal(t, strings.Repeat("word ", 100), strings.Join(stripSuffixes(parts), " ")+" ")
+	})
+
+	t.Run("Never breaks a URL", func(t *testing.T) {
+		link := "https://example.com/" + strings.Repeat("path/", 20)
+		text := strings.Repeat("a", 255) + " " + link + " " + strings.Repeat("b", 50)
+
+		parts := Split(text, MaxWeightedLength)
+
+		assert.Len(t, parts, 2)
+		assert.NotContains(t, parts[0], "https")
+		assert.True(t, strings.HasPrefix(parts[1], link))
+	})
+
+	t.Run("Words longer than a part are cut", func(t *testing.T) {
+		parts := Split(strings.Repeat("字", 200), MaxWeightedLength)
+
+		assert.Len(t, parts, 2)
+		assert.Equal(t, MaxWeightedLength, WeightedLength(parts[0]))
+	})
+
+	t.Run("Widens the suffix for ten or more parts", func(t *testing.T) {
+		parts := Split(strings.Repeat("word ", 300), 60)
+
+		assert.Greater(t, len(parts), 9)
+		assert.True(t, strings.HasSuffix(parts[0], fmt.Sprintf(" 1/%d", len(parts))))
+		for _, part := range parts {
+			assert.LessOrEqual(t, WeightedLength(part), 60)
+		}
+	})
+}
+
+func stripSuffixes(parts []string) []string {
+	stripped := make([]string, len(parts))
+	for i, part := range parts {
+		stripped[i] = part[:strings.LastIndex(part, " ")]
+	}
+	return stripped
+}
@@ -0,0 +1,23 @@
+package twittertext
+
+import "regexp"
+
+var (
+	statusIdPattern = regexp.MustCompile(`^[0-9]{1,19}$`)
+	// statusURLPattern matches status permalinks, including the mobile and x.com hosts
+	statusURLPattern = regexp.MustCompile(`(?i)^(?:https?://)?(?:www\.|mobile\.)?(?:twitter|x)\.com/(?:[a-z0-9_]{1,15}|i(?:/web)?)/status(?:es)?/([0-9]{1,19})/?(?:[?#].*)?$`)
+)
+
+// StatusID returns the ID of the status a reference points to, the reference is either
+// the ID itself or a link to the status
+func StatusID(reference string) (string, bool) {
+	if statusIdPattern.MatchString(reference) {
+		return reference, true
+	}
+
+	if match := statusURLPattern.FindStringSubmatch(reference); match != nil {
+		return match[1], true
+	}
+
+	return "", false
+}
@@ -0,0 +1,38 @@
+package twittertext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusID(t *testing.T) {
+	tests := []struct {
+		name      string
+		reference string
+		expected  string
+		ok        bool
+	}{
+		{"ID", "1436255364069150720", "1436255364069150720", true},
+		{"Permalink", "https://twitter.com/lattr/status/1436255364069150720", "1436255364069150720", true},
+		{"Query and fragment", "https://twitter.com/lattr/status/1436255364069150720?s=20#reply", "1436255364069150720", true},
+		{"Mobile host", "https://mobile.twitter.com/lattr/status/1436255364069150720/", "1436255364069150720", true},
+		{"X host", "https://x.com/lattr/status/1436255364069150720", "1436255364069150720", true},
+		{"Web intent", "https://twitter.com/i/web/status/1436255364069150720", "1436255364069150720", true},
+		{"Without scheme", "twitter.com/lattr/status/1436255364069150720", "1436255364069150720", true},
+		{"Profile", "https://twitter.com/lattr", "", false},
+		{"Photo", "https://twitter.com/lattr/status/1436255364069150720/photo/1", "", false},
+		{"Other host", "https://example.com/lattr/status/1436255364069150720", "", false},
+		{"Not a number", "abc", "", false},
+		{"Empty", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := StatusID(tt.reference)
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, id)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Package twittertext measures tweets the way Twitter does, following the weighted length
+// rules of twitter-text v3
+// https://developer.twitter.com/en/docs/counting-characters
+package twittertext
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxWeightedLength is the longest a tweet can be, 280 Latin characters or 140 CJK characters
+const MaxWeightedLength = 280
+
+const (
+	// scale is the weight of a single character, weights are kept in hundredths of a character
+	scale = 100
+	// defaultWeight applies to every code point outside of weightedRanges, such as CJK characters
+	defaultWeight = 200
+	emojiWeight   = 200
+	// URLLength is the length every link counts as, regardless of its own length, as links are shortened with t.co
+	URLLength = 23
+)
+
+type weightedRange struct {
+	start  rune
+	end    rune
+	weight int
+}
+
+// weightedRanges are the code points that count as a single character: Latin, Greek, Cyrillic,
+// Hebrew, Arabic, Indic scripts and the general punctuation spaces and quotes
+var weightedRanges = []weightedRange{
+	{start: 0x0000, end: 0x10FF, weight: 100},
+	{start: 0x2000, end: 0x200D, weight: 100},
+	{start: 0x2010, end: 0x201F, weight: 100},
+	{start: 0x2032, end: 0x2037, weight: 100},
+}
+
+// Segment is a byte range of the normalized text that is counted as a whole, a link,
+// an emoji sequence or a single code point
+type Segment struct {
+	Start int
+	End   int
+	// Weight is in hundredths of a character
+	Weight int
+}
+
+// Normalize returns the NFC form of text, which is the form Twitter counts and stores
+func Normalize(text string) string {
+	return norm.NFC.String(text)
+}
+
+// WeightedLength returns the length Twitter counts for text
+func WeightedLength(text string) int {
+	var weight int
+
+	for _, s := range Segments(Normalize(text)) {
+		weight += s.Weight
+	}
+
+	return weight / scale
+}
+
+// Segments splits already normalized text into the pieces it is counted by, in order. Links and emoji
+// sequences are single segments so they are never split apart
+func Segments(text string) []Segment {
+	segments := make([]Segment, 0, len(text))
+	links := URLs(text)
+
+	for i := 0; i < len(text); {
+		if len(links) > 0 && links[0][0] == i {
+			segments = append(segments, Segment{Start: i, End: links[0][1], Weight: URLLength * scale})
+			i = links[0][1]
+			links = links[1:]
+			continue
+		}
+
+		if end, ok := emojiSequence(text, i); ok {
+			segments = append(segments, Segment{Start: i, End: end, Weight: emojiWeight})
+			i = end
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(text[i:])
+		segments = append(segments, Segment{Start: i, End: i + size, Weight: runeWeight(r)})
+		i += size
+	}
+
+	return segments
+}
+
+func runeWeight(r rune) int {
+	for _, wr := range weightedRanges {
+		if r >= wr.start && r <= wr.end {
+			return wr.weight
+		}
+	}
+
+	return defaultWeight
+}
+
+// emojiSequence reports whether an emoji starts at byte i and where the sequence ends. Modifiers,
+// variation selectors, keycaps, tags and ZWJ joined emoji all belong to the emoji before them
+func emojiSequence(text string, i int) (int, bool) {
+	r, size := utf8.DecodeRuneInString(text[i:])
+	end := i + size
+
+	switch {
+	case isRegionalIndicator(r):
+		// a flag is a pair of regional indicators
+		if next, nextSize := utf8.DecodeRuneInString(text[end:]); isRegionalIndicator(next) {
+			end += nextSize
+		}
+		return end, true
+	case IsPictographic(r):
+	case (r >= '0' && r <= '9') || r == '#' || r == '*' || r == 0x00A9 || r == 0x00AE:
+		// keycaps and the copyright signs are only emoji in their emoji presentation
+		next, _ := utf8.DecodeRuneInString(text[end:])
+		if next != 0xFE0F && next != 0x20E3 {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	for end < len(text) {
+		next, nextSize := utf8.DecodeRuneInString(text[end:])
+
+		switch {
+		case next == 0xFE0F, next == 0x20E3, next >= 0x1F3FB && next <= 0x1F3FF, next >= 0xE0020 && next <= 0xE007F:
+			end += nextSize
+		case next == 0x200D:
+			joined, joinedSize := utf8.DecodeRuneInString(text[end+nextSize:])
+			if !IsPictographic(joined) {
+				return end, true
+			}
+			end += nextSize + joinedSize
+		default:
+			return end, true
+		}
+	}
+
+	return end, true
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// IsPictographic approximates the Extended_Pictographic property outside of the single weight ranges,
+// the copyright and keycap emoji are handled separately as their text forms count as one character
+func IsPictographic(r rune) bool {
+	switch {
+	case r == 0x203C, r == 0x2049, r == 0x2122, r == 0x2139:
+		return true
+	case r >= 0x2194 && r <= 0x21AA, r >= 0x2300 && r <= 0x23FF, r >= 0x25A0 && r <= 0x27BF:
+		return true
+	case r >= 0x2934 && r <= 0x2935, r >= 0x2B05 && r <= 0x2B55, r == 0x3030, r == 0x303D, r == 0x3297, r == 0x3299:
+		return true
+	case r >= 0x1F000 && r <= 0x1FAFF:
+		return true
+	default:
+		return false
+	}
+}
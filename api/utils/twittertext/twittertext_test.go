@@ -0,0 +1,62 @@
+package twittertext
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightedLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected int
+	}{
+		{"Empty", "", 0},
+		{"ASCII", "hello world", 11},
+		{"Latin accents", "café crème", 10},
+		{"Decomposed accents are normalized", "cafe\u0301", 4},
+		{"CJK counts double", "你好世界", 8},
+		{"Japanese kana", "こんにちは", 10},
+		{"Hangul", "한국어", 6},
+		{"Curly quotes count once", "“quoted”", 8},
+		{"Emoji counts double", "hi 👋", 5},
+		{"Skin tone modifier", "👍🏽", 2},
+		{"ZWJ family", "\U0001f468\u200d\U0001f469\u200d\U0001f467", 2},
+		{"Flag", "🇿🇦", 2},
+		{"Keycap", "1\ufe0f\u20e3", 2},
+		{"Text copyright sign", "© 2021", 6},
+		{"Emoji copyright sign", "\u00a9\ufe0f", 2},
+		{"URL", "read https://example.com/a/very/long/path/that/is/much/longer/than/twenty/three", 28},
+		{"Bare domain", "see example.com", 27},
+		{"Two URLs", "https://a.co and http://b.co", 51},
+		{"Trailing punctuation", "(see https://example.com).", 30},
+		{"Bare domains after a path", "https://a.com/x b.com c.com", 71},
+		{"Bare domain running into a scheme", "b.com/https://a.com c.com", 47},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, WeightedLength(tt.text))
+		})
+	}
+
+	t.Run("Limit", func(t *testing.T) {
+		assert.Equal(t, MaxWeightedLength, WeightedLength(strings.Repeat("a", 280)))
+		assert.Equal(t, MaxWeightedLength, WeightedLength(strings.Repeat("字", 140)))
+	})
+}
+
+func TestSegments(t *testing.T) {
+	t.Run("Keeps links and emoji whole", func(t *testing.T) {
+		text := "a 👍🏽 https://example.com"
+
+		segments := Segments(text)
+
+		assert.Len(t, segments, 5)
+		assert.Equal(t, "👍🏽", text[segments[2].Start:segments[2].End])
+		assert.Equal(t, "https://example.com", text[segments[4].Start:segments[4].End])
+		assert.Equal(t, URLLength*scale, segments[4].Weight)
+	})
+}
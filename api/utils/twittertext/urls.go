@@ -0,0 +1,121 @@
+package twittertext
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	schemePattern = regexp.MustCompile(`(?i)https?://[^\s<>"]+`)
+	// schemelessPattern matches bare domains, which Twitter only links for generic top level domains
+	// or when a path follows, e.g. example.com or bit.ly/abc
+	schemelessPattern = regexp.MustCompile(`(?i)(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+(?:(?:com|net|org|edu|gov|info|biz|app|dev|xyz)\b(?:/[^\s<>"]*)?|[a-z]{2}/[^\s<>"]*)`)
+)
+
+// urlTrailers are punctuation marks that end a sentence rather than the link in front of them
+const urlTrailers = ".,;:!?'\"]}"
+
+// URLs returns the byte ranges of the links in text, in order. Ranges never overlap, a bare domain
+// running into a link with a scheme is merged with it
+func URLs(text string) [][2]int {
+	var urls [][2]int
+
+	for _, match := range schemePattern.FindAllStringIndex(text, -1) {
+		if end := trimURL(text, match[0], match[1]); end > match[0] {
+			urls = append(urls, [2]int{match[0], end})
+		}
+	}
+
+	withScheme := urls
+
+	for _, match := range schemelessPattern.FindAllStringIndex(text, -1) {
+		if within(withScheme, match[0]) || !urlBoundary(text, match[0]) {
+			continue
+		}
+
+		end := trimURL(text, match[0], match[1])
+		urls = insertURL(urls, [2]int{match[0], end})
+	}
+
+	return mergeURLs(urls)
+}
+
+// trimURL drops trailing punctuation from a match, closing parentheses are kept while they
+// close one opened in the link, as in Wikipedia links
+func trimURL(text string, start, end int) int {
+	for end > start {
+		last := text[end-1]
+
+		switch {
+		case strings.IndexByte(urlTrailers, last) >= 0:
+			end--
+		case last == ')' && strings.Count(text[start:end], "(") < strings.Count(text[start:end], ")"):
+			end--
+		default:
+			return end
+		}
+	}
+
+	return end
+}
+
+// urlBoundary reports whether a bare domain starts a word, so e-mail addresses and
+// the tail of other words are not counted as links
+func urlBoundary(text string, start int) bool {
+	if start == 0 {
+		return true
+	}
+
+	prev, _ := utf8.DecodeLastRuneInString(text[:start])
+	return prev != '@' && prev != '.' && prev != '-' && prev != '/' && !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+}
+
+func within(urls [][2]int, pos int) bool {
+	for _, u := range urls {
+		if pos >= u[0] && pos < u[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// insertURL adds a link keeping the list ordered by position
+func insertURL(urls [][2]int, url [2]int) [][2]int {
+	i := len(urls)
+	for i > 0 && urls[i-1][0] > url[0] {
+		i--
+	}
+
+	urls = append(urls, [2]int{})
+	copy(urls[i+1:], urls[i:])
+	urls[i] = url
+
+	return urls
+}
+
+// mergeURLs joins overlapping ranges of an ordered list into one
+func mergeURLs(urls [][2]int) [][2]int {
+	if len(urls) < 2 {
+		return urls
+	}
+
+	merged := urls[:1]
+
+	for _, url := range urls[1:] {
+		last := &merged[len(merged)-1]
+
+		if url[0] < last[1] {
+			if url[1] > last[1] {
+				last[1] = url[1]
+			}
+			continue
+		}
+
+		merged = append(merged, url)
+	}
+
+	return merged
+}
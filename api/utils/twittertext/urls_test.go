@@ -0,0 +1,39 @@
+package twittertext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLs(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{"None", "no links here", nil},
+		{"Scheme", "go to https://example.com/path?q=1 now", []string{"https://example.com/path?q=1"}},
+		{"Trailing punctuation", "see http://example.com.", []string{"http://example.com"}},
+		{"Wikipedia parentheses", "https://en.wikipedia.org/wiki/Go_(programming_language)", []string{"https://en.wikipedia.org/wiki/Go_(programming_language)"}},
+		{"Wrapped in parentheses", "(https://example.com)", []string{"https://example.com"}},
+		{"Bare generic domain", "visit lattr.app today", []string{"lattr.app"}},
+		{"Bare country domain needs a path", "bit.ly/abc but not example.de", []string{"bit.ly/abc"}},
+		{"E-mail address", "mail dev@lattr.app", nil},
+		{"Ordered", "example.com and https://a.io", []string{"example.com", "https://a.io"}},
+		{"Scheme and bare", "https://a.io then example.org/x", []string{"https://a.io", "example.org/x"}},
+		{"Bare after a path", "https://a.com/x b.com c.com", []string{"https://a.com/x", "b.com", "c.com"}},
+		{"Bare running into a scheme", "b.com/https://a.com c.com", []string{"b.com/https://a.com", "c.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, u := range URLs(tt.text) {
+				got = append(got, tt.text[u[0]:u[1]])
+			}
+
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
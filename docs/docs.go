@@ -31,44 +31,28 @@ var doc = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/token": {
-            "post": {
+        "/accounts": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
-                    },
-                    {
-                        "OAuth2Application": [
-                            "token:create"
-                        ]
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Tokens"
-                ],
-                "summary": "Create a new token",
-                "parameters": [
-                    {
-                        "description": "Create Token",
-                        "name": "token",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/domain.Token"
-                        }
-                    }
+                    "Accounts"
                 ],
+                "summary": "Fetches a list of all accounts",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/domain.Token"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Account"
+                            }
                         }
                     },
                     "403": {
@@ -83,39 +67,21 @@ var doc = `{
                             "$ref": "#/definitions/error_utils.MessageErrStruct"
                         }
                     },
-                    "422": {
-                        "description": "Unprocessable Entity",
-                        "schema": {
-                            "$ref": "#/definitions/error_utils.MessageErrStruct"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/error_utils.MessageErrStruct"
                         }
-                    },
-                    "501": {
-                        "description": "Not Implemented",
-                        "schema": {
-                            "$ref": "#/definitions/error_utils.MessageErrStruct"
-                        }
                     }
                 }
-            }
-        },
-        "/token/list": {
+            },
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
-                    },
-                    {
-                        "OAuth2Application": [
-                            "token:read"
-                        ]
                     }
                 ],
+                "description": "The credentials are stored for the scheduler and are never returned by the API. Mastodon accounts only need an instance URL and access token, Bluesky accounts a handle with an app password as the access token",
                 "consumes": [
                     "application/json"
                 ],
@@ -123,17 +89,25 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Tokens"
+                    "Accounts"
+                ],
+                "summary": "Add a Twitter, Mastodon or Bluesky account to post as",
+                "parameters": [
+                    {
+                        "description": "Create Account",
+                        "name": "account",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.Account"
+                        }
+                    }
                 ],
-                "summary": "Fetches a list of all available tokens",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/domain.Token"
-                            }
+                            "$ref": "#/definitions/domain.Account"
                         }
                     },
                     "403": {
@@ -142,12 +116,6 @@ var doc = `{
                             "$ref": "#/definitions/error_utils.MessageErrStruct"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/error_utils.MessageErrStruct"
-                        }
-                    },
                     "422": {
                         "description": "Unprocessable Entity",
                         "schema": {
@@ -159,29 +127,18 @@ var doc = `{
                         "schema": {
                             "$ref": "#/definitions/error_utils.MessageErrStruct"
                         }
-                    },
-                    "501": {
-                        "description": "Not Implemented",
-                        "schema": {
-                            "$ref": "#/definitions/error_utils.MessageErrStruct"
-                        }
                     }
                 }
             }
         },
-        "/token/{id}": {
-            "put": {
+        "/accounts/connect": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
-                    },
-                    {
-                        "OAuth2Application": [
-                            "token:read"
-                        ]
                     }
                 ],
-                "description": "Using the given ID, a new token is generated with either the default timing\nOr the timing specified in the request payload",
+                "description": "Returns the Twitter URL the account owner has to visit to authorize lattr,\nonce authorized Twitter redirects back to the callback which stores the account",
                 "consumes": [
                     "application/json"
                 ],
@@ -189,26 +146,25 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Tokens"
+                    "Accounts"
                 ],
-                "summary": "Resets the token specified by the provided ID",
+                "summary": "Start connecting a Twitter account",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Token ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Name of the account to connect",
+                        "name": "connection",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.Connection"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/domain.Token"
-                            }
+                            "$ref": "#/definitions/domain.Connection"
                         }
                     },
                     "403": {
@@ -217,12 +173,6 @@ var doc = `{
                             "$ref": "#/definitions/error_utils.MessageErrStruct"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/error_utils.MessageErrStruct"
-                        }
-                    },
                     "422": {
                         "description": "Unprocessable Entity",
                         "schema": {
@@ -234,50 +184,46 @@ var doc = `{
                         "schema": {
                             "$ref": "#/definitions/error_utils.MessageErrStruct"
                         }
-                    },
-                    "501": {
-                        "description": "Not Implemented",
-                        "schema": {
-                            "$ref": "#/definitions/error_utils.MessageErrStruct"
-                        }
                     }
                 }
-            },
-            "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    },
-                    {
-                        "OAuth2Application": [
-                            "token:read"
-                        ]
-                    }
-                ],
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/accounts/connect/callback": {
+            "get": {
+                "description": "Stores the authorized account, the request token identifies the pending connection",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Tokens"
+                    "Accounts"
                 ],
-                "summary": "Fetches an existing token by ID",
+                "summary": "OAuth callback Twitter redirects to after the account owner responds",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Token ID",
-                        "name": "id",
-                        "in": "path",
+                        "type": "string",
+                        "description": "Request token",
+                        "name": "oauth_token",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Verifier, present when the owner authorized the app",
+                        "name": "oauth_verifier",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request token, present when the owner declined",
+                        "name": "denied",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/domain.Token"
+                            "$ref": "#/definitions/domain.Account"
                         }
                     },
                     "403": {
@@ -303,40 +249,28 @@ var doc = `{
                         "schema": {
                             "$ref": "#/definitions/error_utils.MessageErrStruct"
                         }
-                    },
-                    "501": {
-                        "description": "Not Implemented",
-                        "schema": {
-                            "$ref": "#/definitions/error_utils.MessageErrStruct"
-                        }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/accounts/{id}": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
-                    },
-                    {
-                        "OAuth2Application": [
-                            "token:read"
-                        ]
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Tokens"
+                    "Accounts"
                 ],
-                "summary": "Deletes the specified token",
+                "summary": "Fetches an existing account by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Token ID",
+                        "description": "Account ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -344,9 +278,9 @@ var doc = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "{message: \"success\"}",
+                        "description": "OK",
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/domain.Account"
                         }
                     },
                     "403": {
@@ -372,23 +306,16 @@ var doc = `{
                         "schema": {
                             "$ref": "#/definitions/error_utils.MessageErrStruct"
                         }
-                    },
-                    "501": {
-                        "description": "Not Implemented",
-                        "schema": {
-                            "$ref": "#/definitions/error_utils.MessageErrStruct"
-                        }
                     }
                 }
-            }
-        },
-        "/tweets/all/{userId}": {
-            "get": {
+            },
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
+                "description": "The credentials are only replaced when a full set for the account's network is provided, the network cannot be changed",
                 "consumes": [
                     "application/json"
                 ],
@@ -396,26 +323,32 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Tweets"
+                    "Accounts"
                 ],
-                "summary": "List all Tweets by UserId",
+                "summary": "Renames an account or replaces its credentials",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "userId",
+                        "description": "Account ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Update Account",
+                        "name": "account",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.Account"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/domain.Tweet"
-                            }
+                            "$ref": "#/definitions/domain.Account"
                         }
                     },
                     "403": {
@@ -441,54 +374,37 @@ var doc = `{
                         "schema": {
                             "$ref": "#/definitions/error_utils.MessageErrStruct"
                         }
-                    },
-                    "501": {
-                        "description": "Not Implemented",
-                        "schema": {
-                            "$ref": "#/definitions/error_utils.MessageErrStruct"
-                        }
                     }
                 }
-            }
-        },
-        "/tweets/create": {
-            "post": {
+            },
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
-                    },
-                    {
-                        "OAuth2Application": [
-                            "tweet:create"
-                        ]
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Accounts that tweets or destinations still post as cannot be deleted",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Tweets"
+                    "Accounts"
                 ],
-                "summary": "Create a new tweet",
+                "summary": "Deletes the specified account",
                 "parameters": [
                     {
-                        "description": "Create tweet",
-                        "name": "tweet",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/domain.Tweet"
-                        }
+                        "type": "integer",
+                        "description": "Account ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "{status: \"deleted\"}",
                         "schema": {
-                            "$ref": "#/definitions/domain.Tweet"
+                            "type": "object"
                         }
                     },
                     "403": {
@@ -514,47 +430,30 @@ var doc = `{
                         "schema": {
                             "$ref": "#/definitions/error_utils.MessageErrStruct"
                         }
-                    },
-                    "501": {
-                        "description": "Not Implemented",
-                        "schema": {
-                            "$ref": "#/definitions/error_utils.MessageErrStruct"
-                        }
                     }
                 }
             }
         },
-        "/tweets/{id}": {
+        "/admin/health": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Reports the last check of each account's credentials, responds with 503 while any of them are rejected. Tweets for an account with rejected credentials are held until the credentials are accepted again",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Tweets"
-                ],
-                "summary": "Fetch Tweet by ID",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Tweet ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Admin"
                 ],
+                "summary": "Show the health of the posting credentials",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/domain.Tweet"
+                            "$ref": "#/definitions/scheduler.Health"
                         }
                     },
                     "403": {
@@ -563,36 +462,102 @@ var doc = `{
                             "$ref": "#/definitions/error_utils.MessageErrStruct"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/scheduler.Health"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/status": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reports the current rate limit window and the state of each account's credentials",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Show the state of the posting queue",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/scheduler.Status"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/error_utils.MessageErrStruct"
                         }
+                    }
+                }
+            }
+        },
+        "/outbox": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "With DRY_RUN enabled the scheduler records every post it would have published in the outbox instead of posting it, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List the posts recorded in dry-run mode",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.OutboxEntry"
+                            }
+                        }
                     },
-                    "422": {
-                        "description": "Unprocessable Entity",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/error_utils.MessageErrStruct"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/error_utils.MessageErrStruct"
                         }
                     },
-                    "501": {
-                        "description": "Not Implemented",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/error_utils.MessageErrStruct"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/token": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
+                    },
+                    {
+                        "OAuth2Application": [
+                            "token:create"
+                        ]
                     }
                 ],
                 "consumes": [
@@ -602,24 +567,17 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Tweets"
+                    "Tokens"
                 ],
-                "summary": "Updated a single tweet",
+                "summary": "Create a new token",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Tweet ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Update tweet",
-                        "name": "tweet",
+                        "description": "Create Token",
+                        "name": "token",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/domain.Tweet"
+                            "$ref": "#/definitions/domain.Token"
                         }
                     }
                 ],
@@ -627,7 +585,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/domain.Tweet"
+                            "$ref": "#/definitions/domain.Token"
                         }
                     },
                     "403": {
@@ -661,11 +619,18 @@ var doc = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/token/list": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
+                    },
+                    {
+                        "OAuth2Application": [
+                            "token:read"
+                        ]
                     }
                 ],
                 "consumes": [
@@ -675,13 +640,79 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Tweets"
+                    "Tokens"
                 ],
-                "summary": "Deletes a single tweet",
+                "summary": "Fetches a list of all available tokens",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Token"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            }
+        },
+        "/token/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "OAuth2Application": [
+                            "token:read"
+                        ]
+                    }
+                ],
+                "description": "Using the given ID, a new token is generated with either the default timing\nOr the timing specified in the request payload",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tokens"
+                ],
+                "summary": "Resets the token specified by the provided ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Tweet ID",
+                        "description": "Token ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -689,9 +720,12 @@ var doc = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "{message: \"success\"}",
+                        "description": "OK",
                         "schema": {
-                            "type": "object"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Token"
+                            }
                         }
                     },
                     "403": {
@@ -725,9 +759,7 @@ var doc = `{
                         }
                     }
                 }
-            }
-        },
-        "/webhook": {
+            },
             "post": {
                 "security": [
                     {
@@ -735,7 +767,7 @@ var doc = `{
                     },
                     {
                         "OAuth2Application": [
-                            "token:create"
+                            "token:read"
                         ]
                     }
                 ],
@@ -746,25 +778,23 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Tweets"
+                    "Tokens"
                 ],
-                "summary": "Create a new Tweet via webhook",
+                "summary": "Fetches an existing token by ID",
                 "parameters": [
                     {
-                        "description": "Create Tweet",
-                        "name": "tweet",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/domain.Tweet"
-                        }
+                        "type": "integer",
+                        "description": "Token ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/domain.Tweet"
+                            "$ref": "#/definitions/domain.Token"
                         }
                     },
                     "403": {
@@ -798,59 +828,1467 @@ var doc = `{
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "domain.Token": {
-            "type": "object",
-            "properties": {
-                "createdAt": {
-                    "type": "string",
-                    "example": "2022-09-09T10:29:07.559636Z"
-                },
-                "expiry": {
-                    "type": "string",
-                    "example": "2022-09-09T10:29:07.559636Z"
-                },
-                "id": {
-                    "type": "integer",
-                    "example": 1
-                },
-                "modified": {
-                    "type": "string",
-                    "example": "2022-09-09T10:29:07.559636Z"
-                },
-                "name": {
-                    "type": "string",
-                    "example": "IFTTT"
-                },
-                "scopes": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
                     },
-                    "example": [
-                        "[token:create]"
-                    ]
-                },
-                "token": {
-                    "type": "string",
-                    "example": "1d6dcc23-51c4-4540-b659-b2834efad5bc"
-                }
-            }
-        },
-        "domain.Tweet": {
-            "type": "object",
-            "properties": {
-                "createdAt": {
+                    {
+                        "OAuth2Application": [
+                            "token:read"
+                        ]
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tokens"
+                ],
+                "summary": "Deletes the specified token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Token ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{message: \"success\"}",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            }
+        },
+        "/tweets/all/{userId}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tweets"
+                ],
+                "summary": "List all Tweets by UserId",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Tweet"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            }
+        },
+        "/tweets/create": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "OAuth2Application": [
+                            "tweet:create"
+                        ]
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tweets"
+                ],
+                "summary": "Create a new tweet",
+                "parameters": [
+                    {
+                        "description": "Create tweet",
+                        "name": "tweet",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.Tweet"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Split a message that is too long into a numbered thread, the created tweets are returned as a list",
+                        "name": "split",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "The created tweet, or a list of the created tweets when split is set",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Tweet"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            }
+        },
+        "/tweets/requeue": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tweets"
+                ],
+                "summary": "Re-queue all failed tweets for posting",
+                "responses": {
+                    "200": {
+                        "description": "{requeued: 2}",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            }
+        },
+        "/tweets/thread": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tweets"
+                ],
+                "summary": "Create a thread of tweets posted as chained replies",
+                "parameters": [
+                    {
+                        "description": "Create thread",
+                        "name": "thread",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.Thread"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Tweet"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            }
+        },
+        "/tweets/thread/{threadId}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tweets"
+                ],
+                "summary": "Fetch all tweets of a thread in posting order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Thread ID",
+                        "name": "threadId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Tweet"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            }
+        },
+        "/tweets/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tweets"
+                ],
+                "summary": "Fetch Tweet by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tweet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Tweet"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tweets"
+                ],
+                "summary": "Updated a single tweet",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tweet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update tweet",
+                        "name": "tweet",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.Tweet"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Tweet"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tweets"
+                ],
+                "summary": "Deletes a single tweet",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tweet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{message: \"success\"}",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            }
+        },
+        "/tweets/{id}/destinations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tweets"
+                ],
+                "summary": "List the destinations a tweet is cross-posted to",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tweet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Destination"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            }
+        },
+        "/tweets/{id}/destinations/{destinationId}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Only the status can be changed, to Skipped or Pending, posted destinations cannot be changed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tweets"
+                ],
+                "summary": "Skips a destination or re-queues it for another attempt",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tweet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Destination ID",
+                        "name": "destinationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Destination",
+                        "name": "destination",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.Destination"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Destination"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            }
+        },
+        "/tweets/{id}/media": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Media"
+                ],
+                "summary": "List the images attached to a tweet",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tweet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Media"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Media"
+                ],
+                "summary": "Attach an image to a tweet",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tweet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Image to attach",
+                        "name": "media",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Alt text describing the image",
+                        "name": "altText",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Media"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            }
+        },
+        "/tweets/{id}/media/{mediaId}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Media"
+                ],
+                "summary": "Remove an image from a tweet",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tweet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Media ID",
+                        "name": "mediaId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{status: \"deleted\"}",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            }
+        },
+        "/tweets/{id}/requeue": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tweets"
+                ],
+                "summary": "Re-queue a failed tweet for posting",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tweet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Tweet"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhook": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "OAuth2Application": [
+                            "token:create"
+                        ]
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tweets"
+                ],
+                "summary": "Create a new Tweet via webhook",
+                "parameters": [
+                    {
+                        "description": "Create Tweet",
+                        "name": "tweet",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.Tweet"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Split a message that is too long into a numbered thread, the created tweets are returned as a list",
+                        "name": "split",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "The created tweet, or a list of the created tweets when split is set",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Tweet"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/error_utils.MessageErrStruct"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "domain.Account": {
+            "type": "object",
+            "properties": {
+                "accessToken": {
+                    "type": "string",
+                    "example": "370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb"
+                },
+                "accessTokenSecret": {
+                    "type": "string",
+                    "example": "LswwdoUaIvS8ltyTt5jkRh4J50vUPVVHtR2YPi5kE"
+                },
+                "apiVersion": {
+                    "type": "string",
+                    "example": "2"
+                },
+                "consumerKey": {
+                    "type": "string",
+                    "example": "xvz1evFS4wEEPTGEFPHBog"
+                },
+                "consumerSecret": {
+                    "type": "string",
+                    "example": "L8qq9PZyRg6ieKGEKhZolGC0vJWLw8iEJ88DRdyOg"
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-09-09T10:29:07.559636Z"
+                },
+                "handle": {
+                    "type": "string",
+                    "example": "lattr.bsky.social"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "instanceUrl": {
+                    "type": "string",
+                    "example": "https://mastodon.social"
+                },
+                "modified": {
+                    "type": "string",
+                    "example": "2022-09-09T10:29:07.559636Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "lattr"
+                },
+                "network": {
+                    "type": "string",
+                    "example": "twitter"
+                }
+            }
+        },
+        "domain.Connection": {
+            "type": "object",
+            "properties": {
+                "authorizationUrl": {
+                    "type": "string",
+                    "example": "https://api.twitter.com/oauth/authorize?oauth_token=NPcudxy0yU5T3tBzho7iCotZ3cnetKwcTIRlX0iwRl0"
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-09-09T10:29:07.559636Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "lattr"
+                },
+                "requestToken": {
+                    "type": "string",
+                    "example": "NPcudxy0yU5T3tBzho7iCotZ3cnetKwcTIRlX0iwRl0"
+                }
+            }
+        },
+        "domain.Destination": {
+            "type": "object",
+            "properties": {
+                "accountId": {
+                    "description": "AccountId is the account to post as, destinations without one use the default credentials",
+                    "type": "integer",
+                    "example": 1
+                },
+                "attempts": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-09-09T10:29:07.559636Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "lastError": {
+                    "type": "string",
+                    "example": "mastodon: 503 Service Unavailable"
+                },
+                "modified": {
+                    "type": "string",
+                    "example": "2022-09-09T10:29:07.559636Z"
+                },
+                "nextAttemptAt": {
+                    "type": "string",
+                    "example": "2022-09-09T10:35:01.559636Z"
+                },
+                "postedAt": {
+                    "type": "string",
+                    "example": "2022-09-09T10:30:01.559636Z"
+                },
+                "remoteId": {
+                    "type": "string",
+                    "example": "1436255364069150720"
+                },
+                "remoteUrl": {
+                    "type": "string",
+                    "example": "https://twitter.com/lattr/status/1436255364069150720"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "Pending"
+                },
+                "tweetId": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "domain.Media": {
+            "type": "object",
+            "properties": {
+                "altText": {
+                    "type": "string",
+                    "example": "A cat asleep on a keyboard"
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-09-09T10:29:07.559636Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "mimeType": {
+                    "type": "string",
+                    "example": "image/png"
+                },
+                "modified": {
+                    "type": "string",
+                    "example": "2022-09-09T10:29:07.559636Z"
+                },
+                "tweetId": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "domain.OutboxEntry": {
+            "type": "object",
+            "properties": {
+                "accountId": {
+                    "description": "AccountId is the account the post would have been published as, 0 is the default account",
+                    "type": "integer",
+                    "example": 1
+                },
+                "contentWarning": {
+                    "type": "string",
+                    "example": "Spoilers"
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-09-09T10:30:01.559636Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "kind": {
+                    "description": "Kind and Target are the kind of tweet and the status it retweets or quotes",
+                    "type": "string",
+                    "example": "original"
+                },
+                "mediaCount": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "message": {
+                    "type": "string",
+                    "example": "TIL: Life is awesome"
+                },
+                "network": {
+                    "type": "string",
+                    "example": "twitter"
+                },
+                "pollDurationMinutes": {
+                    "type": "integer",
+                    "example": 1440
+                },
+                "pollOptions": {
+                    "description": "PollOptions and PollDurationMinutes are the poll posted with the post, they are empty without one",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Tabs",
+                        "Spaces"
+                    ]
+                },
+                "replyTo": {
+                    "description": "ReplyTo is the outbox ID of the post this one replies to, set for every part of a thread but the first",
+                    "type": "string",
+                    "example": "1"
+                },
+                "target": {
+                    "type": "string",
+                    "example": "1436255364069150720"
+                },
+                "visibility": {
+                    "type": "string",
+                    "example": "unlisted"
+                }
+            }
+        },
+        "domain.Poll": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-09-09T10:29:07.559636Z"
+                },
+                "durationMinutes": {
+                    "description": "DurationMinutes is how long the poll stays open once it is posted",
+                    "type": "integer",
+                    "example": 1440
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "options": {
+                    "description": "Options are the choices voted on, updating a tweet with an empty list removes its poll",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Tabs",
+                        "Spaces"
+                    ]
+                },
+                "tweetId": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "domain.Thread": {
+            "type": "object",
+            "properties": {
+                "accountId": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "contentWarning": {
+                    "type": "string",
+                    "example": "Spoilers"
+                },
+                "deleteAfter": {
+                    "type": "string",
+                    "example": "24h"
+                },
+                "deleteAt": {
+                    "type": "string",
+                    "example": "2022-09-10T10:29:07.559636Z"
+                },
+                "destinations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Destination"
+                    }
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "First part of the thread",
+                        "Second part of the thread"
+                    ]
+                },
+                "postTime": {
+                    "type": "string",
+                    "example": "2022-09-09T10:29:07.559636Z"
+                },
+                "userId": {
+                    "type": "string",
+                    "example": "IFTTT"
+                },
+                "visibility": {
+                    "type": "string",
+                    "example": "unlisted"
+                }
+            }
+        },
+        "domain.Token": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-09-09T10:29:07.559636Z"
+                },
+                "expiry": {
+                    "type": "string",
+                    "example": "2022-09-09T10:29:07.559636Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "modified": {
+                    "type": "string",
+                    "example": "2022-09-09T10:29:07.559636Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "IFTTT"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "[token:create]"
+                    ]
+                },
+                "token": {
+                    "type": "string",
+                    "example": "1d6dcc23-51c4-4540-b659-b2834efad5bc"
+                }
+            }
+        },
+        "domain.Tweet": {
+            "type": "object",
+            "properties": {
+                "accountId": {
+                    "description": "AccountId is the account the tweet is posted as, tweets without one use the default credentials",
+                    "type": "integer",
+                    "example": 1
+                },
+                "attempts": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "claimedBy": {
+                    "description": "ClaimedBy is the scheduler replica posting the tweet, its claim lapses at LeaseExpiresAt so\nanother replica takes the tweet over when it stops before finishing",
+                    "type": "string",
+                    "example": "web.1-3f2a9c1e"
+                },
+                "contentWarning": {
+                    "type": "string",
+                    "example": "Spoilers"
+                },
+                "createdAt": {
                     "type": "string",
                     "example": "2022-09-09T10:29:07.559636Z"
                 },
+                "deleteAfter": {
+                    "description": "DeleteAfter removes the tweet again this long after it was posted, DeleteAt removes it at a fixed time.\nDeleteAt is filled in from DeleteAfter once the tweet is posted",
+                    "type": "string",
+                    "example": "24h"
+                },
+                "deleteAt": {
+                    "type": "string",
+                    "example": "2022-09-10T10:29:07.559636Z"
+                },
+                "destinations": {
+                    "description": "Destinations cross-post the tweet to several accounts, they are stored separately from the tweet",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Destination"
+                    }
+                },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
+                "kind": {
+                    "description": "Kind defaults to original, retweets and quotes refer to an existing status with Target",
+                    "type": "string",
+                    "example": "quote"
+                },
+                "lastError": {
+                    "type": "string",
+                    "example": "twitter: 130 Over capacity"
+                },
+                "leaseExpiresAt": {
+                    "type": "string",
+                    "example": "2022-09-09T10:45:01.559636Z"
+                },
                 "message": {
                     "type": "string",
                     "example": "TIL: Life is awesome"
@@ -859,17 +2297,55 @@ var doc = `{
                     "type": "string",
                     "example": "2022-09-09T10:29:07.559636Z"
                 },
+                "nextAttemptAt": {
+                    "type": "string",
+                    "example": "2022-09-09T10:35:01.559636Z"
+                },
+                "poll": {
+                    "description": "Poll is posted with the tweet, it is stored in its own table and loaded with the tweet",
+                    "$ref": "#/definitions/domain.Poll"
+                },
                 "postTime": {
                     "type": "string",
                     "example": "2022-09-09T10:29:07.559636Z"
                 },
+                "postedAt": {
+                    "type": "string",
+                    "example": "2022-09-09T10:30:01.559636Z"
+                },
+                "remoteId": {
+                    "type": "string",
+                    "example": "1436255364069150720"
+                },
+                "remoteUrl": {
+                    "type": "string",
+                    "example": "https://twitter.com/lattr/status/1436255364069150720"
+                },
                 "status": {
                     "type": "string",
                     "example": "Pending"
                 },
+                "target": {
+                    "description": "Target is the status that is retweeted or quoted, given as its ID or link and stored as the ID",
+                    "type": "string",
+                    "example": "1436255364069150720"
+                },
+                "threadId": {
+                    "type": "string",
+                    "example": "1d6dcc23-51c4-4540-b659-b2834efad5bc"
+                },
+                "threadPosition": {
+                    "type": "integer",
+                    "example": 0
+                },
                 "userId": {
                     "type": "string",
                     "example": "IFTTT"
+                },
+                "visibility": {
+                    "description": "Visibility and ContentWarning are only used by destinations that support them, such as Mastodon",
+                    "type": "string",
+                    "example": "unlisted"
                 }
             }
         },
@@ -889,6 +2365,139 @@ var doc = `{
                     "example": 400
                 }
             }
+        },
+        "publisher.RateLimit": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "remaining": {
+                    "type": "integer"
+                },
+                "resetAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "scheduler.AccountRateLimit": {
+            "type": "object",
+            "properties": {
+                "accountId": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "limited": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "window": {
+                    "$ref": "#/definitions/publisher.RateLimit"
+                }
+            }
+        },
+        "scheduler.Credentials": {
+            "type": "object",
+            "properties": {
+                "accountId": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "checkedAt": {
+                    "type": "string",
+                    "example": "2022-09-09T10:30:01.559636Z"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "twitter: 89 Invalid or expired token."
+                },
+                "invalidSince": {
+                    "description": "InvalidSince is when the credentials were first rejected",
+                    "type": "string",
+                    "example": "2022-09-09T10:30:01.559636Z"
+                },
+                "valid": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "scheduler.Health": {
+            "type": "object",
+            "properties": {
+                "credentials": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scheduler.Credentials"
+                    }
+                },
+                "healthy": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "scheduler.Leadership": {
+            "type": "object",
+            "properties": {
+                "checkedAt": {
+                    "type": "string",
+                    "example": "2022-09-09T10:30:31.559636Z"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error when trying to save data: connection refused"
+                },
+                "leader": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "since": {
+                    "description": "Since is when this replica became the leader",
+                    "type": "string",
+                    "example": "2022-09-09T10:30:01.559636Z"
+                }
+            }
+        },
+        "scheduler.Status": {
+            "type": "object",
+            "properties": {
+                "credentials": {
+                    "description": "Credentials lists the last known state of each account's credentials",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scheduler.Credentials"
+                    }
+                },
+                "dryRun": {
+                    "description": "DryRun is set when posts are recorded in the outbox instead of being published",
+                    "type": "boolean"
+                },
+                "leadership": {
+                    "description": "Leadership reports whether this replica holds the leader lock and runs the singleton jobs",
+                    "$ref": "#/definitions/scheduler.Leadership"
+                },
+                "nextRun": {
+                    "description": "NextRun is when the dispatcher next posts the tweets that are due",
+                    "type": "string"
+                },
+                "rateLimited": {
+                    "description": "RateLimited is set while any account waits for its rate limit window to reset",
+                    "type": "boolean"
+                },
+                "rateLimits": {
+                    "description": "RateLimits lists the last rate limit window reported for each account",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scheduler.AccountRateLimit"
+                    }
+                },
+                "replica": {
+                    "description": "Replica identifies this process in the claims it holds on the tweets it posts",
+                    "type": "string",
+                    "example": "web.1-3f2a9c1e"
+                }
+            }
         }
     },
     "securityDefinitions": {